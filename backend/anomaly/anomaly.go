@@ -0,0 +1,174 @@
+// Package anomaly spots sudden changes in an app's ratings, such as a spike in one star reviews after a release, by
+// comparing the most recent window of reviews with a rolling baseline of the windows before it using z-scores.
+package anomaly
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/marcuswu/app-reviews/config"
+	"github.com/marcuswu/app-reviews/models"
+)
+
+// Kinds of anomaly
+const (
+	LowRatingSpike = "low-rating-spike" // more low rated reviews than usual
+	RatingDrop     = "rating-drop"      // a lower average rating than usual
+)
+
+// LowRating is the highest rating counted as a low rating
+const LowRating = 2
+
+// minBaselineWindows is how many baseline windows the cache must cover before low rating volume is compared
+const minBaselineWindows = 2
+
+// minDeviation stops a baseline of low rating counts that barely varies from turning small differences into large
+// z-scores, in reviews per window
+const minDeviation = 1.0
+
+// minRatingDeviation does the same for the standard deviation of individual ratings, in stars
+const minRatingDeviation = 0.5
+
+// Options configures Detect. Zero values use the defaults from config.
+type Options struct {
+	Window     time.Duration // the recent window checked for anomalies
+	Baseline   time.Duration // how far back the baseline reaches, including the recent window
+	Threshold  float64       // z-score at which the recent window is anomalous
+	MinReviews int           // reviews the recent window needs before it can be anomalous
+}
+
+// Anomaly is a recent change in an app's ratings
+type Anomaly struct {
+	Kind     string
+	Score    float64           // z-score of the recent window against the baseline
+	Recent   float64           // low rated reviews in the recent window, or its average rating
+	Baseline float64           // the same measurement averaged over the baseline
+	Reviews  models.AppReviews // low rated reviews in the recent window, newest first
+}
+
+// Message describes the anomaly in one line
+func (a Anomaly) Message() string {
+	switch a.Kind {
+	case LowRatingSpike:
+		return fmt.Sprintf("%.0f reviews rated %d stars or less recently, against %.1f usually (z-score %.1f)",
+			a.Recent, LowRating, a.Baseline, a.Score)
+	case RatingDrop:
+		return fmt.Sprintf("average rating dropped to %.2f recently, against %.2f usually (z-score %.1f)",
+			a.Recent, a.Baseline, a.Score)
+	}
+	return a.Kind
+}
+
+// Detect returns the anomalies in reviews for the window ending at now
+func Detect(reviews models.AppReviews, now time.Time, options Options) []Anomaly {
+	if options.Window <= 0 {
+		options.Window = time.Duration(config.ANOMALY_WINDOW_HOURS) * time.Hour
+	}
+	if options.Baseline <= 0 {
+		options.Baseline = time.Duration(config.ANOMALY_BASELINE_DAYS) * 24 * time.Hour
+	}
+	if options.Threshold <= 0 {
+		options.Threshold = config.ANOMALY_Z_SCORE
+	}
+	if options.MinReviews < 1 {
+		options.MinReviews = config.ANOMALY_MIN_REVIEWS
+	}
+
+	recentStart := now.Add(-options.Window)
+	baselineStart := now.Add(-options.Baseline)
+	recent := models.AppReviews{}
+	low := models.AppReviews{}
+	baseline := models.AppReviews{}
+	oldest := now
+	for _, review := range reviews {
+		if review.Updated.Before(oldest) {
+			oldest = review.Updated
+		}
+		switch {
+		case review.Updated.After(now):
+		case review.Updated.After(recentStart):
+			recent = append(recent, review)
+			if review.Rating <= LowRating {
+				low = append(low, review)
+			}
+		case review.Updated.After(baselineStart):
+			baseline = append(baseline, review)
+		}
+	}
+	if len(recent) < options.MinReviews {
+		return nil
+	}
+	sort.Slice(low, func(i, j int) bool { return low[i].Updated.After(low[j].Updated) })
+
+	anomalies := []Anomaly{}
+	counts := lowRatingCounts(baseline, recentStart, baselineStart, oldest, options.Window)
+	if len(counts) >= minBaselineWindows && len(low) >= options.MinReviews {
+		mean, deviation := meanDeviation(counts)
+		score := (float64(len(low)) - mean) / math.Max(deviation, minDeviation)
+		if score >= options.Threshold {
+			anomalies = append(anomalies, Anomaly{Kind: LowRatingSpike, Score: score, Recent: float64(len(low)),
+				Baseline: mean, Reviews: low})
+		}
+	}
+
+	if len(baseline) >= options.MinReviews {
+		mean, deviation := meanDeviation(ratings(baseline))
+		recentMean, _ := meanDeviation(ratings(recent))
+		// The recent average is of several ratings, so it varies less than a single rating does
+		standardError := math.Max(deviation, minRatingDeviation) / math.Sqrt(float64(len(recent)))
+		score := (mean - recentMean) / standardError
+		if score >= options.Threshold && len(low) > 0 {
+			anomalies = append(anomalies, Anomaly{Kind: RatingDrop, Score: score, Recent: recentMean, Baseline: mean,
+				Reviews: low})
+		}
+	}
+	return anomalies
+}
+
+// lowRatingCounts counts the low rated baseline reviews in each window between baselineStart and recentStart, stopping
+// at the first window that isn't fully covered by reviews going back to oldest
+func lowRatingCounts(baseline models.AppReviews, recentStart time.Time, baselineStart time.Time, oldest time.Time,
+	window time.Duration) []float64 {
+	counts := []float64{}
+	for start := recentStart.Add(-window); !start.Before(baselineStart); start = start.Add(-window) {
+		if start.Before(oldest) {
+			break
+		}
+		end := start.Add(window)
+		count := 0
+		for _, review := range baseline {
+			if review.Rating <= LowRating && review.Updated.After(start) && !review.Updated.After(end) {
+				count++
+			}
+		}
+		counts = append(counts, float64(count))
+	}
+	return counts
+}
+
+func ratings(reviews models.AppReviews) []float64 {
+	result := make([]float64, len(reviews))
+	for i, review := range reviews {
+		result[i] = float64(review.Rating)
+	}
+	return result
+}
+
+// meanDeviation returns the mean and population standard deviation of values
+func meanDeviation(values []float64) (float64, float64) {
+	if len(values) < 1 {
+		return 0, 0
+	}
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	mean := sum / float64(len(values))
+	variance := 0.0
+	for _, value := range values {
+		variance += (value - mean) * (value - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
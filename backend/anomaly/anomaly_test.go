@@ -0,0 +1,61 @@
+package anomaly
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/marcuswu/app-reviews/models"
+)
+
+// steadyReviews returns a review every four hours for days, alternating between 4 and 5 stars with one 1 star
+// review a day
+func steadyReviews(now time.Time, days int) models.AppReviews {
+	reviews := models.AppReviews{}
+	for hour := 1; hour <= days*24; hour += 4 {
+		rating := 4 + hour/4%2
+		if hour%24 == 1 {
+			rating = 1
+		}
+		reviews = append(reviews, models.AppReview{Id: fmt.Sprintf("steady-%d", hour), Rating: rating,
+			Updated: now.Add(-time.Duration(hour) * time.Hour)})
+	}
+	return reviews
+}
+
+func TestDetect(t *testing.T) {
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	options := Options{Window: 24 * time.Hour, Baseline: 14 * 24 * time.Hour, Threshold: 3, MinReviews: 5}
+
+	if anomalies := Detect(steadyReviews(now, 14), now, options); len(anomalies) != 0 {
+		t.Errorf("expected no anomalies in steady reviews, got %+v", anomalies)
+	}
+
+	spike := steadyReviews(now, 14)
+	for i := 0; i < 8; i++ {
+		spike = append(spike, models.AppReview{Id: fmt.Sprintf("spike-%d", i), Rating: 1,
+			Updated: now.Add(-time.Duration(i*30+10) * time.Minute)})
+	}
+	anomalies := Detect(spike, now, options)
+	if len(anomalies) != 2 || anomalies[0].Kind != LowRatingSpike || anomalies[1].Kind != RatingDrop {
+		t.Fatalf("expected a low rating spike and a rating drop, got %+v", anomalies)
+	}
+	if spikeReviews := anomalies[0].Reviews; len(spikeReviews) != 9 || spikeReviews[0].Id != "spike-0" {
+		t.Errorf("expected the 9 recent 1 star reviews newest first, got %d", len(spikeReviews))
+	}
+	if anomalies[0].Recent != 9 || anomalies[0].Baseline != 1 {
+		t.Errorf("expected 9 recent low ratings against 1 a day, got %.1f against %.1f", anomalies[0].Recent,
+			anomalies[0].Baseline)
+	}
+
+	// Without enough history there is no baseline to compare against
+	if anomalies := Detect(spike[len(spike)-8:], now, options); len(anomalies) != 0 {
+		t.Errorf("expected no anomalies without a baseline, got %+v", anomalies)
+	}
+
+	// A spike in a short cache still counts as a rating drop when there are enough earlier reviews
+	short := append(steadyReviews(now, 2), spike[len(spike)-8:]...)
+	if anomalies := Detect(short, now, options); len(anomalies) != 1 || anomalies[0].Kind != RatingDrop {
+		t.Errorf("expected only a rating drop with one baseline window, got %+v", anomalies)
+	}
+}
@@ -0,0 +1,246 @@
+// Package auth authenticates API requests with API keys loaded from a JSON key file. Each key has scopes, a rate
+// limit and a daily quota.
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/marcuswu/app-reviews/clock"
+	"github.com/marcuswu/app-reviews/ratelimit"
+)
+
+// Scope is a permission granted to an API key
+type Scope string
+
+const (
+	ScopeRead  Scope = "read"  // read reviews and feeds
+	ScopeAdmin Scope = "admin" // read status and metrics. Implies read.
+)
+
+// Key is an API key entry in the key file
+type Key struct {
+	Name       string  `json:"name"`
+	Key        string  `json:"key"`
+	Scopes     []Scope `json:"scopes"`
+	RateLimit  int     `json:"rateLimit"`  // requests per minute, zero for no limit
+	DailyQuota int     `json:"dailyQuota"` // requests per UTC day, zero for no quota
+}
+
+// HasScope reports whether the key grants a scope
+func (k *Key) HasScope(scope Scope) bool {
+	for _, granted := range k.Scopes {
+		if granted == scope || granted == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+type contextKey struct{}
+
+// WithKey returns a context carrying the API key a request was authenticated with
+func WithKey(ctx context.Context, key *Key) context.Context {
+	return context.WithValue(ctx, contextKey{}, key)
+}
+
+// KeyFromContext returns the API key a request was authenticated with by Require
+func KeyFromContext(ctx context.Context) (*Key, bool) {
+	key, ok := ctx.Value(contextKey{}).(*Key)
+	return key, ok
+}
+
+type keyFile struct {
+	Keys []Key `json:"keys"`
+}
+
+type quotaUsage struct {
+	day  string
+	used int
+}
+
+// Keyring holds the API keys allowed to use the server and tracks their usage
+type Keyring struct {
+	clock    clock.Clock
+	keys     map[[sha256.Size]byte]*Key
+	limiters map[string]*ratelimit.Limiter // by key name
+
+	mu    sync.Mutex
+	usage map[string]*quotaUsage // by key name
+}
+
+// LoadKeys reads a key file such as {"keys": [{"name": "frontend", "key": "...", "scopes": ["read"]}]}
+func LoadKeys(stream io.Reader, c clock.Clock) (*Keyring, error) {
+	file := keyFile{}
+	if err := json.NewDecoder(stream).Decode(&file); err != nil {
+		return nil, fmt.Errorf("could not read api keys: %w", err)
+	}
+
+	keyring := &Keyring{
+		clock:    c,
+		keys:     map[[sha256.Size]byte]*Key{},
+		limiters: map[string]*ratelimit.Limiter{},
+		usage:    map[string]*quotaUsage{},
+	}
+	for i := range file.Keys {
+		key := &file.Keys[i]
+		if len(key.Name) < 1 || len(key.Key) < 16 {
+			return nil, fmt.Errorf("api key %d needs a name and a key of at least 16 characters", i+1)
+		}
+		for _, scope := range key.Scopes {
+			if scope != ScopeRead && scope != ScopeAdmin {
+				return nil, fmt.Errorf("api key %s has unknown scope %q", key.Name, scope)
+			}
+		}
+		if _, ok := keyring.limiters[key.Name]; ok {
+			return nil, fmt.Errorf("api key name %s is used more than once", key.Name)
+		}
+
+		// Keys are looked up by hash so comparisons don't leak timing information about the key
+		keyring.keys[sha256.Sum256([]byte(key.Key))] = key
+		keyring.limiters[key.Name] = nil
+		if key.RateLimit > 0 {
+			keyring.limiters[key.Name] = ratelimit.NewLimiter(c, key.RateLimit, 0)
+		}
+	}
+
+	return keyring, nil
+}
+
+// LoadKeysFile reads a key file from disk
+func LoadKeysFile(path string, c clock.Clock) (*Keyring, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return LoadKeys(file, c)
+}
+
+// Authenticate returns the key matching a presented API key
+func (kr *Keyring) Authenticate(presented string) (*Key, bool) {
+	key, ok := kr.keys[sha256.Sum256([]byte(presented))]
+	return key, ok
+}
+
+// useQuota counts a request against a key's daily quota. It returns the requests left today and when the quota
+// resets, or false if the quota is used up.
+func (kr *Keyring) useQuota(key *Key) (int, time.Time, bool) {
+	now := kr.clock.Now().UTC()
+	reset := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	if key.DailyQuota < 1 {
+		return math.MaxInt, reset, true
+	}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	day := now.Format(time.DateOnly)
+	usage, ok := kr.usage[key.Name]
+	if !ok || usage.day != day {
+		usage = &quotaUsage{day: day}
+		kr.usage[key.Name] = usage
+	}
+	if usage.used >= key.DailyQuota {
+		return 0, reset, false
+	}
+	usage.used++
+	return key.DailyQuota - usage.used, reset, true
+}
+
+// Usage is the outcome of counting a request against a key's rate limit and daily quota
+type Usage struct {
+	Allowed        bool
+	Reason         string        // why the request was refused: "rate limit" or "daily quota"
+	RetryAfter     time.Duration // how long until a refused request may be retried
+	RateLimit      int           // requests per minute, zero for no limit
+	RateRemaining  int
+	Quota          int       // requests per UTC day, zero for no quota
+	QuotaRemaining int       // unset when the rate limit refused the request first
+	QuotaReset     time.Time // unset when the rate limit refused the request first
+}
+
+// Use counts a request against a key's rate limit and then its daily quota. Every transport calls it once per
+// request so a key's limits hold however it is used.
+func (kr *Keyring) Use(key *Key) Usage {
+	usage := Usage{Allowed: true, Quota: key.DailyQuota}
+	if limiter := kr.limiters[key.Name]; limiter != nil {
+		result := limiter.Allow(key.Name)
+		usage.RateLimit = result.Limit
+		usage.RateRemaining = result.Remaining
+		if !result.Allowed {
+			usage.Allowed = false
+			usage.Reason = "rate limit"
+			usage.RetryAfter = result.RetryAfter
+			return usage
+		}
+	}
+
+	remaining, reset, ok := kr.useQuota(key)
+	usage.QuotaRemaining = remaining
+	usage.QuotaReset = reset
+	if !ok {
+		usage.Allowed = false
+		usage.Reason = "daily quota"
+		usage.RetryAfter = reset.Sub(kr.clock.Now())
+	}
+	return usage
+}
+
+// requestKey returns the API key presented with a request: an Authorization bearer token, an X-API-Key header or
+// an api_key query parameter
+func requestKey(req *http.Request) string {
+	if bearer, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(bearer)
+	}
+	if key := req.Header.Get("X-API-Key"); len(key) > 0 {
+		return key
+	}
+	return req.URL.Query().Get("api_key")
+}
+
+// Require wraps a handler so that it is only served to requests with a valid API key granting scope that is
+// within its rate limit and daily quota. Responds 401 without a valid key, 403 without the scope and 429 when
+// over the limit or quota.
+func (kr *Keyring) Require(scope Scope, handler http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		key, ok := kr.Authenticate(requestKey(req))
+		if !ok {
+			res.Header().Set("WWW-Authenticate", `Bearer realm="app-reviews"`)
+			http.Error(res, "a valid API key is required", http.StatusUnauthorized)
+			return
+		}
+		if !key.HasScope(scope) {
+			http.Error(res, fmt.Sprintf("API key %s does not have the %s scope", key.Name, scope), http.StatusForbidden)
+			return
+		}
+
+		usage := kr.Use(key)
+		if usage.RateLimit > 0 {
+			res.Header().Set("X-RateLimit-Limit", strconv.Itoa(usage.RateLimit))
+			res.Header().Set("X-RateLimit-Remaining", strconv.Itoa(usage.RateRemaining))
+		}
+		if usage.Quota > 0 && !usage.QuotaReset.IsZero() {
+			res.Header().Set("X-Quota-Limit", strconv.Itoa(usage.Quota))
+			res.Header().Set("X-Quota-Remaining", strconv.Itoa(usage.QuotaRemaining))
+			res.Header().Set("X-Quota-Reset", usage.QuotaReset.Format(time.RFC3339))
+		}
+		if !usage.Allowed {
+			res.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(usage.RetryAfter.Seconds()))))
+			http.Error(res, usage.Reason+" exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		handler(res, req.WithContext(WithKey(req.Context(), key)))
+	}
+}
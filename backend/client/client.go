@@ -0,0 +1,370 @@
+// Package client is a typed Go client for the reviews API described in openapi/openapi.json. Each method is named
+// after the operationId it calls.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marcuswu/app-reviews/compare"
+	"github.com/marcuswu/app-reviews/models"
+	"github.com/marcuswu/app-reviews/sentiment"
+	"github.com/marcuswu/app-reviews/topics"
+)
+
+// Client calls the reviews API. Create one with New.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	apiKey     string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the client requests are made with, such as one configured for mutual TLS
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithAPIKey sets the API key sent with every request
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) { c.apiKey = apiKey }
+}
+
+// New creates a client for the server at baseURL, such as http://localhost:8000
+func New(baseURL string, options ...Option) *Client {
+	c := &Client{baseURL: strings.TrimSuffix(baseURL, "/"), httpClient: http.DefaultClient}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// ReviewQuery narrows the reviews returned for an app. Zero values use the server defaults.
+type ReviewQuery struct {
+	Hours      int
+	Ratings    []int
+	Keyword    string
+	Sentiments []sentiment.Label
+	Tags       []string
+	Languages  []string // detected language codes such as en, or und
+	Spam       models.SpamFilter
+	Removed    models.RemovedFilter // reviews that disappeared from Apple's feed are left out by default
+	Sort       models.ReviewOrder
+}
+
+func (q ReviewQuery) values() url.Values {
+	values := url.Values{}
+	if q.Hours > 0 {
+		values.Set("hours", strconv.Itoa(q.Hours))
+	}
+	if len(q.Ratings) > 0 {
+		ratings := make([]string, 0, len(q.Ratings))
+		for _, rating := range q.Ratings {
+			ratings = append(ratings, strconv.Itoa(rating))
+		}
+		values.Set("rating", strings.Join(ratings, ","))
+	}
+	if len(q.Keyword) > 0 {
+		values.Set("q", q.Keyword)
+	}
+	if len(q.Sentiments) > 0 {
+		labels := make([]string, 0, len(q.Sentiments))
+		for _, label := range q.Sentiments {
+			labels = append(labels, string(label))
+		}
+		values.Set("sentiment", strings.Join(labels, ","))
+	}
+	if len(q.Tags) > 0 {
+		values.Set("tag", strings.Join(q.Tags, ","))
+	}
+	if len(q.Languages) > 0 {
+		values.Set("language", strings.Join(q.Languages, ","))
+	}
+	if len(q.Spam) > 0 {
+		values.Set("spam", string(q.Spam))
+	}
+	if len(q.Removed) > 0 {
+		values.Set("removed", string(q.Removed))
+	}
+	if len(q.Sort) > 0 {
+		values.Set("sort", string(q.Sort))
+	}
+	return values
+}
+
+// TopicsQuery narrows the reviews topics are found in. Hours is the length of the window and Sort is ignored. Zero
+// values use the server defaults.
+type TopicsQuery struct {
+	ReviewQuery
+	Limit    int    // most terms in each list
+	Language string // language of the stop words to remove, such as en
+}
+
+func (q TopicsQuery) values() url.Values {
+	values := q.ReviewQuery.values()
+	values.Del("sort")
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	if len(q.Language) > 0 {
+		values.Set("lang", q.Language)
+	}
+	return values
+}
+
+// CompareQuery narrows the reviews apps are compared on. Hours is the length of the window and Sort is ignored.
+// Zero values use the server defaults.
+type CompareQuery struct {
+	ReviewQuery
+	Limit int // most keywords listed for each app
+}
+
+func (q CompareQuery) values(appIds []string) url.Values {
+	values := q.ReviewQuery.values()
+	values.Del("sort")
+	values.Set("apps", strings.Join(appIds, ","))
+	if q.Limit > 0 {
+		values.Set("limit", strconv.Itoa(q.Limit))
+	}
+	return values
+}
+
+// Topics is the terms an app's reviews mention most in a window and how they changed since the window before it
+type Topics struct {
+	AppId string `json:"appId"`
+	topics.Report
+}
+
+// ReviewHistory is every revision of a review, oldest first, ending with the current one
+type ReviewHistory struct {
+	AppId     string            `json:"appId"`
+	ReviewId  string            `json:"reviewId"`
+	Revisions []models.Revision `json:"revisions"`
+}
+
+// AppStatus reports the state of a single app's cache
+type AppStatus struct {
+	AppId           string     `json:"appId"`
+	CacheAgeSeconds float64    `json:"cacheAgeSeconds"`
+	LastRefresh     *time.Time `json:"lastRefresh,omitempty"`
+	LastError       string     `json:"lastError,omitempty"`
+}
+
+// Status reports the server's readiness and the health of its refresher, cache and requests to Apple
+type Status struct {
+	Ready             bool        `json:"ready"`
+	ReadyError        string      `json:"readyError,omitempty"`
+	RefresherLastRun  time.Time   `json:"refresherLastRun"`
+	Apps              []AppStatus `json:"apps"`
+	UpstreamRequests  int         `json:"upstreamRequests"`
+	UpstreamErrors    int         `json:"upstreamErrors"`
+	UpstreamErrorRate float64     `json:"upstreamErrorRate"`
+	CacheWritable     bool        `json:"cacheWritable"`
+	CacheError        string      `json:"cacheError,omitempty"`
+}
+
+// Error is returned when the server answers with a status other than 2xx
+type Error struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration // set on 429 responses
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("reviews API returned %d: %s", e.StatusCode, e.Message)
+}
+
+// do makes a request and returns the response body. The caller must close it. A request body is sent as JSON.
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, body io.Reader, accept string) (io.ReadCloser, error) {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if len(accept) > 0 {
+		req.Header.Set("Accept", accept)
+	}
+	if len(c.apiKey) > 0 {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode/100 == 2 {
+		return res.Body, nil
+	}
+
+	defer res.Body.Close()
+	message, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+	apiErr := &Error{StatusCode: res.StatusCode, Message: strings.TrimSpace(string(message))}
+	if seconds, err := strconv.Atoi(res.Header.Get("Retry-After")); err == nil {
+		apiErr.RetryAfter = time.Duration(seconds) * time.Second
+	}
+	return nil, apiErr
+}
+
+// getBytes makes a GET request and reads the whole response body
+func (c *Client) getBytes(ctx context.Context, path string, query url.Values, accept string) ([]byte, error) {
+	body, err := c.do(ctx, http.MethodGet, path, query, nil, accept)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// getJSON makes a GET request and decodes the JSON response body into v
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, v any) error {
+	body, err := c.do(ctx, http.MethodGet, path, query, nil, "application/json")
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return json.NewDecoder(body).Decode(v)
+}
+
+func appPath(appId string, suffix string) string {
+	return "/" + url.PathEscape(appId) + suffix
+}
+
+// GetReviews returns an app's recent reviews, newest first
+func (c *Client) GetReviews(ctx context.Context, appId string, query ReviewQuery) (models.AppReviews, error) {
+	reviews := models.AppReviews{}
+	err := c.getJSON(ctx, appPath(appId, ""), query.values(), &reviews)
+	return reviews, err
+}
+
+// ExportReviews returns an app's recent reviews in an export format. The caller must close the returned reader.
+func (c *Client) ExportReviews(ctx context.Context, appId string, query ReviewQuery, format models.ExportFormat) (io.ReadCloser, error) {
+	values := query.values()
+	values.Set("format", string(format))
+	return c.do(ctx, http.MethodGet, appPath(appId, ""), values, nil, format.ContentType())
+}
+
+// GetAtomFeed returns an app's recent reviews as an Atom feed
+func (c *Client) GetAtomFeed(ctx context.Context, appId string, query ReviewQuery) ([]byte, error) {
+	return c.getBytes(ctx, appPath(appId, "/atom"), query.values(), "application/atom+xml")
+}
+
+// GetRSSFeed returns an app's recent reviews as an RSS 2.0 feed
+func (c *Client) GetRSSFeed(ctx context.Context, appId string, query ReviewQuery) ([]byte, error) {
+	return c.getBytes(ctx, appPath(appId, "/rss"), query.values(), "application/rss+xml")
+}
+
+// GetTopics returns the terms and bigrams an app's reviews mention most and how they changed since the previous window
+func (c *Client) GetTopics(ctx context.Context, appId string, query TopicsQuery) (Topics, error) {
+	result := Topics{}
+	err := c.getJSON(ctx, appPath(appId, "/topics"), query.values(), &result)
+	return result, err
+}
+
+// GetReviewHistory returns every revision of one of an app's reviews, including those its author has since edited
+func (c *Client) GetReviewHistory(ctx context.Context, appId string, reviewId string) (ReviewHistory, error) {
+	result := ReviewHistory{}
+	err := c.getJSON(ctx, appPath(appId, "/reviews/"+url.PathEscape(reviewId)+"/history"), nil, &result)
+	return result, err
+}
+
+// CompareApps returns the rating averages, review volume, histograms, release cadence and top keywords of several
+// apps side by side over the same window
+func (c *Client) CompareApps(ctx context.Context, appIds []string, query CompareQuery) (compare.Comparison, error) {
+	result := compare.Comparison{}
+	err := c.getJSON(ctx, "/compare", query.values(appIds), &result)
+	return result, err
+}
+
+// GetMetrics returns the server's metrics in the Prometheus text format
+func (c *Client) GetMetrics(ctx context.Context) (string, error) {
+	metrics, err := c.getBytes(ctx, "/metrics", nil, "text/plain")
+	return string(metrics), err
+}
+
+// GetHealth returns an error unless the server is up
+func (c *Client) GetHealth(ctx context.Context) error {
+	_, err := c.getBytes(ctx, "/healthz", nil, "text/plain")
+	return err
+}
+
+// GetReadiness returns an error saying why the server is not ready to serve reviews, or nil if it is
+func (c *Client) GetReadiness(ctx context.Context) error {
+	_, err := c.getBytes(ctx, "/readyz", nil, "text/plain")
+	return err
+}
+
+// GetStatus returns the server's readiness and refresher, cache and upstream status
+func (c *Client) GetStatus(ctx context.Context) (Status, error) {
+	status := Status{}
+	err := c.getJSON(ctx, "/status", nil, &status)
+	return status, err
+}
+
+// GraphQLError is an error reported in a GraphQL response
+type GraphQLError struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path,omitempty"`
+}
+
+// GraphQLErrors is returned by QueryGraphQL when the response has errors
+type GraphQLErrors []GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Message)
+	}
+	return "graphql: " + strings.Join(messages, "; ")
+}
+
+// QueryGraphQL runs a GraphQL query and decodes its data into result. When the response has errors, whatever data
+// was resolved is still decoded and GraphQLErrors is returned.
+func (c *Client) QueryGraphQL(ctx context.Context, query string, variables map[string]any, result any) error {
+	request, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+	if err != nil {
+		return err
+	}
+	body, err := c.do(ctx, http.MethodPost, "/graphql", nil, bytes.NewReader(request), "application/json")
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	response := struct {
+		Data   json.RawMessage `json:"data"`
+		Errors GraphQLErrors   `json:"errors"`
+	}{}
+	if err := json.NewDecoder(body).Decode(&response); err != nil {
+		return err
+	}
+	if len(response.Data) > 0 && string(response.Data) != "null" && result != nil {
+		if err := json.Unmarshal(response.Data, result); err != nil {
+			return err
+		}
+	}
+	if len(response.Errors) > 0 {
+		return response.Errors
+	}
+	return nil
+}
+
+// GetOpenAPI returns the server's OpenAPI document
+func (c *Client) GetOpenAPI(ctx context.Context) ([]byte, error) {
+	return c.getBytes(ctx, "/openapi.json", nil, "application/json")
+}
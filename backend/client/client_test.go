@@ -0,0 +1,284 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcuswu/app-reviews/models"
+	"github.com/marcuswu/app-reviews/openapi"
+	"github.com/marcuswu/app-reviews/sentiment"
+)
+
+// jsonKeys returns the sorted top level keys v marshals to
+func jsonKeys(t *testing.T, v any) []string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{}
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func schemaKeys(schema openapi.Schema) []string {
+	keys := []string{}
+	for key := range schema.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestMethodsCoverOperations(t *testing.T) {
+	document, err := openapi.Parse()
+	if err != nil {
+		t.Fatalf("expected the spec to parse, got %s", err)
+	}
+	clientType := reflect.TypeOf(&Client{})
+	for path, operations := range document.Paths {
+		for method, operation := range operations {
+			name := strings.ToUpper(operation.OperationId[:1]) + operation.OperationId[1:]
+			if _, ok := clientType.MethodByName(name); !ok {
+				t.Errorf("expected a %s method for %s %s", name, strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestMethodsMatchSpecPaths(t *testing.T) {
+	requested := ""
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		requested = req.Method + " " + req.URL.EscapedPath()
+		res.Write([]byte("{}"))
+	}))
+	defer server.Close()
+	c := New(server.URL)
+	ctx := context.Background()
+
+	// Each method's call and the operation it makes, since the client is written by hand rather than generated
+	calls := []struct {
+		method      string
+		operationId string
+		call        func() error
+	}{
+		{"GetReviews", "getReviews", func() error { _, err := c.GetReviews(ctx, "1234", ReviewQuery{}); return err }},
+		{"ExportReviews", "getReviews", func() error {
+			body, err := c.ExportReviews(ctx, "1234", ReviewQuery{}, models.FormatCSV)
+			if err == nil {
+				body.Close()
+			}
+			return err
+		}},
+		{"GetAtomFeed", "getAtomFeed", func() error { _, err := c.GetAtomFeed(ctx, "1234", ReviewQuery{}); return err }},
+		{"GetRSSFeed", "getRSSFeed", func() error { _, err := c.GetRSSFeed(ctx, "1234", ReviewQuery{}); return err }},
+		{"GetTopics", "getTopics", func() error { _, err := c.GetTopics(ctx, "1234", TopicsQuery{}); return err }},
+		{"GetReviewHistory", "getReviewHistory", func() error { _, err := c.GetReviewHistory(ctx, "1234", "5"); return err }},
+		{"CompareApps", "compareApps", func() error {
+			_, err := c.CompareApps(ctx, []string{"1234", "5678"}, CompareQuery{})
+			return err
+		}},
+		{"GetMetrics", "getMetrics", func() error { _, err := c.GetMetrics(ctx); return err }},
+		{"GetHealth", "getHealth", func() error { return c.GetHealth(ctx) }},
+		{"GetReadiness", "getReadiness", func() error { return c.GetReadiness(ctx) }},
+		{"GetStatus", "getStatus", func() error { _, err := c.GetStatus(ctx); return err }},
+		{"QueryGraphQL", "queryGraphQL", func() error { return c.QueryGraphQL(ctx, "{ __typename }", nil, nil) }},
+		{"GetOpenAPI", "getOpenAPI", func() error { _, err := c.GetOpenAPI(ctx); return err }},
+	}
+
+	document, err := openapi.Parse()
+	if err != nil {
+		t.Fatalf("expected the spec to parse, got %s", err)
+	}
+	operations := map[string]string{} // operationId -> method and path
+	for path, methods := range document.Paths {
+		for method, operation := range methods {
+			path = strings.NewReplacer("{appId}", "1234", "{reviewId}", "5").Replace(path)
+			operations[operation.OperationId] = strings.ToUpper(method) + " " + path
+		}
+	}
+
+	covered := map[string]bool{}
+	for _, call := range calls {
+		covered[call.method] = true
+		requested = ""
+		call.call()
+		if want, ok := operations[call.operationId]; !ok || requested != want {
+			t.Errorf("expected %s to request %s (%s), got %q", call.method, want, call.operationId, requested)
+		}
+	}
+	clientType := reflect.TypeOf(&Client{})
+	for i := 0; i < clientType.NumMethod(); i++ {
+		if name := clientType.Method(i).Name; !covered[name] {
+			t.Errorf("expected %s to be checked against the spec", name)
+		}
+	}
+}
+
+func TestStatusMatchesSpec(t *testing.T) {
+	document, err := openapi.Parse()
+	if err != nil {
+		t.Fatalf("expected the spec to parse, got %s", err)
+	}
+	now := time.Now()
+	app := AppStatus{AppId: "1234", LastRefresh: &now, LastError: "failed"}
+	status := Status{ReadyError: "not ready", Apps: []AppStatus{app}, CacheError: "read only"}
+
+	for name, v := range map[string]any{"AppStatus": app, "Status": status} {
+		if got, want := jsonKeys(t, v), schemaKeys(document.Components.Schemas[name]); !reflect.DeepEqual(got, want) {
+			t.Errorf("expected %s fields %v to match the spec %v", name, got, want)
+		}
+	}
+}
+
+func TestGetReviews(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		if req.URL.Path != "/1234" || query.Get("rating") != "1,2" || query.Get("hours") != "24" ||
+			query.Get("sentiment") != "negative,neutral" || query.Get("sort") != "most-negative" ||
+			query.Get("tag") != "crash,login" || query.Get("language") != "en,es" ||
+			query.Get("spam") != "exclude" || query.Get("removed") != "include" {
+			t.Errorf("unexpected request %s", req.URL)
+		}
+		if req.Header.Get("Authorization") != "Bearer secret" {
+			t.Errorf("expected the api key to be sent, got %q", req.Header.Get("Authorization"))
+		}
+		json.NewEncoder(res).Encode(models.AppReviews{{Id: "1", Rating: 1, Author: models.Author{Name: "Someone"}}})
+	}))
+	defer server.Close()
+
+	reviews, err := New(server.URL, WithAPIKey("secret")).GetReviews(context.Background(), "1234",
+		ReviewQuery{Hours: 24, Ratings: []int{1, 2}, Sentiments: []sentiment.Label{sentiment.Negative, sentiment.Neutral},
+			Tags: []string{"crash", "login"}, Languages: []string{"en", "es"},
+			Spam: models.SpamExclude, Removed: models.RemovedInclude, Sort: models.OrderMostNegative})
+	if err != nil || len(reviews) != 1 || reviews[0].Author.Name != "Someone" {
+		t.Errorf("expected one review, got %v (%v)", reviews, err)
+	}
+}
+
+func TestExportReviews(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("format") != "csv" {
+			t.Errorf("expected the csv format to be requested, got %s", req.URL)
+		}
+		fmt.Fprint(res, "id,updated\n")
+	}))
+	defer server.Close()
+
+	body, err := New(server.URL).ExportReviews(context.Background(), "1234", ReviewQuery{}, models.FormatCSV)
+	if err != nil {
+		t.Fatalf("expected an export, got %s", err)
+	}
+	defer body.Close()
+	if data, _ := io.ReadAll(body); string(data) != "id,updated\n" {
+		t.Errorf("unexpected export %q", data)
+	}
+}
+
+func TestErrorResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Retry-After", "30")
+		http.Error(res, "rate limit exceeded", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := New(server.URL).GetStatus(context.Background())
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests ||
+		apiErr.RetryAfter != 30*time.Second || apiErr.Message != "rate limit exceeded" {
+		t.Errorf("expected a 429 error retrying after 30s, got %v", err)
+	}
+}
+
+func TestQueryGraphQL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		request := struct {
+			Query     string
+			Variables map[string]any
+		}{}
+		json.NewDecoder(req.Body).Decode(&request)
+		if req.Method != http.MethodPost || req.URL.Path != "/graphql" || request.Variables["id"] != "1234" {
+			http.Error(res, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(res, `{"data":{"app":{"id":"1234","stats":null}},"errors":[{"message":"invalid rating 6","path":["app","stats"]}]}`)
+	}))
+	defer server.Close()
+
+	result := struct{ App struct{ Id string } }{}
+	err := New(server.URL).QueryGraphQL(context.Background(), `query($id: ID!) { app(id: $id) { id } }`,
+		map[string]any{"id": "1234"}, &result)
+	var errs GraphQLErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Message != "invalid rating 6" {
+		t.Errorf("expected the GraphQL error, got %v", err)
+	}
+	if result.App.Id != "1234" {
+		t.Errorf("expected the partial data to be decoded, got %+v", result)
+	}
+}
+
+func TestGetTopics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		if req.URL.Path != "/1234/topics" || query.Get("hours") != "24" || query.Get("limit") != "5" ||
+			query.Get("lang") != "es" || query.Get("rating") != "1" || query.Has("sort") {
+			t.Errorf("unexpected request %s", req.URL)
+		}
+		fmt.Fprint(res, `{"appId":"1234","reviews":3,"terms":[{"term":"login","score":1.5,"new":true}]}`)
+	}))
+	defer server.Close()
+
+	result, err := New(server.URL).GetTopics(context.Background(), "1234", TopicsQuery{
+		ReviewQuery: ReviewQuery{Hours: 24, Ratings: []int{1}, Sort: models.OrderOldest}, Limit: 5, Language: "es"})
+	if err != nil || result.AppId != "1234" || result.Reviews != 3 || len(result.Terms) != 1 || !result.Terms[0].New {
+		t.Errorf("expected the topics report, got %+v (%v)", result, err)
+	}
+}
+
+func TestGetReviewHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/1234/reviews/99/history" {
+			t.Errorf("unexpected request %s", req.URL)
+		}
+		fmt.Fprint(res, `{"appId":"1234","reviewId":"99","revisions":[{"rating":1},{"rating":5}]}`)
+	}))
+	defer server.Close()
+
+	result, err := New(server.URL).GetReviewHistory(context.Background(), "1234", "99")
+	if err != nil || result.ReviewId != "99" || len(result.Revisions) != 2 || result.Revisions[1].Rating != 5 {
+		t.Errorf("expected the review history, got %+v (%v)", result, err)
+	}
+}
+
+func TestCompareApps(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/compare" || req.URL.Query().Get("apps") != "1234,5678" ||
+			req.URL.Query().Get("limit") != "5" || req.URL.Query().Get("hours") != "168" {
+			t.Errorf("unexpected request %s", req.URL)
+		}
+		fmt.Fprint(res, `{"apps":[{"appId":"1234","averageRating":4.5},{"appId":"5678","averageRating":3}]}`)
+	}))
+	defer server.Close()
+
+	result, err := New(server.URL).CompareApps(context.Background(), []string{"1234", "5678"},
+		CompareQuery{ReviewQuery: ReviewQuery{Hours: 168}, Limit: 5})
+	if err != nil || len(result.Apps) != 2 || result.Apps[0].AverageRating != 4.5 || result.Apps[1].AppId != "5678" {
+		t.Errorf("expected the comparison, got %+v (%v)", result, err)
+	}
+}
@@ -0,0 +1,30 @@
+// Package clock abstracts the current time so time based logic can be tested deterministically.
+// Use clock.System in production and clocktest.Fake in tests.
+package clock
+
+import "time"
+
+// Clock tells the time and schedules waits
+type Clock interface {
+	Now() time.Time
+	// After waits for the duration to elapse and then sends the current time on the returned channel
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// System is the real wall clock
+var System Clock = systemClock{}
+
+// Since returns the time elapsed since t according to a clock
+func Since(c Clock, t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
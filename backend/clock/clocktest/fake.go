@@ -0,0 +1,73 @@
+// Package clocktest provides a fake clock for tests
+package clocktest
+
+import (
+	"sync"
+	"time"
+)
+
+type waiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+// Fake is a clock that only moves when told to. Channels returned by After fire when the clock is advanced past
+// their deadline.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+// NewFake creates a fake clock set to now
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+	f.waiters = append(f.waiters, waiter{at: f.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward, firing any waits that are now due
+func (f *Fake) Advance(d time.Duration) {
+	f.Set(f.Now().Add(d))
+}
+
+// Set moves the clock to a specific time, firing any waits that are now due
+func (f *Fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.now = now
+
+	pending := f.waiters[:0]
+	for _, w := range f.waiters {
+		if w.at.After(now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- now
+	}
+	f.waiters = pending
+}
+
+// Waiters returns the number of After calls that have not fired yet, letting tests wait for a goroutine to block
+// on the clock before advancing it
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
@@ -0,0 +1,53 @@
+package clocktest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	fake := NewFake(start)
+
+	soon := fake.After(time.Minute)
+	later := fake.After(time.Hour)
+	if fake.Waiters() != 2 {
+		t.Errorf("expected 2 waiters, got %d", fake.Waiters())
+	}
+
+	fake.Advance(30 * time.Second)
+	select {
+	case <-soon:
+		t.Errorf("expected the one minute wait to not fire after 30 seconds")
+	default:
+	}
+
+	fake.Advance(30 * time.Second)
+	select {
+	case fired := <-soon:
+		if !fired.Equal(start.Add(time.Minute)) {
+			t.Errorf("expected the wait to fire at %s, got %s", start.Add(time.Minute), fired)
+		}
+	default:
+		t.Errorf("expected the one minute wait to fire")
+	}
+	if fake.Waiters() != 1 {
+		t.Errorf("expected 1 waiter left, got %d", fake.Waiters())
+	}
+
+	fake.Set(start.Add(2 * time.Hour))
+	select {
+	case <-later:
+	default:
+		t.Errorf("expected the one hour wait to fire")
+	}
+
+	select {
+	case <-fake.After(0):
+	default:
+		t.Errorf("expected a zero wait to fire immediately")
+	}
+	if !fake.Now().Equal(start.Add(2 * time.Hour)) {
+		t.Errorf("unexpected time %s", fake.Now())
+	}
+}
@@ -0,0 +1,524 @@
+// Command reviews fetches, exports and summarizes Apple app reviews from the terminal.
+// It shares the server's cache, so run it from the same directory as the server.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/marcuswu/app-reviews/clock"
+	"github.com/marcuswu/app-reviews/config"
+	"github.com/marcuswu/app-reviews/language"
+	"github.com/marcuswu/app-reviews/logging"
+	"github.com/marcuswu/app-reviews/models"
+	"github.com/marcuswu/app-reviews/sentiment"
+	"github.com/marcuswu/app-reviews/tagging"
+	"github.com/marcuswu/app-reviews/updater"
+)
+
+const usage = `usage: reviews <command> [flags]
+
+commands:
+  fetch     fetch the latest reviews from Apple, refresh the cache and print them
+  export    write reviews (from cache when fresh) to a file or stdout
+  stats     summarize reviews by rating, version, sentiment and language
+  watch     poll for new reviews and print them as they appear
+  cache ls     list cached apps
+  cache rm     remove cached apps
+  cache retag  re-tag cached reviews with the current tag rules
+
+Run "reviews <command> -h" for the flags of a command.
+`
+
+// reviewOptions are the flags shared by the commands that read reviews
+type reviewOptions struct {
+	appId       string
+	storefronts string
+	hours       int
+	format      string
+	sentiments  string
+	tags        string
+	languages   string
+	spam        string
+	removed     string
+	sort        string
+
+	filter models.ReviewFilter // sentiments, tags, languages, spam and removed parsed by validate
+	order  models.ReviewOrder  // sort parsed by validate
+}
+
+func (o *reviewOptions) register(flags *flag.FlagSet) {
+	flags.StringVar(&o.appId, "app", "", "app id to read reviews for (required)")
+	flags.StringVar(&o.storefronts, "storefronts", config.DEFAULT_STOREFRONT, "comma separated list of storefront country codes")
+	flags.IntVar(&o.hours, "hours", config.OLDEST_REVIEW_HOURS, "only include reviews updated within this many hours")
+	flags.StringVar(&o.format, "format", "text", "output format (text, json, csv, ndjson or xlsx)")
+	flags.StringVar(&o.sentiments, "sentiment", "", "comma separated sentiments to include (negative, neutral or positive)")
+	flags.StringVar(&o.tags, "tag", "", "comma separated tags; only reviews with at least one are included")
+	flags.StringVar(&o.languages, "language", "", "comma separated detected languages to include, such as en,es (und when unknown)")
+	flags.StringVar(&o.spam, "spam", "include", "whether to include reviews suspected to be spam (include, exclude or only)")
+	flags.StringVar(&o.removed, "removed", "exclude",
+		"whether to include reviews that disappeared from Apple's feed (exclude, include or only)")
+	flags.StringVar(&o.sort, "sort", "newest", "review order (newest, oldest, most-negative or most-positive)")
+}
+
+func (o *reviewOptions) validate() error {
+	if len(o.appId) < 1 {
+		return errors.New("an app id is required (-app)")
+	}
+	if !models.ValidAppId(o.appId) {
+		return fmt.Errorf("invalid app id %q, expected digits", o.appId)
+	}
+	if o.hours < 1 {
+		return errors.New("hours must be positive")
+	}
+	o.filter = models.ReviewFilter{}
+	for _, name := range strings.Split(o.sentiments, ",") {
+		if len(strings.TrimSpace(name)) < 1 {
+			continue
+		}
+		label, err := sentiment.ParseLabel(name)
+		if err != nil {
+			return err
+		}
+		o.filter.Sentiments = append(o.filter.Sentiments, label)
+	}
+	for _, tag := range strings.Split(o.tags, ",") {
+		if tag = tagging.NormalizeTag(tag); len(tag) > 0 {
+			o.filter.Tags = append(o.filter.Tags, tag)
+		}
+	}
+	for _, code := range strings.Split(o.languages, ",") {
+		if code = strings.ToLower(strings.TrimSpace(code)); len(code) < 1 {
+			continue
+		}
+		if err := language.Check(code); err != nil {
+			return err
+		}
+		o.filter.Languages = append(o.filter.Languages, code)
+	}
+	var err error
+	if o.filter.Spam, err = models.ParseSpamFilter(o.spam); err != nil {
+		return err
+	}
+	if o.filter.Removed, err = models.ParseRemovedFilter(o.removed); err != nil {
+		return err
+	}
+	o.order, err = models.ParseReviewOrder(o.sort)
+	return err
+}
+
+// selectReviews returns the reviews within the hours window that match the sentiment, tag, language, spam and
+// removed filters, in the requested order
+func (o *reviewOptions) selectReviews(reviews models.AppReviews) models.AppReviews {
+	reviews = reviews.After(o.minTime()).Filter(o.filter)
+	reviews.Sort(o.order)
+	return reviews
+}
+
+func (o *reviewOptions) storefrontList() []string {
+	storefronts := []string{}
+	for _, storefront := range strings.Split(o.storefronts, ",") {
+		storefront = strings.ToLower(strings.TrimSpace(storefront))
+		if len(storefront) > 0 {
+			storefronts = append(storefronts, storefront)
+		}
+	}
+	if len(storefronts) < 1 {
+		storefronts = append(storefronts, config.DEFAULT_STOREFRONT)
+	}
+	return storefronts
+}
+
+func (o *reviewOptions) minTime() time.Time {
+	return clock.System.Now().Add(time.Duration(-o.hours) * time.Hour)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	// Keep logs on stderr and quiet by default so they do not end up in exported output
+	level, err := logging.ParseLevel(config.Env("LOG_LEVEL", "warn"))
+	if err != nil {
+		level = slog.LevelWarn
+	}
+	if logger, err := logging.New(os.Stderr, level, config.Env("LOG_FORMAT", "text")); err == nil {
+		slog.SetDefault(logger)
+	}
+
+	// Tag fetched reviews with the same rules as the server
+	if rulesFile := config.Env("TAG_RULES_FILE", ""); len(rulesFile) > 0 {
+		rules, err := tagging.LoadFile(rulesFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "unable to load tag rules: %s\n", err)
+			os.Exit(2)
+		}
+		updater.Default().SetTagRules(rules)
+	}
+
+	switch os.Args[1] {
+	case "fetch":
+		err = fetchCommand(os.Args[2:], os.Stdout)
+	case "export":
+		err = exportCommand(os.Args[2:], os.Stdout)
+	case "stats":
+		err = statsCommand(os.Args[2:], os.Stdout)
+	case "watch":
+		err = watchCommand(os.Args[2:], os.Stdout)
+	case "cache":
+		err = cacheCommand(os.Args[2:], os.Stdout)
+	case "help", "-h", "--help":
+		fmt.Fprint(os.Stdout, usage)
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", os.Args[1], usage)
+		os.Exit(2)
+	}
+
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "reviews %s: %s\n", os.Args[1], err)
+		}
+		os.Exit(1)
+	}
+}
+
+// loadReviews reads an app's reviews for each storefront and merges them.
+// The default storefront goes through the shared cache unless refresh is set. Other storefronts are not
+// cached since the cache only holds one file per app.
+func loadReviews(ctx context.Context, appId string, storefronts []string, refresh bool) (models.AppReviews, error) {
+	reviews := models.AppReviews{}
+	seen := map[string]bool{}
+	for _, storefront := range storefronts {
+		var storefrontReviews models.AppReviews
+		var err error
+		if storefront == config.DEFAULT_STOREFRONT {
+			storefrontReviews, err = loadDefaultStorefront(ctx, appId, refresh)
+		} else {
+			storefrontReviews, err = updater.FetchStorefrontReviews(ctx, appId, storefront)
+			updater.Enrich(storefrontReviews)
+		}
+		if errors.Is(err, updater.ErrNoFeed) {
+			continue
+		}
+		if err != nil {
+			return reviews, fmt.Errorf("failed to fetch reviews from storefront %s: %w", storefront, err)
+		}
+
+		for _, review := range storefrontReviews {
+			if seen[review.Id] {
+				continue
+			}
+			seen[review.Id] = true
+			reviews = append(reviews, review)
+		}
+	}
+	return reviews, nil
+}
+
+func loadDefaultStorefront(ctx context.Context, appId string, refresh bool) (models.AppReviews, error) {
+	if !refresh {
+		if reviews, err := updater.LoadReviews(ctx, appId); err == nil {
+			return reviews, nil
+		}
+	}
+
+	reviews, err := updater.FetchAppReviews(ctx, appId)
+	if err == nil {
+		if saveErr := updater.SaveReviews(ctx, appId, reviews); saveErr != nil {
+			fmt.Fprintf(os.Stderr, "failed to update cache for app %s: %s\n", appId, saveErr)
+		} else if cached, loadErr := updater.LoadReviews(ctx, appId); loadErr == nil {
+			// The cache also has the reviews that disappeared from the feed
+			reviews = cached
+		}
+	}
+	return reviews, err
+}
+
+// writeReviews writes reviews to out in the requested format
+func writeReviews(out io.Writer, reviews models.AppReviews, format string) error {
+	switch format {
+	case "json":
+		return json.NewEncoder(out).Encode(reviews)
+	case "text":
+		for _, review := range reviews {
+			fmt.Fprintf(out, "%s  %s  v%s  %s  (%s)", review.Updated.Local().Format(time.DateTime),
+				strings.Repeat("*", review.Rating), review.Version, review.Author.Name, sentiment.Classify(review.Sentiment))
+			if len(review.Tags) > 0 {
+				fmt.Fprintf(out, "  [%s]", strings.Join(review.Tags, ", "))
+			}
+			if review.SuspectedSpam() {
+				fmt.Fprintf(out, "  spam? (%s)", strings.Join(review.Spam, ", "))
+			}
+			if review.Removed() {
+				fmt.Fprintf(out, "  removed %s", review.RemovedAt.Local().Format(time.DateTime))
+			}
+			if len(review.Revisions) > 0 {
+				fmt.Fprintf(out, "  edited, was %s", strings.Repeat("*", review.Revisions[len(review.Revisions)-1].Rating))
+			}
+			fmt.Fprintln(out)
+			fmt.Fprintf(out, "  %s\n", review.Title)
+			for _, line := range strings.Split(review.Content, "\n") {
+				fmt.Fprintf(out, "  %s\n", line)
+			}
+			fmt.Fprintln(out)
+		}
+		return nil
+	default:
+		exportFormat, err := models.ParseExportFormat(format)
+		if err != nil {
+			return err
+		}
+		return models.ExportReviews(out, reviews, exportFormat)
+	}
+}
+
+func fetchCommand(args []string, out io.Writer) error {
+	opts := reviewOptions{}
+	flags := flag.NewFlagSet("fetch", flag.ContinueOnError)
+	opts.register(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	reviews, err := loadReviews(context.Background(), opts.appId, opts.storefrontList(), true)
+	if err != nil {
+		return err
+	}
+	return writeReviews(out, opts.selectReviews(reviews), opts.format)
+}
+
+func exportCommand(args []string, out io.Writer) error {
+	opts := reviewOptions{}
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	opts.register(flags)
+	output := flags.String("o", "", "file to write to (defaults to stdout)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	reviews, err := loadReviews(context.Background(), opts.appId, opts.storefrontList(), false)
+	if err != nil {
+		return err
+	}
+
+	if len(*output) > 0 {
+		file, err := os.OpenFile(*output, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	return writeReviews(out, opts.selectReviews(reviews), opts.format)
+}
+
+func statsCommand(args []string, out io.Writer) error {
+	opts := reviewOptions{}
+	flags := flag.NewFlagSet("stats", flag.ContinueOnError)
+	opts.register(flags)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+
+	reviews, err := loadReviews(context.Background(), opts.appId, opts.storefrontList(), false)
+	if err != nil {
+		return err
+	}
+	stats := opts.selectReviews(reviews).Stats()
+
+	switch opts.format {
+	case "json":
+		return json.NewEncoder(out).Encode(stats)
+	case "text":
+		fmt.Fprintf(out, "%d reviews in the last %d hours (%d suspected spam), average rating %.2f, average sentiment %.2f\n\n",
+			stats.Count, opts.hours, stats.Spam, stats.AverageRating, stats.AverageSentiment)
+		for rating := 5; rating > 0; rating-- {
+			fmt.Fprintf(out, "%d %-5s %d\n", rating, strings.Repeat("*", rating), stats.Histogram[rating])
+		}
+		fmt.Fprintln(out)
+
+		table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "VERSION\tREVIEWS\tAVERAGE")
+		for _, version := range stats.Versions {
+			fmt.Fprintf(table, "%s\t%d\t%.2f\n", version.Version, version.Count, version.AverageRating)
+		}
+		fmt.Fprintln(table)
+		fmt.Fprintf(table, "NEGATIVE\tNEUTRAL\tPOSITIVE\n%d\t%d\t%d\n\n", stats.Sentiments[sentiment.Negative],
+			stats.Sentiments[sentiment.Neutral], stats.Sentiments[sentiment.Positive])
+		fmt.Fprintln(table, "DAY\tREVIEWS\tSENTIMENT")
+		for _, day := range stats.SentimentOverTime {
+			fmt.Fprintf(table, "%s\t%d\t%+.2f\n", day.Start.Format(time.DateOnly), day.Count, day.AverageSentiment)
+		}
+		fmt.Fprintln(table)
+		fmt.Fprintln(table, "LANGUAGE\tREVIEWS")
+		codes := make([]string, 0, len(stats.Languages))
+		for code := range stats.Languages {
+			codes = append(codes, code)
+		}
+		sort.Slice(codes, func(i, j int) bool {
+			if stats.Languages[codes[i]] != stats.Languages[codes[j]] {
+				return stats.Languages[codes[i]] > stats.Languages[codes[j]]
+			}
+			return codes[i] < codes[j]
+		})
+		for _, code := range codes {
+			fmt.Fprintf(table, "%s\t%d\n", code, stats.Languages[code])
+		}
+		return table.Flush()
+	default:
+		return fmt.Errorf("unsupported format %q", opts.format)
+	}
+}
+
+func watchCommand(args []string, out io.Writer) error {
+	opts := reviewOptions{}
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	opts.register(flags)
+	interval := flags.Duration("interval", time.Duration(config.MAX_REVIEW_FILE_AGE_MINUTES)*time.Minute,
+		"how often to check for new reviews")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	if *interval < time.Minute {
+		return errors.New("interval must be at least one minute to avoid hammering Apple")
+	}
+
+	// Stop watching cleanly on Ctrl-C, cancelling any fetch in progress
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	seen := map[string]bool{}
+	for {
+		reviews, err := loadReviews(ctx, opts.appId, opts.storefrontList(), true)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to check for new reviews: %s\n", err)
+		}
+
+		newReviews := models.AppReviews{}
+		for _, review := range reviews.After(opts.minTime()).Filter(opts.filter) {
+			if !seen[review.Id] {
+				seen[review.Id] = true
+				newReviews = append(newReviews, review)
+			}
+		}
+		if err := writeReviews(out, newReviews, opts.format); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(*interval):
+		}
+	}
+}
+
+func cacheCommand(args []string, out io.Writer) error {
+	if len(args) < 1 {
+		return errors.New("expected a cache subcommand (ls, rm or retag)")
+	}
+
+	switch args[0] {
+	case "ls":
+		table := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "APP\tAGE\tREVIEWS\tFILE")
+		for _, filename := range updater.ListAppCache() {
+			fi, err := os.Stat(filename)
+			if err != nil {
+				continue
+			}
+			count := "-"
+			if file, err := os.Open(filename); err == nil {
+				if reviews, err := models.LoadReviews(file); err == nil {
+					count = fmt.Sprint(len(reviews))
+				}
+				file.Close()
+			}
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", updater.AppIdForFile(filename),
+				time.Since(fi.ModTime()).Round(time.Second), count, filename)
+		}
+		return table.Flush()
+	case "rm":
+		flags := flag.NewFlagSet("cache rm", flag.ContinueOnError)
+		all := flags.Bool("all", false, "remove every cached app")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+
+		appIds := flags.Args()
+		if *all {
+			appIds = []string{}
+			for _, filename := range updater.ListAppCache() {
+				appIds = append(appIds, updater.AppIdForFile(filename))
+			}
+		}
+		if len(appIds) < 1 {
+			return errors.New("expected app ids to remove or -all")
+		}
+		for _, appId := range appIds {
+			if !models.ValidAppId(appId) {
+				return fmt.Errorf("invalid app id %q, expected digits", appId)
+			}
+		}
+
+		for _, appId := range appIds {
+			if err := updater.RemoveReviews(appId); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "removed cache for app %s\n", appId)
+		}
+		return nil
+	case "retag":
+		flags := flag.NewFlagSet("cache retag", flag.ContinueOnError)
+		rulesFile := flags.String("rules", "", "JSON file of tag rules; defaults to $APP_REVIEWS_TAG_RULES_FILE or the built in rules")
+		if err := flags.Parse(args[1:]); err != nil {
+			return err
+		}
+		if len(*rulesFile) > 0 {
+			rules, err := tagging.LoadFile(*rulesFile)
+			if err != nil {
+				return err
+			}
+			updater.Default().SetTagRules(rules)
+		}
+
+		changed, err := updater.Default().RetagReviews(context.Background())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "re-tagged %d reviews\n", changed)
+		return nil
+	default:
+		return fmt.Errorf("unknown cache subcommand %q", args[0])
+	}
+}
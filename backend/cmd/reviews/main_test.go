@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcuswu/app-reviews/models"
+	"github.com/marcuswu/app-reviews/updater"
+)
+
+func TestStorefrontList(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{"default", "us", []string{"us"}},
+		{"several", "us, GB,de", []string{"us", "gb", "de"}},
+		{"empty", " , ", []string{"us"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := reviewOptions{storefronts: tt.input}
+			ans := opts.storefrontList()
+			if strings.Join(ans, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("got %v, want %v", ans, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWriteReviews(t *testing.T) {
+	reviews := models.AppReviews{
+		models.AppReview{
+			Author:    models.Author{Name: "Test Author"},
+			Updated:   time.Now(),
+			Rating:    4,
+			Version:   "1.2",
+			Id:        "1",
+			Title:     "Test Title",
+			Content:   "Line one\nLine two",
+			Revisions: []models.Revision{{Rating: 1, Title: "Test Title", Content: "Line one"}},
+		},
+	}
+
+	out := bytes.NewBuffer([]byte{})
+	if err := writeReviews(out, reviews, "text"); err != nil {
+		t.Errorf("expected no error writing text, got %s", err)
+	}
+	if !strings.Contains(out.String(), "****  v1.2  Test Author") || !strings.Contains(out.String(), "  Line two\n") ||
+		!strings.Contains(out.String(), "edited, was *\n") {
+		t.Errorf("unexpected text output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := writeReviews(out, reviews, "json"); err != nil {
+		t.Errorf("expected no error writing json, got %s", err)
+	}
+	loaded, err := models.LoadReviews(out)
+	if err != nil || len(loaded) != 1 || loaded[0].Title != "Test Title" {
+		t.Errorf("expected json output to load back, got %v (%v)", loaded, err)
+	}
+
+	if err := writeReviews(out, reviews, "yaml"); err == nil {
+		t.Errorf("expected an error for an unsupported format")
+	}
+}
+
+func TestSelectReviews(t *testing.T) {
+	now := time.Now()
+	reviews := models.AppReviews{
+		{Id: "old", Sentiment: -0.9, Updated: now.Add(-100 * time.Hour)},
+		{Id: "bad", Sentiment: -0.5, Updated: now.Add(-2 * time.Hour)},
+		{Id: "worse", Sentiment: -0.7, Updated: now.Add(-time.Hour)},
+		{Id: "good", Sentiment: 0.5, Updated: now.Add(-3 * time.Hour)},
+		{Id: "crash", Sentiment: 0.2, Tags: []string{"crash"}, Updated: now.Add(-4 * time.Hour)},
+	}
+	opts := reviewOptions{appId: "1", hours: 48, sentiments: "negative", sort: "most-negative"}
+	if err := opts.validate(); err != nil {
+		t.Fatal(err)
+	}
+	ids := []string{}
+	for _, review := range opts.selectReviews(reviews) {
+		ids = append(ids, review.Id)
+	}
+	if strings.Join(ids, ",") != "worse,bad" {
+		t.Errorf("expected the recent negative reviews, most negative first, got %v", ids)
+	}
+
+	opts = reviewOptions{appId: "1", hours: 48, spam: "maybe"}
+	if err := opts.validate(); err == nil {
+		t.Errorf("expected an error for an unknown spam filter")
+	}
+
+	opts = reviewOptions{appId: "1", hours: 48, removed: "maybe"}
+	if err := opts.validate(); err == nil {
+		t.Errorf("expected an error for an unknown removed filter")
+	}
+
+	opts = reviewOptions{appId: "1", hours: 48, languages: "xx"}
+	if err := opts.validate(); err == nil {
+		t.Errorf("expected an error for an unsupported language")
+	}
+
+	opts = reviewOptions{appId: "1", hours: 48, tags: " Crash, billing"}
+	if err := opts.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if selected := opts.selectReviews(reviews); len(selected) != 1 || selected[0].Id != "crash" {
+		t.Errorf("expected only the review tagged crash, got %v", selected)
+	}
+
+	opts = reviewOptions{appId: "1", hours: 48, sentiments: "angry"}
+	if err := opts.validate(); err == nil {
+		t.Errorf("expected an error for an unknown sentiment")
+	}
+}
+
+func TestCacheRemoveRejectsInvalidAppIds(t *testing.T) {
+	dir := t.TempDir()
+	cacheDir := filepath.Join(dir, "cache")
+	os.Mkdir(cacheDir, 0755)
+	previous := updater.Default()
+	updater.SetDefault(updater.New(updater.WithStore(updater.NewFileStore(cacheDir))))
+	defer updater.SetDefault(previous)
+	victim := filepath.Join(dir, "victim.json")
+	os.WriteFile(victim, []byte("{}"), 0644)
+	cached := filepath.Join(cacheDir, "App-1234.json")
+	os.WriteFile(cached, []byte("[]"), 0644)
+
+	// Every id is checked before anything is removed
+	if err := cacheCommand([]string{"rm", "1234", "/../../victim"}, &bytes.Buffer{}); err == nil {
+		t.Errorf("expected an error for an app id that isn't digits")
+	}
+	for _, file := range []string{victim, cached} {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("expected %s to be kept, got %v", file, err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Package compare summarizes several apps' reviews side by side over the same window, such as an app and its
+// competitors: rating averages, review volume, rating histograms, how often new versions are released and the
+// keywords their reviews mention most.
+package compare
+
+import (
+	"sort"
+	"time"
+
+	"github.com/marcuswu/app-reviews/config"
+	"github.com/marcuswu/app-reviews/models"
+	"github.com/marcuswu/app-reviews/topics"
+)
+
+// App is an app's id and its cached reviews
+type App struct {
+	AppId   string
+	Reviews models.AppReviews
+}
+
+// Release is an app version, released around when its first review was left
+type Release struct {
+	Version       string    `json:"version"`
+	FirstSeen     time.Time `json:"firstSeen"` // when the version's first cached review was left
+	Reviews       int       `json:"reviews"`   // in the window
+	AverageRating float64   `json:"averageRating"`
+}
+
+// Summary is one app's side of a comparison
+type Summary struct {
+	AppId               string        `json:"appId"`
+	CoveredFrom         time.Time     `json:"coveredFrom"`   // the window start, or the app's oldest cached review if later
+	Reviews             int           `json:"reviews"`       // in the window
+	ReviewsPerDay       float64       `json:"reviewsPerDay"` // over the part of the window the cache covers
+	AverageRating       float64       `json:"averageRating"`
+	Histogram           map[int]int   `json:"histogram"` // rating -> number of reviews
+	Releases            []Release     `json:"releases"`  // versions first seen in the window, oldest first
+	DaysBetweenReleases float64       `json:"daysBetweenReleases"`
+	Keywords            []topics.Term `json:"keywords"` // single words by score
+}
+
+// Comparison is several apps' summaries over the same window, in the order the apps were given
+type Comparison struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	Apps  []Summary `json:"apps"`
+}
+
+// Compare summarizes each app's reviews over the window ending at end. Reviews should already be filtered but not cut
+// to the window, since older reviews tell when each version was first seen. limit is how many keywords each app
+// gets, defaulting to config.COMPARE_KEYWORDS.
+func Compare(apps []App, end time.Time, window time.Duration, limit int) Comparison {
+	if limit < 1 {
+		limit = config.COMPARE_KEYWORDS
+	}
+	comparison := Comparison{Start: end.Add(-window), End: end, Apps: make([]Summary, 0, len(apps))}
+	for _, app := range apps {
+		comparison.Apps = append(comparison.Apps, summarize(app, comparison.Start, end, limit))
+	}
+	return comparison
+}
+
+// summarize builds one app's summary of the window from start to end
+func summarize(app App, start time.Time, end time.Time, limit int) Summary {
+	summary := Summary{AppId: app.AppId, CoveredFrom: start, Releases: []Release{}}
+	inWindow := models.AppReviews{}
+	firstSeen := map[string]time.Time{}
+	oldest := end
+	for _, review := range app.Reviews {
+		if review.Updated.Before(oldest) {
+			oldest = review.Updated
+		}
+		if seen, ok := firstSeen[review.Version]; !ok || review.Updated.Before(seen) {
+			firstSeen[review.Version] = review.Updated
+		}
+		if review.Updated.After(start) && !review.Updated.After(end) {
+			inWindow = append(inWindow, review)
+		}
+	}
+	if oldest.After(start) {
+		summary.CoveredFrom = oldest
+	}
+
+	stats := inWindow.Stats()
+	summary.Reviews = stats.Count
+	summary.AverageRating = stats.AverageRating
+	summary.Histogram = stats.Histogram
+	if days := end.Sub(summary.CoveredFrom).Hours() / 24; days > 0 {
+		summary.ReviewsPerDay = float64(stats.Count) / days
+	}
+
+	// A version first seen at the oldest cached review may be older than the cache, so it isn't counted as a release
+	for _, version := range stats.Versions {
+		seen := firstSeen[version.Version]
+		if len(version.Version) < 1 || !seen.After(start) || !seen.After(oldest) {
+			continue
+		}
+		summary.Releases = append(summary.Releases, Release{Version: version.Version, FirstSeen: seen,
+			Reviews: version.Count, AverageRating: version.AverageRating})
+	}
+	sort.Slice(summary.Releases, func(i, j int) bool {
+		return summary.Releases[i].FirstSeen.Before(summary.Releases[j].FirstSeen)
+	})
+	if releases := summary.Releases; len(releases) > 1 {
+		span := releases[len(releases)-1].FirstSeen.Sub(releases[0].FirstSeen)
+		summary.DaysBetweenReleases = span.Hours() / 24 / float64(len(releases)-1)
+	}
+
+	documents := make([]topics.Document, 0, len(inWindow))
+	for _, review := range inWindow {
+		documents = append(documents, topics.Document{Text: review.Title + ".\n" + review.Content,
+			Language: review.Language, Time: review.Updated})
+	}
+	summary.Keywords = topics.Analyze(documents, end, end.Sub(start), topics.Options{Limit: limit}).Terms
+	return summary
+}
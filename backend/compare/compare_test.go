@@ -0,0 +1,52 @@
+package compare
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marcuswu/app-reviews/models"
+)
+
+func TestCompare(t *testing.T) {
+	end := time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	apps := []App{
+		{AppId: "1", Reviews: models.AppReviews{
+			{Id: "1", Rating: 5, Version: "3.0", Title: "Sync is great", Content: "Sync works", Updated: end.Add(-day)},
+			{Id: "2", Rating: 4, Version: "3.0", Title: "Sync", Content: "Fast sync", Updated: end.Add(-2 * day)},
+			{Id: "3", Rating: 2, Version: "2.0", Title: "Crash", Content: "It crashes", Updated: end.Add(-5 * day)},
+			{Id: "4", Rating: 3, Version: "1.0", Title: "Okay", Content: "Fine", Updated: end.Add(-9 * day)},
+			{Id: "5", Rating: 1, Version: "1.0", Title: "Old", Content: "Old", Updated: end.Add(-40 * day)},
+		}},
+		{AppId: "2", Reviews: models.AppReviews{
+			{Id: "6", Rating: 1, Version: "5.1", Title: "Crash", Content: "Crashes", Updated: end.Add(-day)},
+			{Id: "7", Rating: 3, Version: "5.0", Title: "Meh", Content: "Meh", Updated: end.Add(-6 * day)},
+		}},
+	}
+
+	comparison := Compare(apps, end, 30*day, 0)
+	if !comparison.Start.Equal(end.Add(-30*day)) || len(comparison.Apps) != 2 {
+		t.Fatalf("unexpected comparison %+v", comparison)
+	}
+
+	first := comparison.Apps[0]
+	if first.AppId != "1" || first.Reviews != 4 || first.AverageRating != 3.5 || first.Histogram[1] != 0 ||
+		first.Histogram[5] != 1 || !first.CoveredFrom.Equal(comparison.Start) || first.ReviewsPerDay != 4.0/30 {
+		t.Errorf("unexpected summary %+v", first)
+	}
+	// 1.0 predates the window, so 2.0 and 3.0 are the releases in it, 3 days apart
+	if len(first.Releases) != 2 || first.Releases[0].Version != "2.0" || first.Releases[1].Version != "3.0" ||
+		first.Releases[1].Reviews != 2 || first.DaysBetweenReleases != 3 {
+		t.Errorf("unexpected releases %+v (%v days apart)", first.Releases, first.DaysBetweenReleases)
+	}
+	if len(first.Keywords) < 1 || first.Keywords[0].Term != "sync" {
+		t.Errorf("expected sync to be the top keyword, got %+v", first.Keywords)
+	}
+
+	// The second app's cache starts inside the window, so its oldest version may be older than the cache
+	second := comparison.Apps[1]
+	if !second.CoveredFrom.Equal(end.Add(-6*day)) || second.ReviewsPerDay != 2.0/6 || len(second.Releases) != 1 ||
+		second.Releases[0].Version != "5.1" || second.DaysBetweenReleases != 0 {
+		t.Errorf("unexpected summary %+v", second)
+	}
+}
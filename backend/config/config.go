@@ -1,7 +1,74 @@
 package config
 
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
 // There is more that could go in here such as the cache filename patterns
 // This could also be utilizing environment or reading from a config file (dotenv would be nice)
 const MAX_REVIEW_FILE_AGE_MINUTES = 10
 const OLDEST_REVIEW_HOURS = 48
 const SERVER_PORT = 8000
+const DEFAULT_STOREFRONT = "us"
+const DRAIN_TIMEOUT = 15 * time.Second   // how long shutdown waits for in-flight requests and fetches
+const REFRESHER_STALL_SECONDS = 120      // how long without a refresher run before the server reports not ready
+const CLIENT_REQUESTS_PER_MINUTE = 120   // requests each client (API key or IP) may make
+const CLIENT_FETCHES_PER_MINUTE = 6      // requests each client may make that fetch from Apple because of a cache miss
+const NEW_APPS_PER_HOUR = 20             // distinct apps that may be added to the cache by requests each hour
+const NO_FEED_CACHE_MINUTES = 60         // how long to remember that an app has no review feed
+const MAX_FEED_PAGES = 10                // most pages of reviews Apple serves for an app
+const REFRESH_RETRY_MINUTES = 5          // how long the refresher skips an app whose refresh failed
+const REQUEST_TIMEOUT = 30 * time.Second // how long a request may take before it is answered with 503
+const GRPC_PORT = 9000                   // suggested port for the gRPC server, which is off unless an address is set
+const STREAM_POLL_SECONDS = 30           // how often gRPC review streams check the cache for new reviews
+const TOPIC_WINDOW_HOURS = 168           // window topics are found in, compared with the window before it
+const TOPIC_LIMIT = 20                   // terms returned in each topics list by default
+const MAX_TOPIC_LIMIT = 100              // most terms a topics request may ask for in each list
+const TOPIC_MIN_REVIEWS = 2              // reviews in the window a term must appear in to be a topic
+const DUPLICATE_SIMILARITY = 0.8         // estimated Jaccard similarity at which two reviews are near duplicates
+const DUPLICATE_MIN_WORDS = 6            // words a review needs before it is compared for near duplicates
+const SPAM_AUTHOR_REVIEWS = 3            // reviews by one author within SPAM_AUTHOR_WINDOW_HOURS that look like spam
+const SPAM_AUTHOR_WINDOW_HOURS = 24      // window SPAM_AUTHOR_REVIEWS are counted in
+const SPAM_CHECK_MINUTES = 15            // how often every cached app's reviews are checked for spam
+const ANOMALY_WINDOW_HOURS = 24          // recent window checked for rating anomalies after each refresh
+const ANOMALY_BASELINE_DAYS = 14         // how far back the baseline recent ratings are compared with reaches
+const ANOMALY_Z_SCORE = 3.0              // z-score against the baseline at which recent ratings are anomalous
+const ANOMALY_MIN_REVIEWS = 5            // reviews the recent window needs before it can be anomalous
+const NOTIFY_TIMEOUT = 10 * time.Second  // how long sending an event to a notification channel may take
+const NOTIFY_QUEUE_SIZE = 100            // events that may wait to be sent before new ones are dropped
+const COMPARE_WINDOW_HOURS = 720         // window apps are compared over by default
+const MAX_COMPARE_APPS = 10              // most apps one comparison may include
+const COMPARE_KEYWORDS = 10              // top keywords listed for each app in a comparison
+
+// DEFAULT_MIDDLEWARE is the middleware wrapped around every route, outermost first
+const DEFAULT_MIDDLEWARE = "request-id,logging,recover,security,cors,timeout"
+
+// Env returns the value of an environment variable prefixed with APP_REVIEWS_, or a fallback if it is not set
+func Env(name string, fallback string) string {
+	if value, ok := os.LookupEnv("APP_REVIEWS_" + name); ok {
+		return value
+	}
+	return fallback
+}
+
+// EnvDuration returns an environment variable prefixed with APP_REVIEWS_ parsed as a duration (such as 30s), or a
+// fallback if it is not set or invalid
+func EnvDuration(name string, fallback time.Duration) time.Duration {
+	duration, err := time.ParseDuration(Env(name, fallback.String()))
+	if err != nil {
+		return fallback
+	}
+	return duration
+}
+
+// EnvInt returns an environment variable prefixed with APP_REVIEWS_ parsed as an integer, or a fallback if it is not
+// set or invalid
+func EnvInt(name string, fallback int) int {
+	value, err := strconv.Atoi(Env(name, strconv.Itoa(fallback)))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
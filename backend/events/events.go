@@ -0,0 +1,109 @@
+// Package events describes things worth telling someone about, such as a sudden spike in one star reviews, and the
+// notification channels they are sent to.
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/marcuswu/app-reviews/models"
+)
+
+// Event types
+const (
+	RatingAnomaly = "rating-anomaly" // recent ratings are unusually low or one star reviews unusually frequent
+	ReviewEdited  = "review-edited"  // an author changed the title, content or rating of their review
+	ReviewRemoved = "review-removed" // reviews disappeared from Apple's feed
+)
+
+// Kinds of ReviewEdited event
+const (
+	RatingChanged = "rating-changed" // the rating changed, and perhaps the text too
+	TextChanged   = "text-changed"   // only the title or content changed
+)
+
+// Event is something that happened to an app's reviews
+type Event struct {
+	Type    string             `json:"type"`
+	Kind    string             `json:"kind,omitempty"` // what sort of event of its type, such as low-rating-spike
+	AppId   string             `json:"appId"`
+	Time    time.Time          `json:"time"`
+	Message string             `json:"message"`           // a one line summary for people
+	Details map[string]float64 `json:"details,omitempty"` // measurements behind the event, by name
+	Reviews models.AppReviews  `json:"reviews"`           // the reviews the event is about
+}
+
+// Notifier is a notification channel events are sent to
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// NotifierFunc adapts a function to a Notifier
+type NotifierFunc func(ctx context.Context, event Event) error
+
+// Notify calls f
+func (f NotifierFunc) Notify(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// Multi returns a Notifier that sends events to every notifier in turn, returning their errors joined
+func Multi(notifiers ...Notifier) Notifier {
+	return NotifierFunc(func(ctx context.Context, event Event) error {
+		errs := []error{}
+		for _, notifier := range notifiers {
+			if err := notifier.Notify(ctx, event); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		return errors.Join(errs...)
+	})
+}
+
+// Log returns a Notifier that logs events at warn level, or with slog.Default() when logger is nil
+func Log(logger *slog.Logger) Notifier {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return NotifierFunc(func(ctx context.Context, event Event) error {
+		ids := make([]string, 0, len(event.Reviews))
+		for _, review := range event.Reviews {
+			ids = append(ids, review.Id)
+		}
+		logger.WarnContext(ctx, event.Message, "event", event.Type, "app", event.AppId, "reviews", ids)
+		return nil
+	})
+}
+
+// Webhook returns a Notifier that POSTs each event as JSON to url, failing unless it is answered with a 2xx status.
+// A nil client uses http.DefaultClient.
+func Webhook(url string, client *http.Client) Notifier {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return NotifierFunc(func(ctx context.Context, event Event) error {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		res, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		res.Body.Close()
+		if res.StatusCode/100 != 2 {
+			return fmt.Errorf("webhook %s answered %s", url, res.Status)
+		}
+		return nil
+	})
+}
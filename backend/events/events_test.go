@@ -0,0 +1,57 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/marcuswu/app-reviews/models"
+)
+
+func TestWebhook(t *testing.T) {
+	received := []Event{}
+	status := http.StatusNoContent
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		var event Event
+		if req.Method != http.MethodPost || req.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected %s request with content type %s", req.Method, req.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(req.Body).Decode(&event); err != nil {
+			t.Errorf("expected a JSON event, got %s", err)
+		}
+		received = append(received, event)
+		res.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	event := Event{Type: RatingAnomaly, Kind: "low-rating-spike", AppId: "1234", Message: "too many 1 star reviews",
+		Details: map[string]float64{"score": 4}, Reviews: models.AppReviews{{Id: "1", Rating: 1}}}
+	webhook := Webhook(server.URL, server.Client())
+	if err := webhook.Notify(context.Background(), event); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if len(received) != 1 || received[0].AppId != "1234" || received[0].Details["score"] != 4 ||
+		len(received[0].Reviews) != 1 {
+		t.Errorf("unexpected events received %+v", received)
+	}
+
+	status = http.StatusInternalServerError
+	if err := webhook.Notify(context.Background(), event); err == nil {
+		t.Errorf("expected an error when the webhook fails")
+	}
+}
+
+func TestMulti(t *testing.T) {
+	calls := 0
+	failing := errors.New("failed")
+	notifier := Multi(
+		NotifierFunc(func(ctx context.Context, event Event) error { calls++; return failing }),
+		NotifierFunc(func(ctx context.Context, event Event) error { calls++; return nil }),
+	)
+	if err := notifier.Notify(context.Background(), Event{}); !errors.Is(err, failing) || calls != 2 {
+		t.Errorf("expected every notifier to be called and the error returned, got %v after %d calls", err, calls)
+	}
+}
@@ -1,3 +1,16 @@
 module github.com/marcuswu/app-reviews
 
 go 1.22.1
+
+require (
+	github.com/graph-gophers/graphql-go v1.7.0
+	google.golang.org/grpc v1.71.1
+	google.golang.org/protobuf v1.36.7
+)
+
+require (
+	golang.org/x/net v0.35.0 // indirect
+	golang.org/x/sys v0.30.0 // indirect
+	golang.org/x/text v0.22.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250115164207-1a7da9e5054f // indirect
+)
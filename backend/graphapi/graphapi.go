@@ -0,0 +1,491 @@
+// Package graphapi serves a GraphQL endpoint for querying apps, reviews and stats, resolved against the same
+// updater and cache as the REST API
+package graphapi
+
+import (
+	"context"
+	_ "embed"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+
+	"github.com/marcuswu/app-reviews/language"
+	"github.com/marcuswu/app-reviews/models"
+	"github.com/marcuswu/app-reviews/sentiment"
+	"github.com/marcuswu/app-reviews/tagging"
+	"github.com/marcuswu/app-reviews/updater"
+)
+
+//go:embed schema.graphql
+var schema string
+
+// maxPageSize caps how many reviews one page of a connection may hold
+const maxPageSize = 200
+
+// maxDepth caps how deeply queries may nest
+const maxDepth = 10
+
+// LoadFunc returns an app's reviews from the cache, fetching and caching them when the cache is missing or stale
+type LoadFunc func(ctx context.Context, appId string) (models.AppReviews, error)
+
+// Schema returns the GraphQL schema resolved with u and load
+func Schema(u *updater.Updater, load LoadFunc) (*graphql.Schema, error) {
+	return graphql.ParseSchema(schema, &queryResolver{updater: u, load: load}, graphql.MaxDepth(maxDepth))
+}
+
+// Handler serves GraphQL requests POSTed as JSON with query, operationName and variables
+func Handler(u *updater.Updater, load LoadFunc) (http.Handler, error) {
+	s, err := Schema(u, load)
+	if err != nil {
+		return nil, err
+	}
+	return &relay.Handler{Schema: s}, nil
+}
+
+type queryResolver struct {
+	updater *updater.Updater
+	load    LoadFunc
+}
+
+func (q *queryResolver) newApp(appId string, cached *updater.CachedApp) *appResolver {
+	return &appResolver{query: q, appId: appId, cached: cached}
+}
+
+func (q *queryResolver) App(ctx context.Context, args struct{ Id graphql.ID }) (*appResolver, error) {
+	appId := string(args.Id)
+	if len(appId) < 1 {
+		return nil, fmt.Errorf("app id is required")
+	}
+	if !models.ValidAppId(appId) {
+		return nil, fmt.Errorf("invalid app id %q, expected digits", appId)
+	}
+	return q.newApp(appId, nil), nil
+}
+
+func (q *queryResolver) Apps(ctx context.Context) ([]*appResolver, error) {
+	apps, err := q.updater.ListApps(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*appResolver, 0, len(apps))
+	for i := range apps {
+		resolvers = append(resolvers, q.newApp(apps[i].AppId, &apps[i]))
+	}
+	return resolvers, nil
+}
+
+// appResolver loads the app's reviews at most once per query, however many fields use them
+type appResolver struct {
+	query  *queryResolver
+	appId  string
+	cached *updater.CachedApp
+
+	once    sync.Once
+	reviews models.AppReviews
+	err     error
+}
+
+func (a *appResolver) loadReviews(ctx context.Context) (models.AppReviews, error) {
+	a.once.Do(func() { a.reviews, a.err = a.query.load(ctx, a.appId) })
+	return a.reviews, a.err
+}
+
+type reviewFilterInput struct {
+	Ratings    *[]int32
+	Keyword    *string
+	Sentiments *[]string
+	Tags       *[]string
+	Languages  *[]string
+	Spam       *string
+	Removed    *string
+}
+
+// reviewOrders maps ReviewOrder enum values to models.ReviewOrder
+var reviewOrders = map[string]models.ReviewOrder{
+	"NEWEST":        models.OrderNewest,
+	"OLDEST":        models.OrderOldest,
+	"MOST_NEGATIVE": models.OrderMostNegative,
+	"MOST_POSITIVE": models.OrderMostPositive,
+}
+
+// selectReviews returns the reviews within hours that pass the filter
+func (a *appResolver) selectReviews(ctx context.Context, hours int32, input *reviewFilterInput) (models.AppReviews, error) {
+	filter := models.ReviewFilter{}
+	if input != nil {
+		if input.Keyword != nil {
+			filter.Keyword = *input.Keyword
+		}
+		if input.Ratings != nil {
+			for _, rating := range *input.Ratings {
+				if rating < 1 || rating > 5 {
+					return nil, fmt.Errorf("invalid rating %d, expected a number from 1 to 5", rating)
+				}
+				filter.Ratings = append(filter.Ratings, int(rating))
+			}
+		}
+		if input.Sentiments != nil {
+			for _, value := range *input.Sentiments {
+				label, err := sentiment.ParseLabel(value)
+				if err != nil {
+					return nil, err
+				}
+				filter.Sentiments = append(filter.Sentiments, label)
+			}
+		}
+		if input.Tags != nil {
+			for _, tag := range *input.Tags {
+				if tag = tagging.NormalizeTag(tag); len(tag) > 0 {
+					filter.Tags = append(filter.Tags, tag)
+				}
+			}
+		}
+		if input.Languages != nil {
+			for _, code := range *input.Languages {
+				code = strings.ToLower(strings.TrimSpace(code))
+				if err := language.Check(code); err != nil {
+					return nil, err
+				}
+				filter.Languages = append(filter.Languages, code)
+			}
+		}
+		if input.Spam != nil {
+			spamFilter, err := models.ParseSpamFilter(*input.Spam)
+			if err != nil {
+				return nil, err
+			}
+			filter.Spam = spamFilter
+		}
+		if input.Removed != nil {
+			removedFilter, err := models.ParseRemovedFilter(*input.Removed)
+			if err != nil {
+				return nil, err
+			}
+			filter.Removed = removedFilter
+		}
+	}
+
+	reviews, err := a.loadReviews(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// Copy before Within sorts so concurrently resolved fields don't share a slice
+	reviews = append(models.AppReviews{}, reviews...)
+	return reviews.Within(time.Duration(hours) * time.Hour).Filter(filter), nil
+}
+
+func (a *appResolver) Id() graphql.ID {
+	return graphql.ID(a.appId)
+}
+
+func (a *appResolver) CacheModified(ctx context.Context) (*graphql.Time, error) {
+	if a.cached == nil {
+		apps, err := a.query.updater.ListApps(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for i := range apps {
+			if apps[i].AppId == a.appId {
+				a.cached = &apps[i]
+			}
+		}
+	}
+	if a.cached == nil {
+		return nil, nil
+	}
+	return &graphql.Time{Time: a.cached.Modified}, nil
+}
+
+type reviewsArgs struct {
+	Hours  int32
+	Filter *reviewFilterInput
+	Sort   string
+	First  int32
+	After  *string
+}
+
+func (a *appResolver) Reviews(ctx context.Context, args reviewsArgs) (*connectionResolver, error) {
+	reviews, err := a.selectReviews(ctx, args.Hours, args.Filter)
+	if err != nil {
+		return nil, err
+	}
+	reviews.Sort(reviewOrders[args.Sort])
+
+	start := 0
+	if args.After != nil {
+		afterId, err := decodeCursor(*args.After)
+		if err != nil {
+			return nil, err
+		}
+		start = -1
+		for i, review := range reviews {
+			if review.Id == afterId {
+				start = i + 1
+				break
+			}
+		}
+		if start < 0 {
+			return nil, fmt.Errorf("cursor %q does not match a review", *args.After)
+		}
+	}
+	if args.First < 0 || args.First > maxPageSize {
+		return nil, fmt.Errorf("first must be from 0 to %d", maxPageSize)
+	}
+	end := min(start+int(args.First), len(reviews))
+
+	return &connectionResolver{total: len(reviews), page: reviews[start:end], hasNext: end < len(reviews)}, nil
+}
+
+type statsArgs struct {
+	Hours  int32
+	Filter *reviewFilterInput
+}
+
+func (a *appResolver) Stats(ctx context.Context, args statsArgs) (*statsResolver, error) {
+	reviews, err := a.selectReviews(ctx, args.Hours, args.Filter)
+	if err != nil {
+		return nil, err
+	}
+	return &statsResolver{stats: reviews.Stats()}, nil
+}
+
+// encodeCursor makes an opaque cursor pointing at a review
+func encodeCursor(reviewId string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("review:" + reviewId))
+}
+
+func decodeCursor(cursor string) (string, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(decoded) < len("review:") || string(decoded[:len("review:")]) != "review:" {
+		return "", fmt.Errorf("invalid cursor %q", cursor)
+	}
+	return string(decoded[len("review:"):]), nil
+}
+
+type connectionResolver struct {
+	total   int
+	page    models.AppReviews
+	hasNext bool
+}
+
+func (c *connectionResolver) TotalCount() int32 {
+	return int32(c.total)
+}
+
+func (c *connectionResolver) Edges() []*edgeResolver {
+	edges := make([]*edgeResolver, 0, len(c.page))
+	for _, review := range c.page {
+		edges = append(edges, &edgeResolver{review: review})
+	}
+	return edges
+}
+
+func (c *connectionResolver) Nodes() []*reviewResolver {
+	nodes := make([]*reviewResolver, 0, len(c.page))
+	for _, review := range c.page {
+		nodes = append(nodes, &reviewResolver{review: review})
+	}
+	return nodes
+}
+
+func (c *connectionResolver) PageInfo() *pageInfoResolver {
+	info := &pageInfoResolver{hasNext: c.hasNext}
+	if len(c.page) > 0 {
+		cursor := encodeCursor(c.page[len(c.page)-1].Id)
+		info.endCursor = &cursor
+	}
+	return info
+}
+
+type edgeResolver struct {
+	review models.AppReview
+}
+
+func (e *edgeResolver) Cursor() string {
+	return encodeCursor(e.review.Id)
+}
+
+func (e *edgeResolver) Node() *reviewResolver {
+	return &reviewResolver{review: e.review}
+}
+
+type pageInfoResolver struct {
+	hasNext   bool
+	endCursor *string
+}
+
+func (p *pageInfoResolver) HasNextPage() bool {
+	return p.hasNext
+}
+
+func (p *pageInfoResolver) EndCursor() *string {
+	return p.endCursor
+}
+
+type reviewResolver struct {
+	review models.AppReview
+}
+
+func (r *reviewResolver) Id() graphql.ID        { return graphql.ID(r.review.Id) }
+func (r *reviewResolver) Title() string         { return r.review.Title }
+func (r *reviewResolver) Content() string       { return r.review.Content }
+func (r *reviewResolver) Rating() int32         { return int32(r.review.Rating) }
+func (r *reviewResolver) Version() string       { return r.review.Version }
+func (r *reviewResolver) Updated() graphql.Time { return graphql.Time{Time: r.review.Updated} }
+func (r *reviewResolver) Link() string          { return r.review.Link }
+func (r *reviewResolver) Sentiment() float64    { return r.review.Sentiment }
+func (r *reviewResolver) SentimentLabel() string {
+	return strings.ToUpper(string(sentiment.Classify(r.review.Sentiment)))
+}
+
+func (r *reviewResolver) Tags() []string {
+	if r.review.Tags == nil {
+		return []string{}
+	}
+	return r.review.Tags
+}
+
+func (r *reviewResolver) Language() string { return r.review.LanguageOrUndetermined() }
+
+func (r *reviewResolver) Spam() []string {
+	if r.review.Spam == nil {
+		return []string{}
+	}
+	return r.review.Spam
+}
+
+func (r *reviewResolver) Revisions() []*revisionResolver {
+	revisions := make([]*revisionResolver, len(r.review.Revisions))
+	for i, revision := range r.review.Revisions {
+		revisions[i] = &revisionResolver{revision: revision}
+	}
+	return revisions
+}
+
+func (r *reviewResolver) RemovedAt() *graphql.Time {
+	if !r.review.Removed() {
+		return nil
+	}
+	return &graphql.Time{Time: *r.review.RemovedAt}
+}
+
+func (r *reviewResolver) Author() *authorResolver {
+	return &authorResolver{author: r.review.Author}
+}
+
+type revisionResolver struct {
+	revision models.Revision
+}
+
+func (r *revisionResolver) Updated() graphql.Time { return graphql.Time{Time: r.revision.Updated} }
+func (r *revisionResolver) Rating() int32         { return int32(r.revision.Rating) }
+func (r *revisionResolver) Version() string       { return r.revision.Version }
+func (r *revisionResolver) Title() string         { return r.revision.Title }
+func (r *revisionResolver) Content() string       { return r.revision.Content }
+
+type authorResolver struct {
+	author models.Author
+}
+
+func (a *authorResolver) Name() string { return a.author.Name }
+func (a *authorResolver) Uri() string  { return a.author.Uri }
+
+type statsResolver struct {
+	stats models.ReviewStats
+}
+
+func (s *statsResolver) Count() int32           { return int32(s.stats.Count) }
+func (s *statsResolver) AverageRating() float64 { return s.stats.AverageRating }
+func (s *statsResolver) Histogram() *histogramResolver {
+	return &histogramResolver{histogram: s.stats.Histogram}
+}
+func (s *statsResolver) AverageSentiment() float64 { return s.stats.AverageSentiment }
+func (s *statsResolver) Sentiments() *sentimentCountsResolver {
+	return &sentimentCountsResolver{counts: s.stats.Sentiments}
+}
+func (s *statsResolver) SentimentOverTime() []*sentimentPeriodResolver {
+	periods := make([]*sentimentPeriodResolver, 0, len(s.stats.SentimentOverTime))
+	for _, period := range s.stats.SentimentOverTime {
+		periods = append(periods, &sentimentPeriodResolver{period: period})
+	}
+	return periods
+}
+func (s *statsResolver) Languages() []*languageCountResolver {
+	languages := make([]*languageCountResolver, 0, len(s.stats.Languages))
+	for code, count := range s.stats.Languages {
+		languages = append(languages, &languageCountResolver{language: code, count: count})
+	}
+	sort.Slice(languages, func(i, j int) bool {
+		if languages[i].count != languages[j].count {
+			return languages[i].count > languages[j].count
+		}
+		return languages[i].language < languages[j].language
+	})
+	return languages
+}
+func (s *statsResolver) Spam() int32 { return int32(s.stats.Spam) }
+func (s *statsResolver) Versions() []*versionStatsResolver {
+	versions := make([]*versionStatsResolver, 0, len(s.stats.Versions))
+	for _, version := range s.stats.Versions {
+		versions = append(versions, &versionStatsResolver{version: version})
+	}
+	return versions
+}
+
+type histogramResolver struct {
+	histogram map[int]int
+}
+
+func (h *histogramResolver) Buckets() []*ratingBucketResolver {
+	buckets := make([]*ratingBucketResolver, 0, 5)
+	for rating := 1; rating <= 5; rating++ {
+		buckets = append(buckets, &ratingBucketResolver{rating: rating, count: h.histogram[rating]})
+	}
+	return buckets
+}
+
+type ratingBucketResolver struct {
+	rating int
+	count  int
+}
+
+func (b *ratingBucketResolver) Rating() int32 { return int32(b.rating) }
+func (b *ratingBucketResolver) Count() int32  { return int32(b.count) }
+
+type versionStatsResolver struct {
+	version models.VersionStats
+}
+
+func (v *versionStatsResolver) Version() string        { return v.version.Version }
+func (v *versionStatsResolver) Count() int32           { return int32(v.version.Count) }
+func (v *versionStatsResolver) AverageRating() float64 { return v.version.AverageRating }
+
+type sentimentCountsResolver struct {
+	counts map[sentiment.Label]int
+}
+
+func (c *sentimentCountsResolver) Negative() int32 { return int32(c.counts[sentiment.Negative]) }
+func (c *sentimentCountsResolver) Neutral() int32  { return int32(c.counts[sentiment.Neutral]) }
+func (c *sentimentCountsResolver) Positive() int32 { return int32(c.counts[sentiment.Positive]) }
+
+type languageCountResolver struct {
+	language string
+	count    int
+}
+
+func (l *languageCountResolver) Language() string { return l.language }
+func (l *languageCountResolver) Count() int32     { return int32(l.count) }
+
+type sentimentPeriodResolver struct {
+	period models.SentimentPeriod
+}
+
+func (p *sentimentPeriodResolver) Start() graphql.Time       { return graphql.Time{Time: p.period.Start} }
+func (p *sentimentPeriodResolver) Count() int32              { return int32(p.period.Count) }
+func (p *sentimentPeriodResolver) AverageSentiment() float64 { return p.period.AverageSentiment }
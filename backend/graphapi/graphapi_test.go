@@ -0,0 +1,393 @@
+package graphapi
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"github.com/marcuswu/app-reviews/clock/clocktest"
+	"github.com/marcuswu/app-reviews/models"
+	"github.com/marcuswu/app-reviews/updater"
+)
+
+var start = time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+
+// testReviews are the reviews served for app 1234, newest first
+func testReviews() models.AppReviews {
+	return models.AppReviews{
+		{Id: "4", Rating: 5, Version: "2.0", Title: "Great", Content: "Love it", Updated: start.Add(-time.Hour)},
+		{Id: "3", Rating: 1, Version: "2.0", Title: "Crashes", Content: "Crashes on launch", Updated: start.Add(-2 * time.Hour)},
+		{Id: "2", Rating: 3, Version: "1.0", Title: "Okay", Content: "Fine", Updated: start.Add(-3 * time.Hour)},
+		{Id: "1", Rating: 4, Version: "1.0", Title: "Good", Updated: start.Add(-72 * time.Hour)},
+	}
+}
+
+// newSchema returns a schema whose load function serves testReviews for app 1234, caches them and returns the cache
+func newSchema(t *testing.T) (*graphql.Schema, *updater.Updater) {
+	fake := clocktest.NewFake(start)
+	models.SetClock(fake)
+	t.Cleanup(func() { models.SetClock(nil) })
+
+	u := updater.New(updater.WithStore(updater.NewFileStore(t.TempDir())), updater.WithClock(fake))
+	load := func(ctx context.Context, appId string) (models.AppReviews, error) {
+		if appId != "1234" {
+			return nil, updater.ErrNoFeed
+		}
+		if err := u.SaveReviews(ctx, appId, testReviews()); err != nil {
+			return nil, err
+		}
+		return u.LoadReviews(ctx, appId)
+	}
+	s, err := Schema(u, load)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, u
+}
+
+// execute runs a query against a new schema and returns the response data decoded into result and the error messages
+func execute(t *testing.T, query string, variables map[string]any, result any) []string {
+	s, _ := newSchema(t)
+	return executeWith(t, s, query, variables, result)
+}
+
+func executeWith(t *testing.T, s *graphql.Schema, query string, variables map[string]any, result any) []string {
+	res := s.Exec(context.Background(), query, "", variables)
+	if result != nil && len(res.Data) > 0 {
+		if err := json.Unmarshal(res.Data, result); err != nil {
+			t.Fatal(err)
+		}
+	}
+	messages := []string{}
+	for _, err := range res.Errors {
+		messages = append(messages, err.Message)
+	}
+	return messages
+}
+
+type reviewPage struct {
+	App struct {
+		Reviews struct {
+			TotalCount int
+			Nodes      []struct{ Id, Title string }
+			PageInfo   struct {
+				HasNextPage bool
+				EndCursor   *string
+			}
+		}
+	}
+}
+
+func TestReviewsPagination(t *testing.T) {
+	query := `query($after: String) {
+		app(id: "1234") {
+			reviews(first: 2, after: $after) {
+				totalCount
+				nodes { id title }
+				pageInfo { hasNextPage endCursor }
+			}
+		}
+	}`
+
+	first := reviewPage{}
+	if errs := execute(t, query, nil, &first); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	reviews := first.App.Reviews
+	if reviews.TotalCount != 3 || len(reviews.Nodes) != 2 || reviews.Nodes[0].Id != "4" || !reviews.PageInfo.HasNextPage {
+		t.Fatalf("expected the newest 2 of the 3 reviews within 48 hours, got %+v", reviews)
+	}
+
+	second := reviewPage{}
+	if errs := execute(t, query, map[string]any{"after": *reviews.PageInfo.EndCursor}, &second); len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	reviews = second.App.Reviews
+	if len(reviews.Nodes) != 1 || reviews.Nodes[0].Id != "2" || reviews.PageInfo.HasNextPage {
+		t.Errorf("expected the last review on the second page, got %+v", reviews)
+	}
+
+	errs := execute(t, query, map[string]any{"after": "bogus"}, nil)
+	if len(errs) != 1 || !strings.Contains(errs[0], "invalid cursor") {
+		t.Errorf("expected an invalid cursor error, got %v", errs)
+	}
+}
+
+func TestFilteredStats(t *testing.T) {
+	result := struct {
+		App struct {
+			Stats struct {
+				Count         int
+				AverageRating float64
+				Histogram     struct{ Buckets []struct{ Rating, Count int } }
+				Versions      []struct {
+					Version string
+					Count   int
+				}
+			}
+			Reviews struct{ Nodes []struct{ Id string } }
+		}
+	}{}
+	errs := execute(t, `{
+		app(id: "1234") {
+			stats(hours: 100, filter: {ratings: [1, 4, 5]}) {
+				count averageRating
+				histogram { buckets { rating count } }
+				versions { version count }
+			}
+			reviews(filter: {keyword: "crash"}) { nodes { id } }
+		}
+	}`, nil, &result)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	stats := result.App.Stats
+	if stats.Count != 3 || stats.AverageRating != 10.0/3 || len(stats.Histogram.Buckets) != 5 || stats.Histogram.Buckets[0].Count != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if len(stats.Versions) != 2 || stats.Versions[0].Version != "2.0" || stats.Versions[0].Count != 2 {
+		t.Errorf("expected counts for both versions, newest first, got %+v", stats.Versions)
+	}
+	if nodes := result.App.Reviews.Nodes; len(nodes) != 1 || nodes[0].Id != "3" {
+		t.Errorf("expected only the review mentioning crashes, got %+v", nodes)
+	}
+}
+
+func TestApps(t *testing.T) {
+	result := struct {
+		Apps []struct {
+			Id            string
+			CacheModified *time.Time
+			Stats         struct{ Count int }
+		}
+	}{}
+	s, u := newSchema(t)
+	if err := u.SaveReviews(context.Background(), "1234", testReviews()); err != nil {
+		t.Fatal(err)
+	}
+	errs := executeWith(t, s, `{ apps { id cacheModified stats { count } } }`, nil, &result)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if len(result.Apps) != 1 || result.Apps[0].Id != "1234" || result.Apps[0].CacheModified == nil || result.Apps[0].Stats.Count != 3 {
+		t.Errorf("expected the cached app, got %+v", result.Apps)
+	}
+}
+
+func TestInvalidArguments(t *testing.T) {
+	tests := map[string]string{
+		`{ app(id: "abc") { id } }`:                                        "expected digits",
+		`{ app(id: "1234") { stats(filter: {ratings: [6]}) { count } } }`:  "invalid rating 6",
+		`{ app(id: "1234") { reviews(first: 1000) { totalCount } } }`:      "first must be",
+		`{ app(id: "4321") { reviews { totalCount } } }`:                   updater.ErrNoFeed.Error(),
+		`{ app(id: "1234") { reviews { nodes { author { missing } } } } }`: "Cannot query field",
+	}
+	for query, expected := range tests {
+		errs := execute(t, query, nil, nil)
+		if len(errs) < 1 || !strings.Contains(errs[0], expected) {
+			t.Errorf("expected %q for %s, got %v", expected, query, errs)
+		}
+	}
+}
+
+func TestSentiment(t *testing.T) {
+	result := struct {
+		App struct {
+			Reviews struct {
+				Nodes []struct {
+					Id             string
+					Sentiment      float64
+					SentimentLabel string
+				}
+			}
+			Stats struct {
+				AverageSentiment  float64
+				Sentiments        struct{ Negative, Neutral, Positive int }
+				SentimentOverTime []struct{ Count int }
+			}
+		}
+	}{}
+	errs := execute(t, `{
+		app(id: "1234") {
+			reviews(sort: MOST_NEGATIVE, filter: {sentiments: [NEGATIVE, POSITIVE]}) { nodes { id sentiment sentimentLabel } }
+			stats { averageSentiment sentiments { negative neutral positive } sentimentOverTime { count } }
+		}
+	}`, nil, &result)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+
+	nodes := result.App.Reviews.Nodes
+	if len(nodes) != 3 || nodes[0].Id != "3" || nodes[0].SentimentLabel != "NEGATIVE" || nodes[2].Sentiment <= nodes[1].Sentiment {
+		t.Errorf("expected the crash review first and the rest by increasing sentiment, got %+v", nodes)
+	}
+	stats := result.App.Stats
+	if stats.Sentiments.Negative != 1 || stats.Sentiments.Positive != 2 || len(stats.SentimentOverTime) != 1 ||
+		stats.SentimentOverTime[0].Count != 3 {
+		t.Errorf("unexpected sentiment stats %+v", stats)
+	}
+}
+
+func TestTags(t *testing.T) {
+	result := struct {
+		App struct {
+			Reviews struct {
+				Nodes []struct {
+					Id   string
+					Tags []string
+				}
+			}
+		}
+	}{}
+	errs := execute(t, `{ app(id: "1234") { reviews(filter: {tags: ["CRASH", "billing"]}) { nodes { id tags } } } }`, nil, &result)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	nodes := result.App.Reviews.Nodes
+	if len(nodes) != 1 || nodes[0].Id != "3" || len(nodes[0].Tags) != 1 || nodes[0].Tags[0] != "crash" {
+		t.Errorf("expected only the review tagged crash, got %+v", nodes)
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	result := struct {
+		App struct {
+			Reviews struct {
+				Nodes []struct{ Id, Language string }
+			}
+			Stats struct {
+				Languages []struct {
+					Language string
+					Count    int
+				}
+			}
+		}
+	}{}
+	errs := execute(t, `{
+		app(id: "1234") {
+			reviews(filter: {languages: ["EN", "und"]}) { nodes { id language } }
+			stats { languages { language count } }
+		}
+	}`, nil, &result)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if nodes := result.App.Reviews.Nodes; len(nodes) != 3 || nodes[0].Language != "en" {
+		t.Errorf("expected the English reviews, got %+v", nodes)
+	}
+	if languages := result.App.Stats.Languages; len(languages) != 1 || languages[0].Language != "en" || languages[0].Count != 3 {
+		t.Errorf("expected 3 English reviews, got %+v", languages)
+	}
+
+	errs = execute(t, `{ app(id: "1234") { reviews(filter: {languages: ["klingon"]}) { nodes { id } } } }`, nil, &result)
+	if len(errs) < 1 {
+		t.Errorf("expected an error for an unsupported language")
+	}
+}
+
+func TestSpamFilter(t *testing.T) {
+	result := struct {
+		App struct {
+			Spam struct {
+				Nodes []struct{ Id string }
+			}
+			Reviews struct {
+				Nodes []struct{ Spam []string }
+			}
+			Stats struct{ Count, Spam int }
+		}
+	}{}
+	errs := execute(t, `{
+		app(id: "1234") {
+			spam: reviews(filter: {spam: ONLY}) { nodes { id } }
+			reviews { nodes { spam } }
+			stats(filter: {spam: EXCLUDE}) { count spam }
+		}
+	}`, nil, &result)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if len(result.App.Spam.Nodes) != 0 || len(result.App.Reviews.Nodes) != 3 || result.App.Reviews.Nodes[0].Spam == nil {
+		t.Errorf("expected no suspected spam, got %+v", result.App)
+	}
+	if result.App.Stats.Count != 3 || result.App.Stats.Spam != 0 {
+		t.Errorf("unexpected stats %+v", result.App.Stats)
+	}
+}
+
+func TestRevisions(t *testing.T) {
+	s, u := newSchema(t)
+	earlier := testReviews()
+	earlier[1].Rating = 2
+	earlier[1].Content = "Crashes sometimes"
+	if err := u.SaveReviews(context.Background(), "1234", earlier); err != nil {
+		t.Fatal(err)
+	}
+
+	result := struct {
+		App struct {
+			Reviews struct {
+				Nodes []struct {
+					Id        string
+					Revisions []struct {
+						Rating  int
+						Content string
+					}
+				}
+			}
+		}
+	}{}
+	errs := executeWith(t, s, `{ app(id: "1234") { reviews { nodes { id revisions { rating content } } } } }`, nil,
+		&result)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	for _, node := range result.App.Reviews.Nodes {
+		switch {
+		case node.Id == "3" && (len(node.Revisions) != 1 || node.Revisions[0].Rating != 2 ||
+			node.Revisions[0].Content != "Crashes sometimes"):
+			t.Errorf("expected review 3 to have its earlier revision, got %+v", node.Revisions)
+		case node.Id != "3" && (node.Revisions == nil || len(node.Revisions) != 0):
+			t.Errorf("expected review %s to have no revisions, got %+v", node.Id, node.Revisions)
+		}
+	}
+}
+
+func TestRemovedReviews(t *testing.T) {
+	s, u := newSchema(t)
+	// Review 5 was cached before but isn't served by the load function any more
+	earlier := append(testReviews(), models.AppReview{Id: "5", Rating: 1, Title: "Gone", Updated: start.Add(-4 * time.Hour)})
+	if err := u.SaveReviews(context.Background(), "1234", earlier); err != nil {
+		t.Fatal(err)
+	}
+
+	result := struct {
+		App struct {
+			Visible struct{ TotalCount int }
+			Removed struct {
+				Nodes []struct {
+					Id        string
+					RemovedAt *time.Time
+				}
+			}
+		}
+	}{}
+	errs := executeWith(t, s, `{
+		app(id: "1234") {
+			visible: reviews { totalCount }
+			removed: reviews(filter: {removed: ONLY}) { nodes { id removedAt } }
+		}
+	}`, nil, &result)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	if result.App.Visible.TotalCount != 3 || len(result.App.Removed.Nodes) != 1 ||
+		result.App.Removed.Nodes[0].Id != "5" || result.App.Removed.Nodes[0].RemovedAt == nil {
+		t.Errorf("expected review 5 to be removed and left out by default, got %+v", result.App)
+	}
+}
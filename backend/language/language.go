@@ -0,0 +1,212 @@
+// Package language identifies the language reviews are written in, offline. Text in a script used by one language
+// (such as Hangul or Greek) is identified by its script. Latin script text is scored against character n-gram
+// profiles built from the samples in the samples directory with a naive Bayes classifier.
+package language
+
+import (
+	"embed"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Undetermined is the language of text that is too short or too ambiguous to identify (the BCP 47 "und" code)
+const Undetermined = "und"
+
+// minLetters is how many letters Latin script text needs before its language is guessed
+const minLetters = 8
+
+// minMargin is how much more likely, per n-gram and as a log probability, the best language must be than the
+// runner up
+const minMargin = 0.02
+
+// maxGram is the longest n-gram in the profiles
+const maxGram = 3
+
+//go:embed samples/*.txt
+var sampleFiles embed.FS
+
+// profile holds the smoothed log probability of each n-gram in a language
+type profile struct {
+	language string
+	logProb  map[string]float64
+	unseen   float64 // log probability of an n-gram missing from the samples
+}
+
+var profiles = loadProfiles()
+
+// scripts maps scripts used by a single language (or a dominant one) to its code. Han is checked after Hiragana
+// and Katakana so Japanese text using kanji isn't mistaken for Chinese.
+var scripts = []struct {
+	table    *unicode.RangeTable
+	language string
+}{
+	{unicode.Hiragana, "ja"},
+	{unicode.Katakana, "ja"},
+	{unicode.Hangul, "ko"},
+	{unicode.Han, "zh"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+}
+
+func loadProfiles() []profile {
+	files, err := sampleFiles.ReadDir("samples")
+	if err != nil {
+		panic(err)
+	}
+
+	counts := map[string]map[string]int{}
+	vocabulary := map[string]bool{}
+	for _, file := range files {
+		data, err := sampleFiles.ReadFile(path.Join("samples", file.Name()))
+		if err != nil {
+			panic(err)
+		}
+		language := strings.TrimSuffix(file.Name(), ".txt")
+		counts[language] = map[string]int{}
+		for _, gram := range ngrams(string(data)) {
+			counts[language][gram]++
+			vocabulary[gram] = true
+		}
+	}
+
+	// Add one smoothing over the n-grams seen in any language
+	result := []profile{}
+	for language, grams := range counts {
+		total := 0
+		for _, count := range grams {
+			total += count
+		}
+		denominator := math.Log(float64(total + len(vocabulary)))
+		p := profile{language: language, logProb: map[string]float64{}, unseen: -denominator}
+		for gram, count := range grams {
+			p.logProb[gram] = math.Log(float64(count+1)) - denominator
+		}
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].language < result[j].language })
+	return result
+}
+
+// ngrams returns the 1 to maxGram letter n-grams of each lowercase word in text, with words padded by a space on
+// either side so n-grams at the start and end of words are distinct
+func ngrams(text string) []string {
+	grams := []string{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		runes := []rune(" " + word + " ")
+		for n := 1; n <= maxGram; n++ {
+			for i := 0; i+n <= len(runes); i++ {
+				gram := string(runes[i : i+n])
+				if gram != " " {
+					grams = append(grams, gram)
+				}
+			}
+		}
+	}
+	return grams
+}
+
+// Languages returns the codes of the languages Detect can return other than Undetermined, sorted
+func Languages() []string {
+	languages := []string{}
+	seen := map[string]bool{}
+	for _, p := range profiles {
+		languages = append(languages, p.language)
+		seen[p.language] = true
+	}
+	for _, script := range scripts {
+		if !seen[script.language] {
+			languages = append(languages, script.language)
+			seen[script.language] = true
+		}
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Check returns an error unless code is a language Detect can return, including Undetermined
+func Check(code string) error {
+	if code == Undetermined {
+		return nil
+	}
+	for _, language := range Languages() {
+		if language == code {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported language %q, expected %s or one of %s", code, Undetermined,
+		strings.Join(Languages(), ", "))
+}
+
+// Detect returns the code of the language text is written in, such as "en" or "ja", or Undetermined
+func Detect(text string) string {
+	letters := 0
+	scriptCounts := make([]int, len(scripts))
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		for i, script := range scripts {
+			if unicode.Is(script.table, r) {
+				scriptCounts[i]++
+				break
+			}
+		}
+	}
+	if letters < 1 {
+		return Undetermined
+	}
+
+	// Kana alongside Han is Japanese. Otherwise the first script making up most of the letters wins.
+	if scriptCounts[0]+scriptCounts[1] > 0 && scriptCounts[0]+scriptCounts[1]+scriptCounts[3] > letters/2 {
+		return "ja"
+	}
+	for i, count := range scriptCounts {
+		if count > letters/2 {
+			return scripts[i].language
+		}
+	}
+
+	if letters < minLetters {
+		return Undetermined
+	}
+	return detectLatin(text)
+}
+
+// detectLatin scores Latin script text against each n-gram profile
+func detectLatin(text string) string {
+	grams := ngrams(text)
+	if len(grams) < 1 {
+		return Undetermined
+	}
+	best, second := math.Inf(-1), math.Inf(-1)
+	language := Undetermined
+	for _, p := range profiles {
+		score := 0.0
+		for _, gram := range grams {
+			if logProb, ok := p.logProb[gram]; ok {
+				score += logProb
+			} else {
+				score += p.unseen
+			}
+		}
+		if score > best {
+			best, second = score, best
+			language = p.language
+		} else if score > second {
+			second = score
+		}
+	}
+	if (best-second)/float64(len(grams)) < minMargin {
+		return Undetermined
+	}
+	return language
+}
@@ -0,0 +1,41 @@
+package language
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"Terrible update, it crashes all the time", "en"},
+		{"No me deja entrar a mi cuenta", "es"},
+		{"Impossible de me connecter depuis hier", "fr"},
+		{"Seit gestern kann ich mich nicht mehr anmelden", "de"},
+		{"Non riesco più ad accedere al mio account", "it"},
+		{"Não consigo entrar na minha conta desde ontem", "pt"},
+		{"Ik kan niet meer inloggen sinds gisteren", "nl"},
+		{"アプリが起動しません", "ja"},
+		{"非常好用的应用", "zh"},
+		{"정말 좋은 앱입니다", "ko"},
+		{"Отличное приложение", "ru"},
+		{"OK", Undetermined},
+		{"5 stars!!", Undetermined},
+		{"", Undetermined},
+	}
+	for _, tt := range tests {
+		if language := Detect(tt.text); language != tt.expected {
+			t.Errorf("expected %q to be %s, got %s", tt.text, tt.expected, language)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	for _, code := range []string{"en", "ja", Undetermined} {
+		if err := Check(code); err != nil {
+			t.Errorf("expected %s to be supported, got %s", code, err)
+		}
+	}
+	if err := Check("xx"); err == nil {
+		t.Errorf("expected an error for an unsupported language")
+	}
+}
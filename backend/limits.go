@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/marcuswu/app-reviews/auth"
+	"github.com/marcuswu/app-reviews/clock"
+	"github.com/marcuswu/app-reviews/config"
+	"github.com/marcuswu/app-reviews/metrics"
+	"github.com/marcuswu/app-reviews/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+var rateLimited = metrics.NewCounter("app_reviews_rate_limited_total",
+	"Requests refused by rate limits by reason (client, fetch or new_app)", "reason")
+
+// clientLimits keep any one client from overloading the server or, since a cache miss makes up to ten requests to
+// Apple, from getting the server throttled by Apple by requesting random app ids
+type clientLimits struct {
+	requests *ratelimit.Limiter     // every request, per client
+	fetches  *ratelimit.Limiter     // requests that fetch from Apple, per client
+	newApps  *ratelimit.DistinctCap // apps added to the cache by requests, across clients
+}
+
+func newClientLimits(c clock.Clock) *clientLimits {
+	return &clientLimits{
+		requests: ratelimit.NewLimiter(c, config.EnvInt("CLIENT_REQUESTS_PER_MINUTE", config.CLIENT_REQUESTS_PER_MINUTE), 0),
+		fetches:  ratelimit.NewLimiter(c, config.EnvInt("CLIENT_FETCHES_PER_MINUTE", config.CLIENT_FETCHES_PER_MINUTE), 0),
+		newApps:  ratelimit.NewDistinctCap(c, config.EnvInt("NEW_APPS_PER_HOUR", config.NEW_APPS_PER_HOUR), time.Hour),
+	}
+}
+
+var limits = newClientLimits(clock.System)
+
+// rateLimitError is returned when a client is refused by a rate limit
+type rateLimitError struct {
+	reason     string
+	retryAfter time.Duration
+}
+
+func (e *rateLimitError) Error() string {
+	switch e.reason {
+	case "fetch":
+		return "too many requests for reviews that are not cached"
+	case "new_app":
+		return "too many new apps have been requested recently"
+	}
+	return "too many requests"
+}
+
+// writeRateLimited responds 429 with a Retry-After header
+func writeRateLimited(res http.ResponseWriter, err *rateLimitError) {
+	res.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(err.retryAfter.Seconds()))))
+	http.Error(res, err.Error(), http.StatusTooManyRequests)
+}
+
+// clientId identifies the client making a request by its API key when authenticated, otherwise by its address
+func clientId(req *http.Request) string {
+	return clientIdFor(req.Context(), req.RemoteAddr)
+}
+
+// grpcClientId identifies the client making a gRPC call by its API key when authenticated, otherwise by the peer's
+// address
+func grpcClientId(ctx context.Context) string {
+	remote := ""
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		remote = p.Addr.String()
+	}
+	return clientIdFor(ctx, remote)
+}
+
+func clientIdFor(ctx context.Context, remoteAddr string) string {
+	if key, ok := auth.KeyFromContext(ctx); ok {
+		return "key:" + key.Name
+	}
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return "addr:" + remoteAddr
+	}
+	return "addr:" + host
+}
+
+type clientContextKey struct{}
+
+// clientFromContext returns the client id limitClient found for a request
+func clientFromContext(ctx context.Context) string {
+	client, _ := ctx.Value(clientContextKey{}).(string)
+	return client
+}
+
+// limitClient wraps a handler so each client is held to the per client request rate. The client id is added to the
+// request context for handlers that load reviews without the request.
+func limitClient(handler http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		client := clientId(req)
+		req = req.WithContext(context.WithValue(req.Context(), clientContextKey{}, client))
+		if result := limits.requests.Allow(client); !result.Allowed {
+			rateLimited.Inc("client")
+			slog.InfoContext(req.Context(), "client rate limited", "client", client)
+			writeRateLimited(res, &rateLimitError{reason: "client", retryAfter: result.RetryAfter})
+			return
+		}
+		handler(res, req)
+	}
+}
+
+// allowCall holds a gRPC call to the per client request rate, returning a ResourceExhausted status when refused
+func allowCall(ctx context.Context) error {
+	client := grpcClientId(ctx)
+	if result := limits.requests.Allow(client); !result.Allowed {
+		rateLimited.Inc("client")
+		slog.InfoContext(ctx, "client rate limited", "client", client)
+		return status.Errorf(codes.ResourceExhausted, "%s, retry after %s",
+			(&rateLimitError{reason: "client"}).Error(), result.RetryAfter.Round(time.Second))
+	}
+	return nil
+}
+
+// limitUnaryCalls holds unary gRPC calls to the per client request rate
+func limitUnaryCalls(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := allowCall(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// limitStreamCalls holds streaming gRPC calls to the per client request rate
+func limitStreamCalls(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := allowCall(stream.Context()); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// allowFetch returns a rateLimitError if a client may not make a request that fetches an app's reviews from Apple.
+// Apps that are not cached yet also count against the cap on new apps.
+func (l *clientLimits) allowFetch(client string, appId string, cached bool) error {
+	if result := l.fetches.Allow(client); !result.Allowed {
+		rateLimited.Inc("fetch")
+		return &rateLimitError{reason: "fetch", retryAfter: result.RetryAfter}
+	}
+	if !cached && !l.newApps.Allow(appId) {
+		rateLimited.Inc("new_app")
+		return &rateLimitError{reason: "new_app", retryAfter: time.Hour}
+	}
+	return nil
+}
+
+// allowFetches returns a rateLimitError unless a client has the budget to fetch every app in fetch from Apple,
+// without spending any of it, so a request that needs several fetches is refused before it makes any. newApps are
+// the apps in fetch that are not cached yet.
+func (l *clientLimits) allowFetches(client string, fetch []string, newApps []string) *rateLimitError {
+	if result := l.fetches.Peek(client, len(fetch)); !result.Allowed {
+		rateLimited.Inc("fetch")
+		return &rateLimitError{reason: "fetch", retryAfter: result.RetryAfter}
+	}
+	if !l.newApps.Fits(newApps) {
+		rateLimited.Inc("new_app")
+		return &rateLimitError{reason: "new_app", retryAfter: time.Hour}
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+// Package listen opens the server's listener, which may be TCP or a Unix domain socket, and loads TLS
+// certificates that can be reloaded without restarting
+package listen
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+)
+
+// unixPrefix marks a bind address as a Unix domain socket path
+const unixPrefix = "unix:"
+
+// Listen listens on a TCP address such as :8000 or 127.0.0.1:8000, or on a Unix domain socket given as
+// unix:/path/to/socket. A socket file left behind by a previous run is removed first.
+func Listen(address string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(address, unixPrefix)
+	if !ok {
+		return net.Listen("tcp", address)
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return net.Listen("unix", path)
+}
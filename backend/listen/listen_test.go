@@ -0,0 +1,212 @@
+package listen
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestListenUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reviews.sock")
+	listener, err := Listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("expected to listen on a unix socket, got %s", err)
+	}
+	// Closing a unix listener removes its socket file, so recreate a stale one without unlinking it
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+
+	listener, err = Listen("unix:" + path)
+	if err != nil {
+		t.Fatalf("expected a stale socket file to be replaced, got %s", err)
+	}
+	listener.Close()
+
+	notSocket := filepath.Join(t.TempDir(), "reviews.json")
+	os.WriteFile(notSocket, []byte("{}"), 0644)
+	if _, err := Listen("unix:" + notSocket); err == nil {
+		t.Errorf("expected a regular file not to be replaced")
+	}
+}
+
+// writeCert writes a certificate and key for localhost signed by parent (or self signed when parent is nil) and
+// returns the certificate and key
+func writeCert(t *testing.T, dir string, name string, isCA bool, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, name+".pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	os.WriteFile(filepath.Join(dir, name+"-key.pem"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	cert, _ := x509.ParseCertificate(der)
+	return cert, key
+}
+
+// serveTLS serves 200 OK over TLS from reloader and returns the server address
+func serveTLS(t *testing.T, reloader *Reloader) string {
+	listener, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{
+		TLSConfig: reloader.Config(),
+		Handler:   http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {}),
+	}
+	go server.ServeTLS(listener, "", "")
+	t.Cleanup(func() { server.Close() })
+	return listener.Addr().String()
+}
+
+func TestReloaderServesLatestCertificate(t *testing.T) {
+	dir := t.TempDir()
+	first, _ := writeCert(t, dir, "server", false, nil, nil)
+	reloader, err := NewReloader(TLSOptions{
+		CertFile: filepath.Join(dir, "server.pem"), KeyFile: filepath.Join(dir, "server-key.pem"), HTTP2: true,
+	})
+	if err != nil {
+		t.Fatalf("expected the certificate to load, got %s", err)
+	}
+	address := serveTLS(t, reloader)
+
+	servedCert := func() (*x509.Certificate, string) {
+		conn, err := tls.Dial("tcp", address, &tls.Config{InsecureSkipVerify: true, NextProtos: []string{"h2"}})
+		if err != nil {
+			t.Fatalf("expected a TLS connection, got %s", err)
+		}
+		defer conn.Close()
+		state := conn.ConnectionState()
+		return state.PeerCertificates[0], state.NegotiatedProtocol
+	}
+
+	if cert, protocol := servedCert(); !cert.Equal(first) || protocol != "h2" {
+		t.Errorf("expected the first certificate over h2, got protocol %q", protocol)
+	}
+
+	second, _ := writeCert(t, dir, "server", false, nil, nil)
+	if err := reloader.Reload(); err != nil {
+		t.Fatalf("expected the new certificate to load, got %s", err)
+	}
+	if cert, _ := servedCert(); !cert.Equal(second) {
+		t.Errorf("expected the reloaded certificate to be served")
+	}
+
+	os.WriteFile(filepath.Join(dir, "server.pem"), []byte("not a certificate"), 0644)
+	if err := reloader.Reload(); err == nil {
+		t.Errorf("expected a broken certificate to fail to reload")
+	}
+	if cert, _ := servedCert(); !cert.Equal(second) {
+		t.Errorf("expected a failed reload to keep the previous certificate")
+	}
+}
+
+func TestReloaderRequiresClientCertificates(t *testing.T) {
+	dir := t.TempDir()
+	ca, caKey := writeCert(t, dir, "ca", true, nil, nil)
+	writeCert(t, dir, "server", false, ca, caKey)
+	writeCert(t, dir, "client", false, ca, caKey)
+	reloader, err := NewReloader(TLSOptions{
+		CertFile:     filepath.Join(dir, "server.pem"),
+		KeyFile:      filepath.Join(dir, "server-key.pem"),
+		ClientCAFile: filepath.Join(dir, "ca.pem"),
+	})
+	if err != nil {
+		t.Fatalf("expected the TLS files to load, got %s", err)
+	}
+	address := serveTLS(t, reloader)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	request := func(certs []tls.Certificate) error {
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs, ServerName: "localhost"},
+		}}
+		res, err := client.Get("https://" + address + "/")
+		if err == nil {
+			res.Body.Close()
+		}
+		return err
+	}
+
+	if err := request(nil); err == nil {
+		t.Errorf("expected a request without a client certificate to fail")
+	}
+	clientCert, err := tls.LoadX509KeyPair(filepath.Join(dir, "client.pem"), filepath.Join(dir, "client-key.pem"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := request([]tls.Certificate{clientCert}); err != nil {
+		t.Errorf("expected a request with a client certificate to succeed, got %s", err)
+	}
+}
+
+func TestReloaderGRPCConfigOffersH2(t *testing.T) {
+	dir := t.TempDir()
+	writeCert(t, dir, "server", false, nil, nil)
+	reloader, err := NewReloader(TLSOptions{
+		CertFile: filepath.Join(dir, "server.pem"), KeyFile: filepath.Join(dir, "server-key.pem"), HTTP2: false,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	negotiated := func(config *tls.Config) string {
+		listener, err := tls.Listen("tcp", "127.0.0.1:0", config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer listener.Close()
+		go func() {
+			if conn, err := listener.Accept(); err == nil {
+				conn.(*tls.Conn).Handshake()
+				conn.Close()
+			}
+		}()
+		conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{InsecureSkipVerify: true,
+			NextProtos: []string{"h2", "http/1.1"}})
+		if err != nil {
+			t.Fatalf("expected a TLS connection, got %s", err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().NegotiatedProtocol
+	}
+
+	if protocol := negotiated(reloader.Config()); protocol != "http/1.1" {
+		t.Errorf("expected HTTP/1.1 with HTTP/2 off, got %q", protocol)
+	}
+	if protocol := negotiated(reloader.GRPCConfig()); protocol != "h2" {
+		t.Errorf("expected the gRPC config to offer h2 with HTTP/2 off, got %q", protocol)
+	}
+}
@@ -0,0 +1,104 @@
+package listen
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+)
+
+// TLSOptions are the files a TLS configuration is loaded from and the protocols it offers
+type TLSOptions struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string // optional. When set, clients must present a certificate signed by one of these CAs.
+	HTTP2        bool   // offer HTTP/2 as well as HTTP/1.1
+}
+
+func (o TLSOptions) nextProtos() []string {
+	if o.HTTP2 {
+		return []string{"h2", "http/1.1"}
+	}
+	return []string{"http/1.1"}
+}
+
+// Reloader serves a TLS configuration loaded from files and reloads it on request, so certificates can be rotated
+// without dropping connections
+type Reloader struct {
+	options    TLSOptions
+	config     atomic.Pointer[tls.Config]
+	grpcConfig atomic.Pointer[tls.Config] // config offering only h2
+}
+
+// NewReloader loads the TLS files
+func NewReloader(options TLSOptions) (*Reloader, error) {
+	if len(options.CertFile) < 1 || len(options.KeyFile) < 1 {
+		return nil, errors.New("TLS needs both a certificate and a key file")
+	}
+	r := &Reloader{options: options}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Reload reads the TLS files again. The current configuration is kept if any of them fail to load.
+func (r *Reloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.options.CertFile, r.options.KeyFile)
+	if err != nil {
+		return fmt.Errorf("could not load TLS certificate: %w", err)
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   r.options.nextProtos(),
+	}
+
+	if len(r.options.ClientCAFile) > 0 {
+		caPEM, err := os.ReadFile(r.options.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("could not read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return fmt.Errorf("no certificates found in client CA file %s", r.options.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	grpcConfig := config.Clone()
+	grpcConfig.NextProtos = []string{"h2"}
+	r.config.Store(config)
+	r.grpcConfig.Store(grpcConfig)
+	return nil
+}
+
+// Config returns a TLS configuration for a server that picks up the latest reload for each new connection
+func (r *Reloader) Config() *tls.Config {
+	return reloading(r.options.nextProtos(), &r.config)
+}
+
+// GRPCConfig is like Config but always offers h2, which gRPC clients require even when HTTP/2 is not offered to
+// HTTP clients
+func (r *Reloader) GRPCConfig() *tls.Config {
+	return reloading([]string{"h2"}, &r.grpcConfig)
+}
+
+// reloading returns a TLS configuration that uses the latest config stored in current for each new connection.
+// GetCertificate is only there so http.Server.ServeTLS sees a certificate; older Go releases ignore
+// GetConfigForClient there and try to load one from files.
+func reloading(nextProtos []string, current *atomic.Pointer[tls.Config]) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: nextProtos,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return current.Load(), nil
+		},
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &current.Load().Certificates[0], nil
+		},
+	}
+}
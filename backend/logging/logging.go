@@ -0,0 +1,83 @@
+// Package logging sets up structured log/slog loggers and carries request ids through contexts so that log lines
+// from fetching and caching can be tied back to the HTTP request that caused them.
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+type requestIdKey struct{}
+
+// WithRequestId returns a context carrying a request id
+func WithRequestId(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, requestId)
+}
+
+// RequestId returns the request id carried by a context, or an empty string if there is none
+func RequestId(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestId, _ := ctx.Value(requestIdKey{}).(string)
+	return requestId
+}
+
+// NewRequestId returns a random request id
+func NewRequestId() string {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// contextHandler adds the request id from the context to every record logged with one
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestId := RequestId(ctx); len(requestId) > 0 {
+		record.AddAttrs(slog.String("request_id", requestId))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// ParseLevel parses a level name such as debug, info, warn or error
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return level, fmt.Errorf("invalid log level %q", name)
+	}
+	return level, nil
+}
+
+// New creates a logger writing to out at the given level in either "text" or "json" format.
+// Records logged with a context carrying a request id include it as request_id.
+func New(out io.Writer, level slog.Level, format string) (*slog.Logger, error) {
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "text":
+		handler = slog.NewTextHandler(out, options)
+	case "json":
+		handler = slog.NewJSONHandler(out, options)
+	default:
+		return nil, fmt.Errorf("invalid log format %q, expected text or json", format)
+	}
+
+	return slog.New(contextHandler{handler}), nil
+}
@@ -0,0 +1,68 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRequestIdIsLogged(t *testing.T) {
+	out := bytes.NewBuffer([]byte{})
+	logger, err := New(out, slog.LevelInfo, "json")
+	if err != nil {
+		t.Fatalf("expected no error creating logger, got %s", err)
+	}
+
+	ctx := WithRequestId(context.Background(), "abc123")
+	logger.With("app", "1234").InfoContext(ctx, "fetched reviews", "reviews", 5)
+	logger.DebugContext(ctx, "should be filtered out")
+
+	var record map[string]any
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("expected a single json record, got %s: %s", err, out.String())
+	}
+	if record["request_id"] != "abc123" || record["app"] != "1234" || record["msg"] != "fetched reviews" {
+		t.Errorf("unexpected record %v", record)
+	}
+
+	out.Reset()
+	logger.Info("no request")
+	if strings.Contains(out.String(), "request_id") {
+		t.Errorf("expected no request id without one in the context, got %s", out.String())
+	}
+}
+
+func TestNewOptions(t *testing.T) {
+	tests := []struct {
+		name   string
+		level  string
+		format string
+		error  bool
+	}{
+		{"text debug", "debug", "text", false},
+		{"json warn", "WARN", "json", false},
+		{"bad level", "loud", "text", true},
+		{"bad format", "info", "xml", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, err := ParseLevel(tt.level)
+			if err == nil {
+				_, err = New(bytes.NewBuffer([]byte{}), level, tt.format)
+			}
+			if tt.error != (err != nil) {
+				t.Errorf("expected error %v, got %v", tt.error, err)
+			}
+		})
+	}
+
+	if RequestId(context.Background()) != "" {
+		t.Errorf("expected no request id in an empty context")
+	}
+	if len(NewRequestId()) != 16 {
+		t.Errorf("expected a 16 character request id")
+	}
+}
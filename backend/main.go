@@ -1,84 +1,895 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
+	"github.com/marcuswu/app-reviews/auth"
+	"github.com/marcuswu/app-reviews/clock"
+	"github.com/marcuswu/app-reviews/compare"
 	"github.com/marcuswu/app-reviews/config"
+	"github.com/marcuswu/app-reviews/events"
+	"github.com/marcuswu/app-reviews/graphapi"
+	"github.com/marcuswu/app-reviews/listen"
+	"github.com/marcuswu/app-reviews/logging"
+	"github.com/marcuswu/app-reviews/metrics"
+	"github.com/marcuswu/app-reviews/middleware"
+	"github.com/marcuswu/app-reviews/models"
+	"github.com/marcuswu/app-reviews/openapi"
+	"github.com/marcuswu/app-reviews/rpc"
+	"github.com/marcuswu/app-reviews/rpc/reviewspb"
+	"github.com/marcuswu/app-reviews/tagging"
+	"github.com/marcuswu/app-reviews/topics"
 	"github.com/marcuswu/app-reviews/updater"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
 )
 
-// Request handler for looking up app reviews for an app.
-// Prefers local cache if within config.MAX_REVIEW_FILE_AGE_MINUTES
-// If local cache doesn't exist or is stale, fetch reviews from Apple and cache them
-func reviewRequestHandler(res http.ResponseWriter, req *http.Request) {
-	appId := req.PathValue("appId")
+// serverClock is used for readiness and refresher scheduling so tests can control time
+var serverClock clock.Clock = clock.System
+
+// apiKeys authenticates requests. Authentication is disabled when it is nil.
+var apiKeys *auth.Keyring
+
+var (
+	cacheRequests = metrics.NewCounter("app_reviews_cache_requests_total",
+		"Review requests by cache result (hit, miss or stale)", "result")
+	httpRequestDuration = metrics.NewHistogram("app_reviews_http_request_duration_seconds",
+		"Latency of HTTP requests by route, method and status code", nil, "route", "method", "code")
+)
+
+// loadAppReviews returns an app's reviews from local cache if it is within config.MAX_REVIEW_FILE_AGE_MINUTES
+// If local cache doesn't exist or is stale, fetch reviews from Apple and cache them as long as the client is
+// within its fetch limits, then return the cache so reviews that disappeared from the feed are included
+func loadAppReviews(ctx context.Context, appId string, client string) (models.AppReviews, error) {
+	reviews, err := updater.LoadReviews(ctx, appId)
+	switch {
+	case err == nil:
+		cacheRequests.Inc("hit")
+	case errors.Is(err, updater.ErrStaleCache):
+		cacheRequests.Inc("stale")
+	default:
+		cacheRequests.Inc("miss")
+	}
+	if err != nil {
+		// Apps known to have no feed are answered without asking Apple, so they don't use up any fetch budget
+		if updater.KnownNoFeed(appId) {
+			slog.DebugContext(ctx, "app is known to have no review feed", "app", appId)
+			return nil, updater.ErrNoFeed
+		}
+		if err := limits.allowFetch(client, appId, errors.Is(err, updater.ErrStaleCache)); err != nil {
+			slog.InfoContext(ctx, "fetch rate limited", "app", appId, "client", client, "error", err)
+			return nil, err
+		}
+		reviews, err = updater.FetchAppReviews(ctx, appId)
+		if err != nil {
+			slog.ErrorContext(ctx, "encountered an error fetching app reviews", "app", appId, "error", err)
+			return nil, err
+		}
+		if err = updater.SaveReviews(ctx, appId, reviews); err != nil {
+			slog.ErrorContext(ctx, "encountered an error saving app reviews", "app", appId, "error", err)
+		} else if cached, err := updater.LoadReviews(ctx, appId); err == nil {
+			reviews = cached
+		}
+	}
+	return reviews, nil
+}
+
+// requestedReviews loads the reviews for the app in the request path, applying the hours window, any rating,
+// keyword or sentiment filter and the sort order from the query string. Writes an error response and returns false
+// on failure.
+func requestedReviews(res http.ResponseWriter, req *http.Request) (models.AppReviews, bool) {
 	maxHours, err := strconv.Atoi(req.URL.Query().Get("hours"))
 	if err != nil {
 		maxHours = 48
 	}
-	fmt.Printf("handling request for app id %s\n", appId)
+	filter, err := models.ParseReviewFilter(req.URL.Query())
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+	order, err := models.ParseReviewOrder(req.URL.Query().Get("sort"))
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
 
-	reviews, err := updater.LoadReviews(appId)
+	reviews, ok := loadRequestedReviews(res, req)
+	if !ok {
+		return nil, false
+	}
+	reviews = reviews.Within(time.Duration(maxHours) * time.Hour).Filter(filter)
+	reviews.Sort(order)
+	return reviews, true
+}
+
+// loadRequestedReviews loads every cached review for the app in the request path. Writes an error response and
+// returns false on failure.
+func loadRequestedReviews(res http.ResponseWriter, req *http.Request) (models.AppReviews, bool) {
+	return loadReviewsFor(res, req, req.PathValue("appId"))
+}
+
+// loadReviewsFor loads every cached review for an app on behalf of a request. Writes an error response and returns
+// false on failure, including for an app id that isn't all digits.
+func loadReviewsFor(res http.ResponseWriter, req *http.Request, appId string) (models.AppReviews, bool) {
+	if !models.ValidAppId(appId) {
+		http.Error(res, fmt.Sprintf("invalid app id %q, expected digits", appId), http.StatusBadRequest)
+		return nil, false
+	}
+	slog.InfoContext(req.Context(), "handling request", "app", appId, "path", req.URL.Path)
+
+	reviews, err := loadAppReviews(req.Context(), appId, clientId(req))
+	var limitErr *rateLimitError
+	switch {
+	case errors.As(err, &limitErr):
+		writeRateLimited(res, limitErr)
+		return nil, false
+	case errors.Is(err, updater.ErrNoFeed):
+		http.Error(res, fmt.Sprintf("No reviews found for app %s", appId), http.StatusNotFound)
+		return nil, false
+	case err != nil:
+		http.Error(res, fmt.Sprintf("Failed to fetch app reviews: %s", err), http.StatusFailedDependency)
+		return nil, false
+	}
+	return reviews, true
+}
+
+// Request handler for looking up app reviews for an app.
+// Reviews are returned as JSON unless another export format is requested via the format query parameter or
+// the Accept header
+func reviewRequestHandler(res http.ResponseWriter, req *http.Request) {
+	appId := req.PathValue("appId")
+	format, err := requestedFormat(req)
 	if err != nil {
-		reviews, err = updater.FetchAppReviews(appId)
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reviews, ok := requestedReviews(res, req)
+	if !ok {
+		return
+	}
+
+	res.Header().Set("Content-Type", format.ContentType())
+	if format == models.FormatCSV || format == models.FormatXLSX {
+		res.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"App-%s-reviews.%s\"", appId, format))
+	}
+	if format == models.FormatJSON {
+		json.NewEncoder(res).Encode(reviews)
+		return
+	}
+	if err = models.ExportReviews(res, reviews, format); err != nil {
+		slog.ErrorContext(req.Context(), "encountered an error exporting app reviews", "app", appId, "error", err)
+	}
+}
+
+// Request handler re-publishing an app's reviews as an Atom feed
+func atomRequestHandler(res http.ResponseWriter, req *http.Request) {
+	feedRequestHandler(res, req, false)
+}
+
+// Request handler re-publishing an app's reviews as an RSS 2.0 feed
+func rssRequestHandler(res http.ResponseWriter, req *http.Request) {
+	feedRequestHandler(res, req, true)
+}
+
+// feedRequestHandler re-publishes an app's reviews as a feed for feed readers.
+// Accepts the same hours, rating and q query parameters as the reviews endpoint.
+func feedRequestHandler(res http.ResponseWriter, req *http.Request, rss bool) {
+	appId := req.PathValue("appId")
+	reviews, ok := requestedReviews(res, req)
+	if !ok {
+		return
+	}
+
+	scheme := "http"
+	if req.TLS != nil {
+		scheme = "https"
+	}
+	info := models.FeedInfo{
+		AppId: appId,
+		Title: fmt.Sprintf("App Store reviews for app %s", appId),
+		Link:  fmt.Sprintf("%s://%s%s", scheme, req.Host, req.URL.RequestURI()),
+	}
+
+	if rss {
+		res.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		err := models.SaveReviewsRSS(res, reviews, info)
 		if err != nil {
-			fmt.Printf("Encountered an error fetching app reviews: %s\n", err)
-			if len(reviews) < 1 {
-				http.Error(res, fmt.Sprintf("Failed to fetch app reviews: %s", err), http.StatusFailedDependency)
-				return
+			slog.ErrorContext(req.Context(), "encountered an error writing rss feed", "app", appId, "error", err)
+		}
+		return
+	}
+
+	res.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	if err := models.SaveReviewsAtom(res, reviews, info); err != nil {
+		slog.ErrorContext(req.Context(), "encountered an error writing atom feed", "app", appId, "error", err)
+	}
+}
+
+// topicsResponse is the trending terms in an app's reviews
+type topicsResponse struct {
+	AppId string `json:"appId"`
+	topics.Report
+}
+
+// Request handler for the terms an app's reviews mention most in the last hours (a week by default) and how they
+// changed since the window before. Accepts the review filters, a limit on the terms in each list and the lang of the
+// stop words to remove from reviews whose language wasn't detected.
+func topicsRequestHandler(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	hours, err := strconv.Atoi(query.Get("hours"))
+	if err != nil || hours < 1 {
+		hours = config.TOPIC_WINDOW_HOURS
+	}
+	limit := config.TOPIC_LIMIT
+	if len(query.Get("limit")) > 0 {
+		limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil || limit < 1 || limit > config.MAX_TOPIC_LIMIT {
+			http.Error(res, fmt.Sprintf("invalid limit %q, expected a number from 1 to %d", query.Get("limit"),
+				config.MAX_TOPIC_LIMIT), http.StatusBadRequest)
+			return
+		}
+	}
+	language := strings.ToLower(query.Get("lang"))
+	if len(language) < 1 {
+		language = "en"
+	}
+	if err := topics.CheckLanguage(language); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := models.ParseReviewFilter(query)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reviews, ok := loadRequestedReviews(res, req)
+	if !ok {
+		return
+	}
+	documents := []topics.Document{}
+	for _, review := range reviews.Filter(filter) {
+		documents = append(documents, topics.Document{Text: review.Title + ".\n" + review.Content,
+			Language: review.Language, Time: review.Updated})
+	}
+
+	report := topics.Analyze(documents, serverClock.Now(), time.Duration(hours)*time.Hour,
+		topics.Options{Language: language, Limit: limit})
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(topicsResponse{AppId: req.PathValue("appId"), Report: report})
+}
+
+// reviewHistory is every revision of a review
+type reviewHistory struct {
+	AppId     string            `json:"appId"`
+	ReviewId  string            `json:"reviewId"`
+	Revisions []models.Revision `json:"revisions"`
+}
+
+// Request handler for a review's history: its earlier revisions, oldest first, then the current one
+func reviewHistoryRequestHandler(res http.ResponseWriter, req *http.Request) {
+	reviews, ok := loadRequestedReviews(res, req)
+	if !ok {
+		return
+	}
+	reviewId := req.PathValue("reviewId")
+	for _, review := range reviews {
+		if review.Id == reviewId {
+			res.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(res).Encode(reviewHistory{AppId: req.PathValue("appId"), ReviewId: reviewId,
+				Revisions: review.History()})
+			return
+		}
+	}
+	http.Error(res, fmt.Sprintf("No review %s found for app %s", reviewId, req.PathValue("appId")),
+		http.StatusNotFound)
+}
+
+// Request handler comparing several apps side by side over the last hours (30 days by default): their rating
+// averages, review volume, histograms, release cadence and top keywords. Accepts a comma separated list of apps,
+// the review filters and a limit on the keywords listed for each app.
+func compareRequestHandler(res http.ResponseWriter, req *http.Request) {
+	query := req.URL.Query()
+	appIds := []string{}
+	for _, appId := range strings.Split(query.Get("apps"), ",") {
+		if appId = strings.TrimSpace(appId); len(appId) > 0 && !slices.Contains(appIds, appId) {
+			appIds = append(appIds, appId)
+		}
+	}
+	if len(appIds) < 2 || len(appIds) > config.MAX_COMPARE_APPS {
+		http.Error(res, fmt.Sprintf("invalid apps %q, expected 2 to %d comma separated app ids", query.Get("apps"),
+			config.MAX_COMPARE_APPS), http.StatusBadRequest)
+		return
+	}
+	for _, appId := range appIds {
+		if !models.ValidAppId(appId) {
+			http.Error(res, fmt.Sprintf("invalid app id %q, expected digits", appId), http.StatusBadRequest)
+			return
+		}
+	}
+	hours, err := strconv.Atoi(query.Get("hours"))
+	if err != nil || hours < 1 {
+		hours = config.COMPARE_WINDOW_HOURS
+	}
+	limit := config.COMPARE_KEYWORDS
+	if len(query.Get("limit")) > 0 {
+		limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil || limit < 1 || limit > config.MAX_TOPIC_LIMIT {
+			http.Error(res, fmt.Sprintf("invalid limit %q, expected a number from 1 to %d", query.Get("limit"),
+				config.MAX_TOPIC_LIMIT), http.StatusBadRequest)
+			return
+		}
+	}
+	filter, err := models.ParseReviewFilter(query)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Refuse up front rather than after fetching some of the apps
+	fetch, newApps := uncachedApps(req.Context(), appIds)
+	if limitErr := limits.allowFetches(clientId(req), fetch, newApps); limitErr != nil {
+		slog.InfoContext(req.Context(), "fetch rate limited", "apps", fetch, "client", clientId(req), "error", limitErr)
+		writeRateLimited(res, limitErr)
+		return
+	}
+	apps := []compare.App{}
+	for _, appId := range appIds {
+		reviews, ok := loadReviewsFor(res, req, appId)
+		if !ok {
+			return
+		}
+		apps = append(apps, compare.App{AppId: appId, Reviews: reviews.Filter(filter)})
+	}
+	comparison := compare.Compare(apps, serverClock.Now(), time.Duration(hours)*time.Hour, limit)
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(comparison)
+}
+
+// uncachedApps returns the apps in appIds that would be fetched from Apple when loaded, leaving out apps known to have
+// no feed, and those of them that are not cached at all
+func uncachedApps(ctx context.Context, appIds []string) ([]string, []string) {
+	fetch, newApps := []string{}, []string{}
+	for _, appId := range appIds {
+		_, err := updater.LoadReviews(ctx, appId)
+		if err == nil || updater.KnownNoFeed(appId) {
+			continue
+		}
+		fetch = append(fetch, appId)
+		if !errors.Is(err, updater.ErrStaleCache) {
+			newApps = append(newApps, appId)
+		}
+	}
+	return fetch, newApps
+}
+
+// Request handler for liveness checks. If the process can answer, it is alive.
+func healthzHandler(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(res, "ok")
+}
+
+// readiness returns why the server is not ready to serve reviews, or nil if it is
+func readiness() error {
+	if err := updater.CheckCacheWritable(); err != nil {
+		return fmt.Errorf("cache directory is not writable: %w", err)
+	}
+	lastRun := updater.RefresherLastRun()
+	if lastRun.IsZero() {
+		return errors.New("refresher has not run yet")
+	}
+	if clock.Since(serverClock, lastRun) > time.Duration(config.REFRESHER_STALL_SECONDS)*time.Second {
+		return fmt.Errorf("refresher has not run since %s", lastRun.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Request handler for readiness checks. Not ready when the cache can't be written or the refresher has stalled.
+func readyzHandler(res http.ResponseWriter, req *http.Request) {
+	res.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err := readiness(); err != nil {
+		res.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(res, err)
+		return
+	}
+	fmt.Fprintln(res, "ok")
+}
+
+// serverStatus is the body of the status endpoint
+type serverStatus struct {
+	Ready      bool   `json:"ready"`
+	ReadyError string `json:"readyError,omitempty"`
+	updater.Status
+}
+
+// Request handler reporting refresher, cache and upstream status as JSON
+func statusHandler(res http.ResponseWriter, req *http.Request) {
+	status := serverStatus{Ready: true, Status: updater.CurrentStatus()}
+	if err := readiness(); err != nil {
+		status.Ready = false
+		status.ReadyError = err.Error()
+	}
+
+	res.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(res).Encode(status)
+}
+
+// requestedFormat returns the export format asked for by the format query parameter or Accept header.
+// The query parameter wins since it is easier to set from a browser. Defaults to JSON.
+func requestedFormat(req *http.Request) (models.ExportFormat, error) {
+	if name := req.URL.Query().Get("format"); len(name) > 0 {
+		return models.ParseExportFormat(name)
+	}
+	if format, ok := models.FormatForAccept(req.Header.Get("Accept")); ok {
+		return format, nil
+	}
+	return models.FormatJSON, nil
+}
+
+// statusRecorder remembers the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (sr *statusRecorder) WriteHeader(status int) {
+	sr.status = status
+	sr.ResponseWriter.WriteHeader(status)
+}
+
+// instrument records request latency for a handler under its route pattern
+func instrument(route string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: res, status: http.StatusOK}
+		handler(recorder, req)
+		httpRequestDuration.Observe(time.Since(start).Seconds(), route, req.Method, strconv.Itoa(recorder.status))
+	}
+}
+
+// withRequestId tags each request with an id, reusing the caller's X-Request-Id when there is one, so that log
+// lines from fetching and caching can be tied back to the request
+func withRequestId(handler http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		requestId := req.Header.Get("X-Request-Id")
+		if len(requestId) < 1 || len(requestId) > 128 {
+			requestId = logging.NewRequestId()
+		}
+		res.Header().Set("X-Request-Id", requestId)
+		handler(res, req.WithContext(logging.WithRequestId(req.Context(), requestId)))
+	}
+}
+
+// buildMiddleware returns the named middleware in order. CORS is skipped without allowed origins and the timeout is
+// skipped when it is zero.
+func buildMiddleware(names []string, corsOrigins []string, timeout time.Duration) ([]middleware.Middleware, error) {
+	chain := []middleware.Middleware{}
+	for _, name := range names {
+		switch strings.TrimSpace(name) {
+		case "":
+		case "request-id":
+			chain = append(chain, func(next http.Handler) http.Handler { return withRequestId(next.ServeHTTP) })
+		case "logging":
+			chain = append(chain, middleware.Logging(slog.Default()))
+		case "recover":
+			chain = append(chain, middleware.Recover(slog.Default()))
+		case "security":
+			chain = append(chain, middleware.SecurityHeaders())
+		case "cors":
+			if len(corsOrigins) > 0 {
+				chain = append(chain, middleware.CORS(middleware.DefaultCORSConfig(corsOrigins)))
 			}
+		case "timeout":
+			if timeout > 0 {
+				chain = append(chain, middleware.Timeout(timeout))
+			}
+		default:
+			return nil, fmt.Errorf("unknown middleware %q", name)
 		}
-		updater.SaveReviews(appId, reviews)
 	}
+	return chain, nil
+}
 
-	minTime := time.Now().Add(time.Duration(-maxHours) * time.Hour)
-	reviews = reviews.After(minTime)
-	json.NewEncoder(res).Encode(reviews)
+// requireScope wraps a handler so it needs an API key with scope when authentication is enabled
+func requireScope(scope auth.Scope, handler http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if apiKeys == nil {
+			handler(res, req)
+			return
+		}
+		apiKeys.Require(scope, handler)(res, req)
+	}
+}
+
+// setupLogging configures the default logger used by every package
+func setupLogging(levelName string, format string) error {
+	level, err := logging.ParseLevel(levelName)
+	if err != nil {
+		return err
+	}
+	logger, err := logging.New(os.Stdout, level, format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
 }
 
 func main() {
-	var wg sync.WaitGroup
-	exitchan := make(chan bool, 1)
+	logLevel := flag.String("log-level", config.Env("LOG_LEVEL", "info"), "log level (debug, info, warn or error)")
+	logFormat := flag.String("log-format", config.Env("LOG_FORMAT", "text"), "log format (text or json)")
+	drainTimeout := flag.Duration("drain-timeout", config.EnvDuration("DRAIN_TIMEOUT", config.DRAIN_TIMEOUT),
+		"how long to wait for in-flight requests and fetches when shutting down")
+	middlewareNames := flag.String("middleware", config.Env("MIDDLEWARE", config.DEFAULT_MIDDLEWARE),
+		"comma separated middleware to wrap routes in, outermost first")
+	corsOrigins := flag.String("cors-origins", config.Env("CORS_ORIGINS", ""),
+		"comma separated origins browsers may call the API from, or * for any; CORS is disabled when empty")
+	requestTimeout := flag.Duration("request-timeout", config.EnvDuration("REQUEST_TIMEOUT", config.REQUEST_TIMEOUT),
+		"how long a request may take before it is answered with 503, zero for no limit")
+	address := flag.String("listen", config.Env("LISTEN", fmt.Sprintf(":%d", config.SERVER_PORT)),
+		"address to listen on, such as :8000, 127.0.0.1:8000 or unix:/run/app-reviews.sock")
+	tlsCert := flag.String("tls-cert", config.Env("TLS_CERT_FILE", ""), "certificate file to serve HTTPS with")
+	tlsKey := flag.String("tls-key", config.Env("TLS_KEY_FILE", ""), "private key file for -tls-cert")
+	tlsClientCA := flag.String("tls-client-ca", config.Env("TLS_CLIENT_CA_FILE", ""),
+		"CA certificates that client certificates must be signed by; client certificates are not required when empty")
+	http2 := flag.Bool("http2", config.Env("HTTP2", "true") == "true", "offer HTTP/2 over TLS")
+	grpcAddress := flag.String("grpc-listen", config.Env("GRPC_LISTEN", ""),
+		fmt.Sprintf("address to serve gRPC on, such as :%d; gRPC is off when empty", config.GRPC_PORT))
+	apiKeysFile := flag.String("api-keys", config.Env("API_KEYS_FILE", ""),
+		"JSON file of API keys; authentication is disabled when empty")
+	tagRulesFile := flag.String("tag-rules", config.Env("TAG_RULES_FILE", ""),
+		"JSON file of rules to tag reviews with, reloaded on SIGHUP; the built in rules are used when empty")
+	webhooks := flag.String("notify-webhooks", config.Env("NOTIFY_WEBHOOKS", ""),
+		"comma separated URLs events such as rating anomalies are POSTed to as JSON; events are only logged when empty")
+	flag.Parse()
+
+	if err := setupLogging(*logLevel, *logFormat); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	origins := []string{}
+	for _, origin := range strings.Split(*corsOrigins, ",") {
+		if origin = strings.TrimSpace(origin); len(origin) > 0 {
+			origins = append(origins, origin)
+		}
+	}
+	chain, err := buildMiddleware(strings.Split(*middlewareNames, ","), origins, *requestTimeout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if len(*apiKeysFile) > 0 {
+		keyring, err := auth.LoadKeysFile(*apiKeysFile, serverClock)
+		if err != nil {
+			slog.Error("unable to load api keys", "file", *apiKeysFile, "error", err)
+			os.Exit(2)
+		}
+		apiKeys = keyring
+	} else {
+		slog.Warn("no api key file configured, requests are not authenticated")
+	}
+
+	if len(*tagRulesFile) > 0 {
+		rules, err := tagging.LoadFile(*tagRulesFile)
+		if err != nil {
+			slog.Error("unable to load tag rules", "file", *tagRulesFile, "error", err)
+			os.Exit(2)
+		}
+		updater.Default().SetTagRules(rules)
+	}
+
+	notifiers := []events.Notifier{events.Log(nil)}
+	for _, url := range strings.Split(*webhooks, ",") {
+		if url = strings.TrimSpace(url); len(url) > 0 {
+			notifiers = append(notifiers, events.Webhook(url, nil))
+		}
+	}
+	updater.Default().SetNotifier(events.Multi(notifiers...))
+
+	var tlsReloader *listen.Reloader
+	if len(*tlsCert) > 0 || len(*tlsKey) > 0 {
+		tlsReloader, err = listen.NewReloader(listen.TLSOptions{
+			CertFile: *tlsCert, KeyFile: *tlsKey, ClientCAFile: *tlsClientCA, HTTP2: *http2,
+		})
+		if err != nil {
+			slog.Error("unable to load TLS configuration", "error", err)
+			os.Exit(2)
+		}
+	} else if len(*tlsClientCA) > 0 {
+		slog.Error("-tls-client-ca needs -tls-cert and -tls-key")
+		os.Exit(2)
+	}
+	listener, err := listen.Listen(*address)
+	if err != nil {
+		slog.Error("unable to listen", "address", *address, "error", err)
+		os.Exit(2)
+	}
+	var grpcListener net.Listener
+	if len(*grpcAddress) > 0 {
+		if grpcListener, err = listen.Listen(*grpcAddress); err != nil {
+			slog.Error("unable to listen for gRPC", "address", *grpcAddress, "error", err)
+			os.Exit(2)
+		}
+	}
+
+	// *** Shut down on SIGINT or SIGTERM ***
+	stopCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Fetches made by the refresher and by request handlers use workCtx so that anything still running once the
+	// drain timeout is up can be cancelled
+	workCtx, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
 
 	// *** Start up review fetching ***
+	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
-		for {
-			time.Sleep(1 * time.Second)
-			updater.UpdateNext(updater.ListAppCache())
-
-			// If there is anything on exitchan, we should stop
-			select {
-			case <-exitchan:
-				wg.Done()
-				return
-			default:
-				continue
+		defer wg.Done()
+		// Bring caches written by older versions or rules up to date, then keep checking for spam
+		retag(workCtx)
+		runSpamChecker(stopCtx, workCtx)
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		runRefresher(stopCtx, workCtx)
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// Send events raised by saves from a worker so slow notification channels don't hold up requests
+		updater.Default().SendEvents(stopCtx, workCtx)
+	}()
+
+	// *** Start up request handler ***
+	limits = newClientLimits(serverClock)
+	registerRoutes(http.DefaultServeMux)
+
+	server := &http.Server{
+		Handler:     middleware.Chain(http.DefaultServeMux, chain...),
+		BaseContext: func(net.Listener) context.Context { return workCtx },
+	}
+	serve := func() error { return server.Serve(listener) }
+	if tlsReloader != nil {
+		server.TLSConfig = tlsReloader.Config()
+		if !*http2 {
+			// A non-nil empty map stops net/http from setting up HTTP/2
+			server.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+		}
+		serve = func() error { return server.ServeTLS(listener, "", "") }
+	}
+	go reloadOnHangup(stopCtx, tlsReloader, *tagRulesFile, workCtx)
+	// Set from the serving goroutines when a server fails
+	var exitCode atomic.Int32
+	if grpcListener != nil {
+		grpcServer := newGRPCServer(stopCtx, tlsReloader)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			stopGRPC(grpcServer, stopCtx, workCtx)
+		}()
+		go func() {
+			slog.Info("starting gRPC server", "address", grpcListener.Addr().String())
+			if err := grpcServer.Serve(grpcListener); err != nil {
+				slog.Error("gRPC server stopped", "error", err)
+				exitCode.Store(1)
+				stop()
 			}
+		}()
+	}
+	go func() {
+		slog.Info("starting server", "address", listener.Addr().String(), "tls", tlsReloader != nil,
+			"client_certs", len(*tlsClientCA) > 0)
+		if err := serve(); !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("server stopped", "error", err)
+			exitCode.Store(1)
+			stop()
 		}
 	}()
 
-	// *** Start up interrupt handler so we can shut down gracefully ***
+	<-stopCtx.Done()
+	slog.Info("shutting down", "drain_timeout", *drainTimeout)
+	if err := shutdown(server, &wg, cancelWork, *drainTimeout); err != nil {
+		slog.Warn("shutdown did not finish draining", "error", err)
+	}
+	os.Exit(int(exitCode.Load()))
+}
+
+// newGRPCServer creates the gRPC server. It shares the review loading, cache and rate limits with the HTTP
+// handlers, uses the same TLS certificates (always offering h2, which gRPC needs) and requires API keys when
+// authentication is enabled.
+func newGRPCServer(stopCtx context.Context, tlsReloader *listen.Reloader) *grpc.Server {
+	options := []grpc.ServerOption{}
+	if tlsReloader != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsReloader.GRPCConfig())))
+	}
+	// Like HTTP requests, calls are authenticated and counted against their key before the per client request rate
+	unary := []grpc.UnaryServerInterceptor{limitUnaryCalls}
+	stream := []grpc.StreamServerInterceptor{limitStreamCalls}
+	if apiKeys != nil {
+		unary = append([]grpc.UnaryServerInterceptor{rpc.UnaryAuth(apiKeys)}, unary...)
+		stream = append([]grpc.StreamServerInterceptor{rpc.StreamAuth(apiKeys)}, stream...)
+	}
+	options = append(options, grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
+	server := grpc.NewServer(options...)
+
+	load := func(ctx context.Context, appId string) (models.AppReviews, error) {
+		reviews, err := loadAppReviews(ctx, appId, grpcClientId(ctx))
+		var limitErr *rateLimitError
+		if errors.As(err, &limitErr) {
+			return nil, status.Error(codes.ResourceExhausted, limitErr.Error())
+		}
+		return reviews, err
+	}
+	reviewspb.RegisterReviewsServer(server, rpc.NewServer(updater.Default(), load,
+		rpc.WithClock(serverClock), rpc.WithShutdown(stopCtx)))
+	return server
+}
+
+// stopGRPC gracefully stops the gRPC server once stopCtx is done, stopping it outright if it is still draining when
+// workCtx is cancelled
+func stopGRPC(server *grpc.Server, stopCtx context.Context, workCtx context.Context) {
+	<-stopCtx.Done()
+	stopped := make(chan struct{})
 	go func() {
-		sigchan := make(chan os.Signal, 1)
-		signal.Notify(sigchan, os.Interrupt)
-		<-sigchan // Wait for a SIGINT
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-workCtx.Done():
+		server.Stop()
+	}
+}
+
+// reloadOnHangup reloads the TLS certificates and tag rules file, when configured, whenever the process receives
+// SIGHUP until ctx is done. Cached reviews are re-tagged with workCtx after the tag rules are reloaded.
+func reloadOnHangup(ctx context.Context, reloader *listen.Reloader, tagRulesFile string, workCtx context.Context) {
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hangup:
+		}
+		if reloader != nil {
+			if err := reloader.Reload(); err != nil {
+				slog.Error("unable to reload TLS configuration, keeping the current one", "error", err)
+			} else {
+				slog.Info("reloaded TLS configuration")
+			}
+		}
+		if len(tagRulesFile) > 0 {
+			rules, err := tagging.LoadFile(tagRulesFile)
+			if err != nil {
+				slog.Error("unable to reload tag rules, keeping the current ones", "file", tagRulesFile, "error", err)
+				continue
+			}
+			slog.Info("reloaded tag rules", "file", tagRulesFile, "rules", len(rules.Rules), "version", rules.Version)
+			updater.Default().SetTagRules(rules)
+			go retag(workCtx)
+		}
+	}
+}
+
+// retag applies the current tag rules to the reviews already cached, so the archive matches rules that changed
+// since it was saved
+func retag(ctx context.Context) {
+	if _, err := updater.Default().RetagReviews(ctx); err != nil {
+		slog.Error("unable to tag cached reviews", "error", err)
+	}
+}
 
-		// Close out our go routine gracefully
-		exitchan <- true
-		wg.Wait()
+// registerRoutes adds every endpoint to mux and returns their patterns. Every pattern must be described in
+// openapi/openapi.json.
+func registerRoutes(mux *http.ServeMux) []string {
+	patterns := []string{}
+	handle := func(pattern string, handler http.HandlerFunc) {
+		mux.HandleFunc(pattern, handler)
+		patterns = append(patterns, pattern)
+	}
+	reviewRoute := func(route string, handler http.HandlerFunc) http.HandlerFunc {
+		return instrument(route, requireScope(auth.ScopeRead, limitClient(handler)))
+	}
+
+	handle("/{appId}", reviewRoute("/{appId}", reviewRequestHandler))
+	handle("GET /{appId}/atom", reviewRoute("/{appId}/atom", atomRequestHandler))
+	handle("GET /{appId}/rss", reviewRoute("/{appId}/rss", rssRequestHandler))
+	handle("GET /{appId}/topics", reviewRoute("/{appId}/topics", topicsRequestHandler))
+	handle("GET /{appId}/reviews/{reviewId}/history", reviewRoute("/{appId}/reviews/{reviewId}/history",
+		reviewHistoryRequestHandler))
+	handle("GET /compare", reviewRoute("/compare", compareRequestHandler))
+	handle("GET /metrics", requireScope(auth.ScopeAdmin, metrics.Handler().ServeHTTP))
+	handle("GET /healthz", healthzHandler)
+	handle("GET /readyz", readyzHandler)
+	handle("GET /status", requireScope(auth.ScopeAdmin, statusHandler))
+	handle("GET /openapi.json", openapi.Handler().ServeHTTP)
+
+	graphqlHandler, err := graphapi.Handler(updater.Default(), func(ctx context.Context, appId string) (models.AppReviews, error) {
+		return loadAppReviews(ctx, appId, clientFromContext(ctx))
+	})
+	if err != nil {
+		// The schema is embedded, so this only happens if it is broken
+		panic(err)
+	}
+	handle("POST /graphql", reviewRoute("/graphql", graphqlHandler.ServeHTTP))
+	return patterns
+}
 
-		os.Exit(0)
+// runRefresher refreshes the stalest app cache every second until stopCtx is done.
+// Fetches use workCtx so an in-flight refresh is allowed to finish while draining.
+func runRefresher(stopCtx context.Context, workCtx context.Context) {
+	for {
+		select {
+		case <-stopCtx.Done():
+			return
+		case <-serverClock.After(1 * time.Second):
+		}
+		updater.UpdateNext(workCtx, updater.ListAppCache())
+	}
+}
+
+// runSpamChecker flags suspected spam across every cached app now and every config.SPAM_CHECK_MINUTES until stopCtx
+// is done, so saving reviews doesn't have to compare them with the whole cache
+func runSpamChecker(stopCtx context.Context, workCtx context.Context) {
+	for {
+		if _, err := updater.Default().DetectSpam(workCtx); err != nil {
+			slog.Error("unable to check cached reviews for spam", "error", err)
+		}
+		select {
+		case <-stopCtx.Done():
+			return
+		case <-serverClock.After(config.SPAM_CHECK_MINUTES * time.Minute):
+		}
+	}
+}
+
+// shutdown stops accepting requests and waits up to drainTimeout for in-flight requests and the refresher to
+// finish. Anything still running after that is cancelled. Cache writes are atomic, so a cancelled fetch never
+// leaves a half-written cache file behind.
+func shutdown(server *http.Server, refresher *sync.WaitGroup, cancelWork context.CancelFunc, drainTimeout time.Duration) error {
+	drainCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	err := server.Shutdown(drainCtx)
+
+	refresherDone := make(chan struct{})
+	go func() {
+		refresher.Wait()
+		close(refresherDone)
 	}()
+	select {
+	case <-refresherDone:
+	case <-drainCtx.Done():
+		err = errors.Join(err, drainCtx.Err())
+	}
 
-	// *** Start up request handler ***
-	http.HandleFunc("/{appId}", reviewRequestHandler)
-	http.ListenAndServe(fmt.Sprintf(":%d", config.SERVER_PORT), nil)
+	cancelWork()
+	refresher.Wait()
+	return err
 }
@@ -1,18 +1,76 @@
 package main
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"math/big"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/marcuswu/app-reviews/clock"
+	"github.com/marcuswu/app-reviews/clock/clocktest"
+	"github.com/marcuswu/app-reviews/compare"
 	"github.com/marcuswu/app-reviews/config"
+	"github.com/marcuswu/app-reviews/listen"
+	"github.com/marcuswu/app-reviews/logging"
 	"github.com/marcuswu/app-reviews/models"
+	"github.com/marcuswu/app-reviews/openapi"
+	"github.com/marcuswu/app-reviews/ratelimit"
+	"github.com/marcuswu/app-reviews/rpc/reviewspb"
+	"github.com/marcuswu/app-reviews/topics"
+	"github.com/marcuswu/app-reviews/updater"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
 )
 
 func TestReviewIntegration(t *testing.T) {
-	req := httptest.NewRequest("GET", fmt.Sprintf("http://localhost:%d/articles", config.SERVER_PORT), nil)
+	now := time.Now()
+	entry := func(id int, updated time.Time) string {
+		return fmt.Sprintf(`{"author":{"name":{"label":"Author %[1]d"},"uri":{"label":"unused"}},`+
+			`"updated":{"label":"%[2]s"},"im:rating":{"label":"5"},"im:version":{"label":"1.0"},"id":{"label":"%[1]d"},`+
+			`"title":{"label":"Title %[1]d"},"content":{"label":"Content %[1]d"},"link":{"attributes":{"href":"unused"}}}`,
+			id, updated.Format(time.RFC3339))
+	}
+	feed := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		if !strings.Contains(req.URL.Path, "/page=1/") {
+			fmt.Fprint(res, `{"feed":{}}`)
+			return
+		}
+		fmt.Fprintf(res, `{"feed":{"entry":[%s,%s,%s]}}`, entry(3, now.Add(-time.Hour)),
+			entry(2, now.Add(-24*time.Hour)), entry(1, now.Add(-72*time.Hour)))
+	}))
+	defer feed.Close()
+	previousUpdater, previousLimits := updater.Default(), limits
+	updater.SetDefault(updater.New(updater.WithBaseURL(feed.URL), updater.WithStore(updater.NewFileStore(t.TempDir()))))
+	limits = newClientLimits(clock.System)
+	defer func() {
+		updater.SetDefault(previousUpdater)
+		limits = previousLimits
+	}()
+
+	req := httptest.NewRequest("GET", fmt.Sprintf("http://localhost:%d/595068606", config.SERVER_PORT), nil)
+	req.SetPathValue("appId", "595068606")
 
 	w := httptest.NewRecorder()
 	reviewRequestHandler(w, req)
@@ -27,6 +85,9 @@ func TestReviewIntegration(t *testing.T) {
 	if err != nil {
 		t.Errorf("expected no error reading from body, got %s", err)
 	}
+	if len(reviews) != 2 {
+		t.Errorf("expected the 2 reviews within 48 hours, got %d", len(reviews))
+	}
 
 	for _, review := range reviews {
 		if time.Since(review.Updated).Hours() > 48 {
@@ -34,3 +95,573 @@ func TestReviewIntegration(t *testing.T) {
 		}
 	}
 }
+
+func TestRequestedFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		accept   string
+		expected models.ExportFormat
+		error    bool
+	}{
+		{"default", "/1234", "", models.FormatJSON, false},
+		{"query", "/1234?format=csv", "", models.FormatCSV, false},
+		{"accept", "/1234", "application/x-ndjson", models.FormatNDJSON, false},
+		{"query wins", "/1234?format=xlsx", "text/csv", models.FormatXLSX, false},
+		{"unsupported query", "/1234?format=yaml", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.url, nil)
+			req.Header.Set("Accept", tt.accept)
+			format, err := requestedFormat(req)
+			if tt.error != (err != nil) {
+				t.Errorf("expected error %v, got %v", tt.error, err)
+			}
+			if format != tt.expected {
+				t.Errorf("got %q, want %q", format, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWithRequestId(t *testing.T) {
+	found := ""
+	handler := withRequestId(func(res http.ResponseWriter, req *http.Request) {
+		found = logging.RequestId(req.Context())
+	})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/1234", nil)
+	req.Header.Set("X-Request-Id", "from-caller")
+	handler(w, req)
+	if found != "from-caller" || w.Header().Get("X-Request-Id") != "from-caller" {
+		t.Errorf("expected the caller's request id to be used, got %q", found)
+	}
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest("GET", "/1234", nil))
+	if len(found) < 1 || w.Header().Get("X-Request-Id") != found {
+		t.Errorf("expected a generated request id in the context and response, got %q", found)
+	}
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	w := httptest.NewRecorder()
+	healthzHandler(w, httptest.NewRequest("GET", "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected healthz to be OK, got %d", w.Code)
+	}
+
+	// Running the refresher with nothing to refresh still counts as a run
+	updater.UpdateNext(context.Background(), []string{})
+	w = httptest.NewRecorder()
+	readyzHandler(w, httptest.NewRequest("GET", "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("expected readyz to be OK after the refresher ran, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	statusHandler(w, httptest.NewRequest("GET", "/status", nil))
+	var status struct {
+		Ready         bool `json:"ready"`
+		CacheWritable bool `json:"cacheWritable"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+		t.Fatalf("expected status to be json, got %s", err)
+	}
+	if !status.Ready || !status.CacheWritable {
+		t.Errorf("expected a ready status with a writable cache, got %+v", status)
+	}
+}
+
+func TestShutdownCancelsWorkAfterDrainTimeout(t *testing.T) {
+	workCtx, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
+
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	server := &http.Server{
+		Handler: http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			close(started)
+			<-req.Context().Done()
+			close(cancelled)
+		}),
+		BaseContext: func(net.Listener) context.Context { return workCtx },
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+	go server.Serve(listener)
+	go http.Get("http://" + listener.Addr().String())
+	<-started
+
+	var refresher sync.WaitGroup
+	refresher.Add(1)
+	go func() {
+		defer refresher.Done()
+		<-workCtx.Done()
+	}()
+
+	if err := shutdown(server, &refresher, cancelWork, 50*time.Millisecond); err == nil {
+		t.Errorf("expected an error when the drain timeout is exceeded")
+	}
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Errorf("expected the in-flight request to be cancelled")
+	}
+}
+
+func TestReadinessDetectsStalledRefresher(t *testing.T) {
+	fake := clocktest.NewFake(time.Now())
+	previousUpdater := updater.Default()
+	updater.SetDefault(updater.New(updater.WithClock(fake)))
+	serverClock = fake
+	defer func() {
+		updater.SetDefault(previousUpdater)
+		serverClock = clock.System
+	}()
+
+	if err := readiness(); err == nil {
+		t.Errorf("expected not to be ready before the refresher runs")
+	}
+
+	stopCtx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		runRefresher(stopCtx, context.Background())
+		close(done)
+	}()
+	for fake.Waiters() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	fake.Advance(time.Second)
+	for fake.Waiters() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+	<-done
+
+	if err := readiness(); err != nil {
+		t.Errorf("expected to be ready after the refresher ran, got %s", err)
+	}
+
+	fake.Advance(time.Duration(config.REFRESHER_STALL_SECONDS+1) * time.Second)
+	if err := readiness(); err == nil {
+		t.Errorf("expected a stalled refresher to make the server not ready")
+	}
+}
+
+func TestFetchLimits(t *testing.T) {
+	fake := clocktest.NewFake(time.Now())
+	feed := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(res, `{"feed":{}}`)
+	}))
+	defer feed.Close()
+	previousUpdater, previousLimits := updater.Default(), limits
+	updater.SetDefault(updater.New(updater.WithClock(fake), updater.WithBaseURL(feed.URL),
+		updater.WithStore(updater.NewFileStore(t.TempDir()))))
+	limits = &clientLimits{
+		requests: ratelimit.NewLimiter(fake, 3, 0),
+		fetches:  ratelimit.NewLimiter(fake, 60, 0),
+		newApps:  ratelimit.NewDistinctCap(fake, 1, time.Hour),
+	}
+	defer func() {
+		updater.SetDefault(previousUpdater)
+		limits = previousLimits
+	}()
+
+	request := func(appId string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/"+appId, nil)
+		req.SetPathValue("appId", appId)
+		res := httptest.NewRecorder()
+		limitClient(reviewRequestHandler)(res, req)
+		return res
+	}
+
+	if res := request("1"); res.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an app without a feed, got %d", res.Code)
+	}
+	if res := request("2"); res.Code != http.StatusTooManyRequests || res.Header().Get("Retry-After") != "3600" {
+		t.Errorf("expected a second new app within the hour to be refused, got %d", res.Code)
+	}
+	if res := request("1"); res.Code != http.StatusNotFound {
+		t.Errorf("expected the first app to stay allowed, got %d", res.Code)
+	}
+	if res := request("1"); res.Code != http.StatusTooManyRequests {
+		t.Errorf("expected the client request limit to be reached, got %d", res.Code)
+	}
+}
+
+func TestKnownNoFeedSkipsFetchLimits(t *testing.T) {
+	fake := clocktest.NewFake(time.Now())
+	fetched := atomic.Int32{}
+	feed := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		fetched.Add(1)
+		fmt.Fprint(res, `{"feed":{}}`)
+	}))
+	defer feed.Close()
+	previousUpdater, previousLimits := updater.Default(), limits
+	updater.SetDefault(updater.New(updater.WithClock(fake), updater.WithBaseURL(feed.URL),
+		updater.WithStore(updater.NewFileStore(t.TempDir()))))
+	limits = &clientLimits{
+		requests: ratelimit.NewLimiter(fake, 60, 0),
+		fetches:  ratelimit.NewLimiter(fake, 1, 0),
+		newApps:  ratelimit.NewDistinctCap(fake, 1, time.Hour),
+	}
+	defer func() {
+		updater.SetDefault(previousUpdater)
+		limits = previousLimits
+	}()
+
+	// Only the first request reaches Apple; retries are answered by the no feed cache without spending fetches
+	for i := 0; i < 3; i++ {
+		if _, err := loadAppReviews(context.Background(), "1", "addr:192.0.2.1"); !errors.Is(err, updater.ErrNoFeed) {
+			t.Errorf("expected request %d to find no feed, got %v", i+1, err)
+		}
+	}
+	if fetched.Load() != 1 {
+		t.Errorf("expected a single request to Apple, got %d", fetched.Load())
+	}
+}
+
+// writeTestCert writes a self signed certificate and key for localhost to dir and returns a pool trusting it
+func writeTestCert(t *testing.T, dir string) *x509.CertPool {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(filepath.Join(dir, "cert.pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	os.WriteFile(filepath.Join(dir, "key.pem"), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	cert, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	return pool
+}
+
+func TestGRPCOverTLS(t *testing.T) {
+	fake := clocktest.NewFake(time.Now())
+	previousUpdater, previousLimits := updater.Default(), limits
+	updater.SetDefault(updater.New(updater.WithClock(fake), updater.WithStore(updater.NewFileStore(t.TempDir()))))
+	limits = &clientLimits{
+		requests: ratelimit.NewLimiter(fake, 2, 0),
+		fetches:  ratelimit.NewLimiter(fake, 60, 0),
+		newApps:  ratelimit.NewDistinctCap(fake, 1, time.Hour),
+	}
+	defer func() {
+		updater.SetDefault(previousUpdater)
+		limits = previousLimits
+	}()
+	reviews := models.AppReviews{{Id: "1", Rating: 5, Title: "Great", Updated: fake.Now().Add(-time.Hour)}}
+	if err := updater.SaveReviews(context.Background(), "1234", reviews); err != nil {
+		t.Fatal(err)
+	}
+
+	// HTTP/2 is off for HTTP clients, but gRPC still needs h2
+	dir := t.TempDir()
+	pool := writeTestCert(t, dir)
+	reloader, err := listen.NewReloader(listen.TLSOptions{CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile: filepath.Join(dir, "key.pem"), HTTP2: false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newGRPCServer(context.Background(), reloader)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	_, port, _ := net.SplitHostPort(listener.Addr().String())
+	conn, err := grpc.NewClient("localhost:"+port,
+		grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{RootCAs: pool})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := reviewspb.NewReviewsClient(conn)
+
+	// Anonymous callers are held to the per client request rate by their address
+	for i := 0; i < 2; i++ {
+		stats, err := client.GetStats(context.Background(), &reviewspb.GetStatsRequest{AppId: "1234"})
+		if err != nil || stats.GetCount() != 1 {
+			t.Fatalf("expected stats over TLS, got %v (%v)", stats, err)
+		}
+	}
+	_, err = client.GetStats(context.Background(), &reviewspb.GetStatsRequest{AppId: "1234"})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected ResourceExhausted over the client request rate, got %v", err)
+	}
+}
+
+func TestTopicsEndpoint(t *testing.T) {
+	now := time.Now()
+	previousUpdater := updater.Default()
+	updater.SetDefault(updater.New(updater.WithStore(updater.NewFileStore(t.TempDir()))))
+	defer updater.SetDefault(previousUpdater)
+	reviews := models.AppReviews{
+		{Id: "1", Rating: 1, Title: "Login bug", Content: "The login bug is back", Updated: now.Add(-time.Hour)},
+		{Id: "2", Rating: 2, Title: "Login bug again", Content: "Still can't log in", Updated: now.Add(-2 * time.Hour)},
+		{Id: "3", Rating: 5, Title: "Great", Content: "Love the dark mode", Updated: now.Add(-3 * time.Hour)},
+		{Id: "4", Rating: 2, Title: "Slow", Content: "Sync is slow", Updated: now.Add(-200 * time.Hour)},
+	}
+	if err := updater.SaveReviews(context.Background(), "1234", reviews); err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/1234/topics?"+query, nil)
+		req.SetPathValue("appId", "1234")
+		res := httptest.NewRecorder()
+		topicsRequestHandler(res, req)
+		return res
+	}
+
+	res := request("rating=1,2")
+	report := topicsResponse{}
+	if err := json.NewDecoder(res.Body).Decode(&report); err != nil || res.Code != http.StatusOK {
+		t.Fatalf("expected a topics report, got %d (%v)", res.Code, err)
+	}
+	if report.AppId != "1234" || report.Reviews != 2 || report.PreviousReviews != 1 || len(report.Bigrams) != 1 ||
+		report.Bigrams[0].Term != "login bug" || !report.Bigrams[0].New {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	for _, query := range []string{"limit=0", "limit=1000", "lang=xx", "rating=9"} {
+		if res := request(query); res.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", query, res.Code)
+		}
+	}
+}
+
+func TestReviewHistoryEndpoint(t *testing.T) {
+	now := time.Now()
+	previousUpdater := updater.Default()
+	updater.SetDefault(updater.New(updater.WithStore(updater.NewFileStore(t.TempDir()))))
+	defer updater.SetDefault(previousUpdater)
+	for _, rating := range []int{1, 5} {
+		reviews := models.AppReviews{{Id: "1", Rating: rating, Title: "Sync", Content: "Sync", Updated: now}}
+		if err := updater.SaveReviews(context.Background(), "1234", reviews); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	request := func(reviewId string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/1234/reviews/"+reviewId+"/history", nil)
+		req.SetPathValue("appId", "1234")
+		req.SetPathValue("reviewId", reviewId)
+		res := httptest.NewRecorder()
+		reviewHistoryRequestHandler(res, req)
+		return res
+	}
+
+	res := request("1")
+	history := reviewHistory{}
+	if err := json.NewDecoder(res.Body).Decode(&history); err != nil || res.Code != http.StatusOK {
+		t.Fatalf("expected a review history, got %d (%v)", res.Code, err)
+	}
+	if history.ReviewId != "1" || len(history.Revisions) != 2 || history.Revisions[0].Rating != 1 ||
+		history.Revisions[1].Rating != 5 {
+		t.Errorf("unexpected history %+v", history)
+	}
+	if res := request("2"); res.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown review, got %d", res.Code)
+	}
+}
+
+func TestCompareEndpoint(t *testing.T) {
+	now := time.Now()
+	previousUpdater := updater.Default()
+	updater.SetDefault(updater.New(updater.WithStore(updater.NewFileStore(t.TempDir()))))
+	defer updater.SetDefault(previousUpdater)
+	apps := map[string]models.AppReviews{
+		"1234": {
+			{Id: "1", Rating: 5, Version: "2.0", Title: "Great", Content: "Love it", Updated: now.Add(-time.Hour)},
+			{Id: "2", Rating: 3, Version: "1.0", Title: "Okay", Content: "Fine", Updated: now.Add(-48 * time.Hour)},
+		},
+		"5678": {{Id: "3", Rating: 1, Version: "1.0", Title: "Crashes", Content: "Crashes", Updated: now}},
+	}
+	for appId, reviews := range apps {
+		if err := updater.SaveReviews(context.Background(), appId, reviews); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	request := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/compare?"+query, nil)
+		res := httptest.NewRecorder()
+		compareRequestHandler(res, req)
+		return res
+	}
+
+	res := request("apps=5678,1234&hours=24")
+	comparison := compare.Comparison{}
+	if err := json.NewDecoder(res.Body).Decode(&comparison); err != nil || res.Code != http.StatusOK {
+		t.Fatalf("expected a comparison, got %d (%v)", res.Code, err)
+	}
+	if len(comparison.Apps) != 2 || comparison.Apps[0].AppId != "5678" || comparison.Apps[1].AppId != "1234" {
+		t.Fatalf("expected both apps in the order requested, got %+v", comparison.Apps)
+	}
+	if summary := comparison.Apps[1]; summary.Reviews != 1 || summary.AverageRating != 5 ||
+		len(summary.Releases) != 1 || summary.Releases[0].Version != "2.0" {
+		t.Errorf("unexpected summary %+v", summary)
+	}
+
+	for _, query := range []string{"apps=1234", "apps=1234,abc", "apps=1234,5678&limit=0", "apps=1234,5678&rating=9"} {
+		if res := request(query); res.Code != http.StatusBadRequest {
+			t.Errorf("expected 400 for %s, got %d", query, res.Code)
+		}
+	}
+}
+
+func TestCompareChecksFetchBudget(t *testing.T) {
+	fake := clocktest.NewFake(time.Now())
+	fetched := atomic.Int32{}
+	feed := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		fetched.Add(1)
+		fmt.Fprint(res, `{"feed":{}}`)
+	}))
+	defer feed.Close()
+	previousUpdater, previousLimits := updater.Default(), limits
+	updater.SetDefault(updater.New(updater.WithClock(fake), updater.WithBaseURL(feed.URL),
+		updater.WithStore(updater.NewFileStore(t.TempDir()))))
+	limits = &clientLimits{
+		requests: ratelimit.NewLimiter(fake, 60, 0),
+		fetches:  ratelimit.NewLimiter(fake, 2, 0),
+		newApps:  ratelimit.NewDistinctCap(fake, 20, time.Hour),
+	}
+	defer func() {
+		updater.SetDefault(previousUpdater)
+		limits = previousLimits
+	}()
+	if err := updater.SaveReviews(context.Background(), "1234", models.AppReviews{{Id: "1", Updated: fake.Now()}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Three uncached apps need more fetches than the client has, so none are made
+	req := httptest.NewRequest(http.MethodGet, "/compare?apps=1234,1,2,3", nil)
+	res := httptest.NewRecorder()
+	compareRequestHandler(res, req)
+	if res.Code != http.StatusTooManyRequests || fetched.Load() != 0 || len(res.Header().Get("Retry-After")) < 1 {
+		t.Errorf("expected 429 before any fetch, got %d after %d fetches", res.Code, fetched.Load())
+	}
+}
+
+func TestInvalidAppId(t *testing.T) {
+	for _, appId := range []string{"abc", "1234\"; filename=\"evil.sh", "12%0d34"} {
+		req := httptest.NewRequest(http.MethodGet, "/x/reviews?format=csv", nil)
+		req.SetPathValue("appId", appId)
+		res := httptest.NewRecorder()
+		reviewRequestHandler(res, req)
+		if res.Code != http.StatusBadRequest || len(res.Header().Get("Content-Disposition")) > 0 {
+			t.Errorf("expected 400 without an attachment for app id %q, got %d %v", appId, res.Code, res.Header())
+		}
+	}
+}
+
+func TestBuildMiddleware(t *testing.T) {
+	chain, err := buildMiddleware([]string{"request-id", "logging", "recover", "security", "cors", "timeout"}, nil, 0)
+	if err != nil || len(chain) != 4 {
+		t.Errorf("expected cors and timeout to be skipped when unconfigured, got %d middleware (%v)", len(chain), err)
+	}
+	if _, err := buildMiddleware([]string{"gzip"}, nil, 0); err == nil {
+		t.Errorf("expected an unknown middleware to be an error")
+	}
+}
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	document, err := openapi.Parse()
+	if err != nil {
+		t.Fatalf("expected the spec to parse, got %s", err)
+	}
+
+	documented := map[string]bool{}
+	for path, operations := range document.Paths {
+		for method := range operations {
+			documented[strings.ToUpper(method)+" "+path] = true
+		}
+	}
+	for _, pattern := range registerRoutes(http.NewServeMux()) {
+		if !strings.Contains(pattern, " ") {
+			// Patterns without a method serve GET along with everything else
+			pattern = http.MethodGet + " " + pattern
+		}
+		if !documented[pattern] {
+			t.Errorf("expected %s to be in openapi.json", pattern)
+		}
+		delete(documented, pattern)
+	}
+	for operation := range documented {
+		t.Errorf("expected %s in openapi.json to be a registered route", operation)
+	}
+}
+
+func TestOpenAPIMatchesModels(t *testing.T) {
+	document, err := openapi.Parse()
+	if err != nil {
+		t.Fatalf("expected the spec to parse, got %s", err)
+	}
+	jsonKeys := func(v any) []string {
+		data, _ := json.Marshal(v)
+		fields := map[string]json.RawMessage{}
+		json.Unmarshal(data, &fields)
+		keys := []string{}
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+
+	now := time.Now()
+	app := updater.AppStatus{AppId: "1234", LastRefresh: &now, LastError: "failed"}
+	schemas := map[string]any{
+		"AppReview":     models.AppReview{},
+		"Topics":        topicsResponse{},
+		"TopicTerm":     topics.Term{},
+		"Author":        models.Author{},
+		"Revision":      models.Revision{},
+		"ReviewHistory": reviewHistory{},
+		"Release":       compare.Release{},
+		"AppSummary":    compare.Summary{},
+		"Comparison":    compare.Comparison{},
+		"AppStatus":     app,
+		"Status": serverStatus{ReadyError: "not ready", Status: updater.Status{
+			Apps: []updater.AppStatus{app}, CacheError: "read only"}},
+	}
+	for name, v := range schemas {
+		schema, ok := document.Components.Schemas[name]
+		if !ok {
+			t.Errorf("expected a %s schema", name)
+			continue
+		}
+		properties := []string{}
+		for property := range schema.Properties {
+			properties = append(properties, property)
+		}
+		sort.Strings(properties)
+		if got := jsonKeys(v); !reflect.DeepEqual(got, properties) {
+			t.Errorf("expected %s fields %v to match the spec %v", name, got, properties)
+		}
+	}
+}
@@ -0,0 +1,297 @@
+// Package metrics is a small Prometheus compatible metrics registry.
+// It supports labeled counters, gauges and histograms and renders them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are histogram buckets suited to request latencies in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// collector is implemented by every metric type so the registry can render it
+type collector interface {
+	name() string
+	write(out io.Writer)
+}
+
+// Registry holds a set of metrics to be exposed together
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// DefaultRegistry is the registry metrics created with the package level constructors are added to
+var DefaultRegistry = &Registry{}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.collectors {
+		if existing.name() == c.name() {
+			panic(fmt.Sprintf("metric %s registered twice", c.name()))
+		}
+	}
+	r.collectors = append(r.collectors, c)
+}
+
+// Write writes every registered metric in the Prometheus text exposition format
+func (r *Registry) Write(out io.Writer) {
+	r.mu.Lock()
+	collectors := append([]collector{}, r.collectors...)
+	r.mu.Unlock()
+
+	sort.Slice(collectors, func(i, j int) bool { return collectors[i].name() < collectors[j].name() })
+	for _, c := range collectors {
+		c.write(out)
+	}
+}
+
+// Handler serves the registry for Prometheus to scrape
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.Write(res)
+	})
+}
+
+// Handler serves the default registry
+func Handler() http.Handler {
+	return DefaultRegistry.Handler()
+}
+
+// vec keeps the per label combination values of a metric
+type vec[T any] struct {
+	metricName string
+	help       string
+	labels     []string
+	mu         sync.Mutex
+	values     map[string]*T
+	newValue   func() *T
+}
+
+func (v *vec[T]) name() string {
+	return v.metricName
+}
+
+// with returns the value for a label combination, creating it if needed. The caller must hold v.mu.
+func (v *vec[T]) with(labelValues []string) *T {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", v.metricName, len(v.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	value, ok := v.values[key]
+	if !ok {
+		value = v.newValue()
+		v.values[key] = value
+	}
+	return value
+}
+
+// sortedKeys returns label combinations in a stable order for rendering. The caller must hold v.mu.
+func (v *vec[T]) sortedKeys() []string {
+	keys := make([]string, 0, len(v.values))
+	for key := range v.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (v *vec[T]) writeHeader(out io.Writer, kind string) {
+	fmt.Fprintf(out, "# HELP %s %s\n", v.metricName, escapeHelp(v.help))
+	fmt.Fprintf(out, "# TYPE %s %s\n", v.metricName, kind)
+}
+
+// labelString renders label pairs such as {app="123",code="200"}, with optional extra pairs appended
+func (v *vec[T]) labelString(key string, extra ...string) string {
+	pairs := []string{}
+	if len(v.labels) > 0 {
+		for i, value := range strings.Split(key, "\xff") {
+			pairs = append(pairs, fmt.Sprintf(`%s="%s"`, v.labels[i], escapeLabel(value)))
+		}
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], escapeLabel(extra[i+1])))
+	}
+	if len(pairs) < 1 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// Counter is a monotonically increasing value per label combination
+type Counter struct {
+	vec[float64]
+}
+
+// NewCounter creates a counter in the default registry
+func NewCounter(name string, help string, labels ...string) *Counter {
+	c := &Counter{vec[float64]{metricName: name, help: help, labels: labels, values: map[string]*float64{},
+		newValue: func() *float64 { return new(float64) }}}
+	DefaultRegistry.register(c)
+	return c
+}
+
+// Inc adds one to the counter for the label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds a non negative amount to the counter for the label values
+func (c *Counter) Add(amount float64, labelValues ...string) {
+	if amount < 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.with(labelValues) += amount
+}
+
+// Value returns the current counter value for the label values
+func (c *Counter) Value(labelValues ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return *c.with(labelValues)
+}
+
+func (c *Counter) write(out io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writeHeader(out, "counter")
+	for _, key := range c.sortedKeys() {
+		fmt.Fprintf(out, "%s%s %s\n", c.metricName, c.labelString(key), formatFloat(*c.values[key]))
+	}
+}
+
+// Gauge is a value per label combination that can go up and down
+type Gauge struct {
+	vec[float64]
+}
+
+// NewGauge creates a gauge in the default registry
+func NewGauge(name string, help string, labels ...string) *Gauge {
+	g := &Gauge{vec[float64]{metricName: name, help: help, labels: labels, values: map[string]*float64{},
+		newValue: func() *float64 { return new(float64) }}}
+	DefaultRegistry.register(g)
+	return g
+}
+
+// Set sets the gauge for the label values
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	*g.with(labelValues) = value
+}
+
+// Add adds an amount (which may be negative) to the gauge for the label values
+func (g *Gauge) Add(amount float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	*g.with(labelValues) += amount
+}
+
+// Value returns the current gauge value for the label values
+func (g *Gauge) Value(labelValues ...string) float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return *g.with(labelValues)
+}
+
+func (g *Gauge) write(out io.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.writeHeader(out, "gauge")
+	for _, key := range g.sortedKeys() {
+		fmt.Fprintf(out, "%s%s %s\n", g.metricName, g.labelString(key), formatFloat(*g.values[key]))
+	}
+}
+
+// histogramValue holds the bucket counts for one label combination
+type histogramValue struct {
+	buckets []uint64 // cumulative counts, one per upper bound
+	count   uint64
+	sum     float64
+}
+
+// Histogram counts observations into buckets per label combination
+type Histogram struct {
+	vec[histogramValue]
+	upperBounds []float64
+}
+
+// NewHistogram creates a histogram in the default registry. Nil buckets uses DefaultBuckets.
+func NewHistogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	upperBounds := append([]float64{}, buckets...)
+	sort.Float64s(upperBounds)
+
+	h := &Histogram{upperBounds: upperBounds}
+	h.vec = vec[histogramValue]{metricName: name, help: help, labels: labels, values: map[string]*histogramValue{},
+		newValue: func() *histogramValue { return &histogramValue{buckets: make([]uint64, len(upperBounds))} }}
+	DefaultRegistry.register(h)
+	return h
+}
+
+// Observe records a value for the label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hv := h.with(labelValues)
+	for i, bound := range h.upperBounds {
+		if value <= bound {
+			hv.buckets[i]++
+		}
+	}
+	hv.count++
+	hv.sum += value
+}
+
+// Count returns the number of observations for the label values
+func (h *Histogram) Count(labelValues ...string) uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.with(labelValues).count
+}
+
+func (h *Histogram) write(out io.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.writeHeader(out, "histogram")
+	for _, key := range h.sortedKeys() {
+		hv := h.values[key]
+		for i, bound := range h.upperBounds {
+			fmt.Fprintf(out, "%s_bucket%s %d\n", h.metricName, h.labelString(key, "le", formatFloat(bound)), hv.buckets[i])
+		}
+		fmt.Fprintf(out, "%s_bucket%s %d\n", h.metricName, h.labelString(key, "le", "+Inf"), hv.count)
+		fmt.Fprintf(out, "%s_sum%s %s\n", h.metricName, h.labelString(key), formatFloat(hv.sum))
+		fmt.Fprintf(out, "%s_count%s %d\n", h.metricName, h.labelString(key), hv.count)
+	}
+}
+
+func formatFloat(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(strings.ToValidUTF8(value, "\uFFFD"))
+}
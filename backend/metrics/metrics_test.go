@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCounter(t *testing.T) {
+	counter := NewCounter("test_counter_total", "A test counter", "app", "code")
+	counter.Inc("123", "200")
+	counter.Inc("123", "200")
+	counter.Add(3, "456", "404")
+	counter.Add(-1, "456", "404")
+
+	if counter.Value("123", "200") != 2 {
+		t.Errorf("expected counter to be 2, got %f", counter.Value("123", "200"))
+	}
+
+	out := bytes.NewBuffer([]byte{})
+	DefaultRegistry.Write(out)
+	expected := `# HELP test_counter_total A test counter
+# TYPE test_counter_total counter
+test_counter_total{app="123",code="200"} 2
+test_counter_total{app="456",code="404"} 3
+`
+	if !strings.Contains(out.String(), expected) {
+		t.Errorf("expected output to contain:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestGauge(t *testing.T) {
+	gauge := NewGauge("test_gauge", "A test gauge")
+	gauge.Set(5)
+	gauge.Add(-2.5)
+
+	out := bytes.NewBuffer([]byte{})
+	DefaultRegistry.Write(out)
+	if !strings.Contains(out.String(), "# TYPE test_gauge gauge\ntest_gauge 2.5\n") {
+		t.Errorf("unexpected gauge output:\n%s", out.String())
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	histogram := NewHistogram("test_histogram_seconds", "A test histogram", []float64{1, 0.5}, "route")
+	histogram.Observe(0.2, "/a")
+	histogram.Observe(0.7, "/a")
+	histogram.Observe(3, "/a")
+
+	if histogram.Count("/a") != 3 {
+		t.Errorf("expected 3 observations, got %d", histogram.Count("/a"))
+	}
+
+	out := bytes.NewBuffer([]byte{})
+	DefaultRegistry.Write(out)
+	expected := `test_histogram_seconds_bucket{route="/a",le="0.5"} 1
+test_histogram_seconds_bucket{route="/a",le="1"} 2
+test_histogram_seconds_bucket{route="/a",le="+Inf"} 3
+test_histogram_seconds_sum{route="/a"} 3.9
+test_histogram_seconds_count{route="/a"} 3
+`
+	if !strings.Contains(out.String(), expected) {
+		t.Errorf("expected output to contain:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestLabelEscaping(t *testing.T) {
+	counter := NewCounter("test_escaped_total", "Help with a \\ and\nnewline", "value")
+	counter.Inc("quote \" backslash \\ newline \n")
+
+	res := httptest.NewRecorder()
+	Handler().ServeHTTP(res, httptest.NewRequest("GET", "/metrics", nil))
+	body := res.Body.String()
+
+	if !strings.HasPrefix(res.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %s", res.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(body, `# HELP test_escaped_total Help with a \\ and\nnewline`) {
+		t.Errorf("expected help to be escaped:\n%s", body)
+	}
+	if !strings.Contains(body, `test_escaped_total{value="quote \" backslash \\ newline \n"} 1`) {
+		t.Errorf("expected label to be escaped:\n%s", body)
+	}
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig describes which cross origin requests browsers may make
+type CORSConfig struct {
+	AllowedOrigins []string // exact origins such as https://example.com, or * for any origin
+	AllowedMethods []string
+	AllowedHeaders []string // request headers preflights may ask for
+	ExposedHeaders []string // response headers scripts may read
+	MaxAge         time.Duration
+}
+
+// DefaultCORSConfig allows reads and GraphQL queries from the provided origins and exposes the request id, rate
+// limit and download headers
+func DefaultCORSConfig(origins []string) CORSConfig {
+	return CORSConfig{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{http.MethodGet, http.MethodHead, http.MethodPost},
+		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-API-Key", "X-Request-Id"},
+		ExposedHeaders: []string{"Content-Disposition", "Retry-After", "X-Request-Id", "X-RateLimit-Limit",
+			"X-RateLimit-Remaining", "X-Quota-Limit", "X-Quota-Remaining", "X-Quota-Reset"},
+		MaxAge: 10 * time.Minute,
+	}
+}
+
+func (c CORSConfig) allowsOrigin(origin string) bool {
+	return slices.Contains(c.AllowedOrigins, "*") || slices.Contains(c.AllowedOrigins, origin)
+}
+
+// CORS adds CORS headers to responses for allowed origins and answers preflight requests itself so they don't
+// need an API key
+func CORS(config CORSConfig) Middleware {
+	methods := strings.Join(config.AllowedMethods, ", ")
+	headers := strings.Join(config.AllowedHeaders, ", ")
+	exposed := strings.Join(config.ExposedHeaders, ", ")
+	maxAge := strconv.Itoa(int(config.MaxAge.Seconds()))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			origin := req.Header.Get("Origin")
+			preflight := req.Method == http.MethodOptions && len(req.Header.Get("Access-Control-Request-Method")) > 0
+			res.Header().Add("Vary", "Origin")
+			if len(origin) < 1 || !config.allowsOrigin(origin) {
+				if preflight {
+					res.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(res, req)
+				return
+			}
+
+			res.Header().Set("Access-Control-Allow-Origin", origin)
+			if !preflight {
+				if len(exposed) > 0 {
+					res.Header().Set("Access-Control-Expose-Headers", exposed)
+				}
+				next.ServeHTTP(res, req)
+				return
+			}
+
+			res.Header().Set("Access-Control-Allow-Methods", methods)
+			res.Header().Set("Access-Control-Allow-Headers", headers)
+			res.Header().Set("Access-Control-Max-Age", maxAge)
+			res.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
@@ -0,0 +1,130 @@
+// Package middleware provides the HTTP middleware wrapped around the server's routes
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+)
+
+// Middleware wraps a handler with extra behaviour
+type Middleware func(http.Handler) http.Handler
+
+// Chain wraps handler in each middleware. The first middleware is the outermost, so it sees requests first and
+// responses last.
+func Chain(handler http.Handler, middleware ...Middleware) http.Handler {
+	for i := len(middleware) - 1; i >= 0; i-- {
+		handler = middleware[i](handler)
+	}
+	return handler
+}
+
+// responseRecorder remembers the status code and size of a response
+type responseRecorder struct {
+	http.ResponseWriter
+	status  int
+	written int
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(body []byte) (int, error) {
+	if rr.status == 0 {
+		rr.status = http.StatusOK
+	}
+	n, err := rr.ResponseWriter.Write(body)
+	rr.written += n
+	return n, err
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
+
+// Logging logs each request once it has been served
+func Logging(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			start := time.Now()
+			recorder := &responseRecorder{ResponseWriter: res}
+			next.ServeHTTP(recorder, req)
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+			}
+			logger.InfoContext(req.Context(), "served request", "method", req.Method, "path", req.URL.Path,
+				"status", recorder.status, "bytes", recorder.written, "duration", time.Since(start),
+				"remote", req.RemoteAddr)
+		})
+	}
+}
+
+// Recover turns a panicking handler into a 500 response and logs the panic with its stack trace instead of letting
+// it take down the connection
+func Recover(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			recorder := &responseRecorder{ResponseWriter: res}
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				if recovered == http.ErrAbortHandler {
+					// The handler deliberately aborted the response; let the server handle it
+					panic(recovered)
+				}
+				logger.ErrorContext(req.Context(), "handler panicked", "method", req.Method, "path", req.URL.Path,
+					"panic", fmt.Sprint(recovered), "stack", string(debug.Stack()))
+				if recorder.status == 0 {
+					http.Error(res, "internal server error", http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(recorder, req)
+		})
+	}
+}
+
+// Timeout cancels the context of requests that take longer than timeout and responds 503 if the handler gives up
+// before writing anything. Unlike http.TimeoutHandler it doesn't buffer responses, so exports keep streaming and
+// can still be flushed.
+func Timeout(timeout time.Duration) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			ctx, cancel := context.WithTimeout(req.Context(), timeout)
+			defer cancel()
+			recorder := &responseRecorder{ResponseWriter: res}
+			next.ServeHTTP(recorder, req.WithContext(ctx))
+			if recorder.status == 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				http.Error(res, "request timed out", http.StatusServiceUnavailable)
+			}
+		})
+	}
+}
+
+// SecurityHeaders sets headers telling browsers not to sniff content types, frame responses or send referrers.
+// Strict-Transport-Security is only set on TLS connections.
+func SecurityHeaders() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+			headers := res.Header()
+			headers.Set("X-Content-Type-Options", "nosniff")
+			headers.Set("X-Frame-Options", "DENY")
+			headers.Set("Referrer-Policy", "no-referrer")
+			headers.Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+			if req.TLS != nil {
+				headers.Set("Strict-Transport-Security", "max-age=31536000")
+			}
+			next.ServeHTTP(res, req)
+		})
+	}
+}
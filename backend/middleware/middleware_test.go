@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestChainOrder(t *testing.T) {
+	order := []string{}
+	named := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(res, req)
+			})
+		}
+	}
+	handler := Chain(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		order = append(order, "handler")
+	}), named("outer"), named("inner"))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if strings.Join(order, ",") != "outer,inner,handler" {
+		t.Errorf("expected outer,inner,handler, got %v", order)
+	}
+}
+
+func TestRecoverAndLogging(t *testing.T) {
+	out := &bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(out, nil))
+	handler := Chain(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		var res2 *http.Response
+		res.Write([]byte(res2.Status)) // nil pointer dereference
+	}), Logging(logger), Recover(logger))
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/1234", nil))
+	if res.Code != http.StatusInternalServerError {
+		t.Errorf("expected a panic to be answered with 500, got %d", res.Code)
+	}
+	logged := out.String()
+	if !strings.Contains(logged, "handler panicked") || !strings.Contains(logged, "nil pointer") {
+		t.Errorf("expected the panic to be logged, got %s", logged)
+	}
+	if !strings.Contains(logged, "served request") || !strings.Contains(logged, "status=500") {
+		t.Errorf("expected the request to be logged with its status, got %s", logged)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	handler := Timeout(10 * time.Millisecond)(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		<-req.Context().Done()
+	}))
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	if res.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected a slow request to get 503, got %d", res.Code)
+	}
+}
+
+func TestTimeoutStreams(t *testing.T) {
+	handler := Timeout(time.Second)(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(res, "id,rating\n")
+		if err := http.NewResponseController(res).Flush(); err != nil {
+			t.Errorf("expected the response to be flushable, got %s", err)
+		}
+	}))
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	if !res.Flushed || res.Code != http.StatusOK || res.Body.String() != "id,rating\n" {
+		t.Errorf("expected the response to be written through unbuffered, got %d %q", res.Code, res.Body.String())
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	handler := SecurityHeaders()(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {}))
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+	if res.Header().Get("X-Content-Type-Options") != "nosniff" || res.Header().Get("X-Frame-Options") != "DENY" {
+		t.Errorf("expected security headers, got %v", res.Header())
+	}
+	if res.Header().Get("Strict-Transport-Security") != "" {
+		t.Errorf("expected no HSTS header without TLS")
+	}
+}
+
+func TestCORS(t *testing.T) {
+	served := 0
+	handler := CORS(DefaultCORSConfig([]string{"https://reviews.example.com"}))(
+		http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) { served++ }))
+	request := func(method string, origin string, preflight bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/1234", nil)
+		req.Header.Set("Origin", origin)
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		}
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+		return res
+	}
+
+	res := request(http.MethodOptions, "https://reviews.example.com", true)
+	if res.Code != http.StatusNoContent || res.Header().Get("Access-Control-Allow-Origin") != "https://reviews.example.com" ||
+		!strings.Contains(res.Header().Get("Access-Control-Allow-Headers"), "X-API-Key") || served != 0 {
+		t.Errorf("expected the preflight to be answered without the handler, got %d %v", res.Code, res.Header())
+	}
+
+	res = request(http.MethodGet, "https://reviews.example.com", false)
+	if res.Header().Get("Access-Control-Allow-Origin") != "https://reviews.example.com" ||
+		!strings.Contains(res.Header().Get("Access-Control-Expose-Headers"), "X-Request-Id") || served != 1 {
+		t.Errorf("expected CORS headers on an allowed request, got %v", res.Header())
+	}
+
+	res = request(http.MethodGet, "https://elsewhere.example.com", false)
+	if res.Header().Get("Access-Control-Allow-Origin") != "" || served != 2 {
+		t.Errorf("expected no CORS headers for another origin, got %v", res.Header())
+	}
+}
@@ -2,11 +2,14 @@ package models
 
 import (
 	"encoding/json"
-	"fmt"
 	"io"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
+
+	"github.com/marcuswu/app-reviews/language"
+	"github.com/marcuswu/app-reviews/sentiment"
 )
 
 // Labeled field helps to flatten verbose RSS structure where a field is an object containing a label
@@ -155,25 +158,89 @@ func (ar *AppleAppReview) UnmarshalJSON(data []byte) error {
 
 // AppReview is a simplified Review structure for our own local cache
 type AppReview struct {
-	Author  Author    `json:"author"`
-	Updated time.Time `json:"updated"`
-	Rating  int       `json:"rating"`
-	Version string    `json:"version"`
-	Id      string    `json:"id"`
-	Title   string    `json:"title"`
-	Content string    `json:"content"`
-	Link    string    `json:"link"`
+	Author    Author     `json:"author"`
+	Updated   time.Time  `json:"updated"`
+	Rating    int        `json:"rating"`
+	Version   string     `json:"version"`
+	Id        string     `json:"id"`
+	Title     string     `json:"title"`
+	Content   string     `json:"content"`
+	Link      string     `json:"link"`
+	Sentiment float64    `json:"sentiment"` // from -1 (negative) to 1 (positive), see ScoreSentiment
+	Tags      []string   `json:"tags"`      // assigned by the rules in the tagging package
+	Language  string     `json:"language"`  // such as en or ja, see DetectLanguage
+	Spam      []string   `json:"spam"`      // why the review looks like spam, set by the spam package
+	Revisions []Revision `json:"revisions"` // earlier versions the author has since edited, oldest first
+	RemovedAt *time.Time `json:"removedAt"` // when the review was found missing from Apple's feed, nil while it is there
 }
 
 type AppReviews []AppReview
 type AppleAppReviews []AppleAppReview
 
+// AppReview converts an Apple review to our own review structure
+func (ar AppleAppReview) AppReview() AppReview {
+	return AppReview{
+		Author:  ar.Author,
+		Updated: ar.Updated,
+		Rating:  ar.Rating,
+		Version: ar.Version,
+		Id:      ar.Id,
+		Title:   ar.Title,
+		Content: ar.Content,
+		Link:    ar.Link,
+	}
+}
+
+// ScoreSentiment scores the sentiment of each review's title and content
+func (r AppReviews) ScoreSentiment() {
+	for i := range r {
+		r[i].Sentiment = sentiment.Score(r[i].Title + ".\n" + r[i].Content)
+	}
+}
+
+// DetectLanguage identifies the language of each review's title and content
+func (r AppReviews) DetectLanguage() {
+	for i := range r {
+		r[i].Language = language.Detect(r[i].Title + ".\n" + r[i].Content)
+	}
+}
+
+// LanguageOrUndetermined returns the review's language, or language.Undetermined for reviews cached before
+// languages were detected
+func (r AppReview) LanguageOrUndetermined() string {
+	if len(r.Language) < 1 {
+		return language.Undetermined
+	}
+	return r.Language
+}
+
+// ValidAppId reports whether appId looks like an App Store app id, which is all digits. Ids are used in cache file
+// names and response headers, so anything else is refused before it reaches them.
+func ValidAppId(appId string) bool {
+	return len(appId) > 0 && strings.Trim(appId, "0123456789") == ""
+}
+
+// SuspectedSpam reports whether the review was flagged as looking like spam
+func (r AppReview) SuspectedSpam() bool {
+	return len(r.Spam) > 0
+}
+
+// Removed reports whether the review has disappeared from Apple's feed
+func (r AppReview) Removed() bool {
+	return r.RemovedAt != nil
+}
+
+// Within returns the app reviews updated within a duration of the package clock's current time
+func (r AppReviews) Within(d time.Duration) AppReviews {
+	return r.After(now().Add(-d))
+}
+
 // After returns the app reviews whose update date is after a specified time
 func (r AppReviews) After(minTime time.Time) AppReviews {
 	sort.Slice(r, func(i, j int) bool { return time.Time(r[i].Updated).After(time.Time(r[j].Updated)) })
 	if len(r) > 0 {
-		fmt.Printf("First updated date is %f hours ago\n", time.Since(r[0].Updated).Hours())
-		fmt.Printf("Last updated date is %f hours ago\n", time.Since(r[len(r)-1].Updated).Hours())
+		log().Debug("filtering reviews by age", "min_time", minTime,
+			"newest_hours", now().Sub(r[0].Updated).Hours(), "oldest_hours", now().Sub(r[len(r)-1].Updated).Hours())
 	}
 	end := -1
 	for idx, review := range r {
@@ -203,7 +270,7 @@ func (arf *AppReviewFeed) UnmarshalJSON(data []byte) error {
 	}
 
 	if err := json.Unmarshal(data, &appReviewFeed); err != nil {
-		fmt.Printf("Error unmarshalling app review feed: %s\n", err)
+		log().Warn("error unmarshalling app review feed", "error", err)
 		return err
 	}
 
@@ -217,13 +284,13 @@ func LoadReviews(stream io.Reader) (AppReviews, error) {
 	data, err := io.ReadAll(stream)
 
 	if err != nil {
-		fmt.Printf("LoadReviews could not read stream: %s\n", err)
+		log().Warn("LoadReviews could not read stream", "error", err)
 		return nil, err
 	}
 
 	reviews := make(AppReviews, 0, 10)
 	if err = json.Unmarshal(data, &reviews); err != nil {
-		fmt.Printf("LoadReviews could not unmarshal json: %s\n", err)
+		log().Warn("LoadReviews could not unmarshal json", "error", err)
 		return nil, err
 	}
 
@@ -8,6 +8,8 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/marcuswu/app-reviews/clock/clocktest"
 )
 
 func TestModelMarshalling(t *testing.T) {
@@ -260,3 +262,121 @@ func TestReviewFiltering(t *testing.T) {
 		t.Errorf("expected to find no reviews and found %d", len(filtered))
 	}
 }
+
+func TestReviewsWithin(t *testing.T) {
+	now, _ := time.Parse(time.RFC3339, "2024-03-13T12:00:00-07:00")
+	SetClock(clocktest.NewFake(now))
+	defer SetClock(nil)
+
+	reviews := AppReviews{
+		AppReview{Id: "1", Updated: now.Add(-time.Hour)},
+		AppReview{Id: "2", Updated: now.Add(-47 * time.Hour)},
+		AppReview{Id: "3", Updated: now.Add(-49 * time.Hour)},
+	}
+
+	if within := reviews.Within(48 * time.Hour); len(within) != 2 {
+		t.Errorf("expected 2 reviews within 48 hours, got %d", len(within))
+	}
+	if within := reviews.Within(30 * time.Minute); len(within) != 0 {
+		t.Errorf("expected no reviews within 30 minutes, got %d", len(within))
+	}
+}
+
+func TestTrackRevisions(t *testing.T) {
+	first := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	previous := AppReviews{
+		{Id: "1", Rating: 1, Title: "Broken", Content: "Crashes on launch", Updated: first},
+		{Id: "2", Rating: 4, Title: "Good", Content: "Works well", Updated: first},
+	}
+	current := AppReviews{
+		{Id: "1", Rating: 5, Title: "Fixed", Content: "Works after the update", Updated: first.Add(48 * time.Hour)},
+		{Id: "2", Rating: 4, Title: "Good", Content: "Works well", Updated: first.Add(time.Hour)},
+		{Id: "3", Rating: 3, Title: "New", Content: "Just installed it", Updated: first.Add(time.Hour)},
+	}
+
+	discovered, edited := current.TrackRevisions(previous)
+	if discovered != 1 || len(edited) != 1 || edited[0].Id != "1" {
+		t.Fatalf("expected 1 new review and review 1 edited, got %d and %+v", discovered, edited)
+	}
+	if len(current[1].Revisions) != 0 {
+		t.Errorf("expected a review with only a new updated time to have no revisions, got %+v", current[1].Revisions)
+	}
+	history := current[0].History()
+	if len(history) != 2 || history[0].Rating != 1 || history[0].Title != "Broken" || history[1].Rating != 5 {
+		t.Errorf("unexpected history %+v", history)
+	}
+
+	// Revisions carry over to later saves and keep growing with each edit
+	later := AppReviews{{Id: "1", Rating: 3, Title: "Fixed", Content: "Works, mostly", Updated: first.Add(72 * time.Hour)}}
+	if _, edited := later.TrackRevisions(current); len(edited) != 1 || len(later[0].Revisions) != 2 ||
+		later[0].Revisions[1].Rating != 5 {
+		t.Errorf("expected a second revision, got %+v", later[0].Revisions)
+	}
+	again := AppReviews{later[0]}
+	again[0].Revisions = nil
+	if _, edited := again.TrackRevisions(later); len(edited) != 0 || len(again[0].Revisions) != 2 {
+		t.Errorf("expected revisions to be carried over without an edit, got %+v", again[0].Revisions)
+	}
+}
+
+func TestTrackRemoved(t *testing.T) {
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	previous := AppReviews{
+		{Id: "4", Rating: 1, Updated: now.Add(-time.Hour)},
+		{Id: "3", Rating: 5, Updated: now.Add(-2 * time.Hour)},
+		{Id: "2", Rating: 2, Updated: now.Add(-3 * time.Hour)},
+		{Id: "1", Rating: 4, Updated: now.Add(-72 * time.Hour)},
+	}
+	fetched := AppReviews{
+		{Id: "5", Rating: 3, Updated: now.Add(-time.Minute)},
+		{Id: "3", Rating: 5, Updated: now.Add(-2 * time.Hour)},
+		{Id: "2", Rating: 2, Updated: now.Add(-3 * time.Hour)},
+	}
+
+	// Review 4 vanished within the fetched window, review 1 is just older than the pages fetched
+	saved, removed := fetched.TrackRemoved(previous, now)
+	if len(removed) != 1 || removed[0].Id != "4" || !removed[0].RemovedAt.Equal(now) {
+		t.Fatalf("expected review 4 to be removed, got %+v", removed)
+	}
+	if len(saved) != 4 || !saved[3].Removed() || saved[0].Removed() {
+		t.Errorf("expected the fetched reviews and the removed review, got %+v", saved)
+	}
+	if visible := saved.Filter(ReviewFilter{}); len(visible) != 3 {
+		t.Errorf("expected removed reviews to be filtered out by default, got %d", len(visible))
+	}
+	if only := saved.Filter(ReviewFilter{Removed: RemovedOnly}); len(only) != 1 || only[0].Id != "4" {
+		t.Errorf("expected only the removed review, got %+v", only)
+	}
+
+	// Later fetches keep the removed review and when it was first found missing
+	again, removed := fetched.TrackRemoved(saved, now.Add(time.Hour))
+	if len(removed) != 0 || len(again) != 4 || !again[3].RemovedAt.Equal(now) {
+		t.Errorf("expected the removed review to be kept as is, got %+v and %+v", again, removed)
+	}
+
+	// A review that comes back is no longer removed
+	back, removed := append(fetched, previous[0]).TrackRemoved(saved, now.Add(time.Hour))
+	if len(removed) != 0 || len(back.Filter(ReviewFilter{Removed: RemovedOnly})) != 0 {
+		t.Errorf("expected no removed reviews once review 4 is back, got %+v", back)
+	}
+}
+
+func TestTrackRemovedAtOldestFetched(t *testing.T) {
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	oldest := now.Add(-3 * time.Hour)
+	previous := AppReviews{
+		{Id: "3", Rating: 5, Updated: now.Add(-time.Hour)},
+		{Id: "2", Rating: 2, Updated: oldest},
+		{Id: "1", Rating: 4, Updated: oldest},
+	}
+	fetched := AppReviews{
+		{Id: "3", Rating: 5, Updated: now.Add(-time.Hour)},
+		{Id: "2", Rating: 2, Updated: oldest},
+	}
+
+	// Review 1 was left at the same time as the oldest fetched review, so it may just be on the next page
+	saved, removed := fetched.TrackRemoved(previous, now)
+	if len(removed) != 0 || len(saved) != 2 {
+		t.Errorf("expected a review at the oldest fetched time not to be removed, got %+v and %+v", saved, removed)
+	}
+}
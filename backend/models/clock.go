@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"github.com/marcuswu/app-reviews/clock"
+)
+
+var modelClock clock.Clock
+
+// SetClock sets the clock used by the models package. A nil clock uses clock.System
+func SetClock(c clock.Clock) {
+	modelClock = c
+}
+
+// now returns the current time according to the package clock
+func now() time.Time {
+	if modelClock == nil {
+		return clock.System.Now()
+	}
+	return modelClock.Now()
+}
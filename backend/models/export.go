@@ -0,0 +1,220 @@
+package models
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportFormat is a file format app reviews can be exported to
+type ExportFormat string
+
+const (
+	FormatJSON   ExportFormat = "json"
+	FormatCSV    ExportFormat = "csv"
+	FormatNDJSON ExportFormat = "ndjson"
+	FormatXLSX   ExportFormat = "xlsx"
+)
+
+var exportContentTypes = map[ExportFormat]string{
+	FormatJSON:   "application/json",
+	FormatCSV:    "text/csv",
+	FormatNDJSON: "application/x-ndjson",
+	FormatXLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// exportColumns are the columns used for tabular export formats
+var exportColumns = []string{"id", "updated", "rating", "version", "title", "content", "author", "author_uri", "link", "sentiment", "tags", "language", "spam", "removed_at"}
+
+// ParseExportFormat returns the export format for a name such as "csv"
+func ParseExportFormat(name string) (ExportFormat, error) {
+	format := ExportFormat(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := exportContentTypes[format]; !ok {
+		return "", fmt.Errorf("unsupported export format %q", name)
+	}
+	return format, nil
+}
+
+// FormatForAccept picks the first export format matching an Accept header, if any
+func FormatForAccept(accept string) (ExportFormat, bool) {
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+		for format, contentType := range exportContentTypes {
+			if mediaType == contentType {
+				return format, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ContentType returns the MIME type for the export format
+func (f ExportFormat) ContentType() string {
+	return exportContentTypes[f]
+}
+
+// ExportReviews writes reviews to a stream in the requested format.
+// Reviews are written one at a time rather than building the whole document in memory.
+func ExportReviews(stream io.Writer, reviews AppReviews, format ExportFormat) error {
+	switch format {
+	case FormatJSON:
+		return SaveReviews(stream, reviews)
+	case FormatCSV:
+		return SaveReviewsCSV(stream, reviews)
+	case FormatNDJSON:
+		return SaveReviewsNDJSON(stream, reviews)
+	case FormatXLSX:
+		return SaveReviewsXLSX(stream, reviews)
+	default:
+		return fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// exportRow flattens a review into the values for exportColumns
+func exportRow(review AppReview) []string {
+	removedAt := ""
+	if review.Removed() {
+		removedAt = review.RemovedAt.Format(time.RFC3339)
+	}
+	return []string{
+		review.Id,
+		review.Updated.Format(time.RFC3339),
+		strconv.Itoa(review.Rating),
+		review.Version,
+		review.Title,
+		review.Content,
+		review.Author.Name,
+		review.Author.Uri,
+		review.Link,
+		strconv.FormatFloat(review.Sentiment, 'f', 3, 64),
+		strings.Join(review.Tags, ","),
+		review.Language,
+		strings.Join(review.Spam, ","),
+		removedAt,
+	}
+}
+
+// SaveReviewsCSV writes reviews to a stream as CSV with a header row.
+// Fields containing quotes, commas or newlines (multiline Content) are quoted.
+func SaveReviewsCSV(stream io.Writer, reviews AppReviews) error {
+	writer := csv.NewWriter(stream)
+	if err := writer.Write(exportColumns); err != nil {
+		return err
+	}
+
+	for _, review := range reviews {
+		if err := writer.Write(exportRow(review)); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// SaveReviewsNDJSON writes reviews to a stream as newline delimited JSON, one review per line
+func SaveReviewsNDJSON(stream io.Writer, reviews AppReviews) error {
+	encoder := json.NewEncoder(stream)
+	for _, review := range reviews {
+		if err := encoder.Encode(review); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`</Types>`
+
+const xlsxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="Reviews" sheetId="1" r:id="rId1"/></sheets>` +
+	`</workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`</Relationships>`
+
+// SaveReviewsXLSX writes reviews to a stream as a single sheet Excel workbook.
+// Only the parts Excel requires are written and cells use inline strings, so no shared string table has to be
+// built up before the sheet can be streamed out.
+func SaveReviewsXLSX(stream io.Writer, reviews AppReviews) error {
+	archive := zip.NewWriter(stream)
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	}
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(file, part.content); err != nil {
+			return err
+		}
+	}
+
+	file, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	sheet := bufio.NewWriter(file)
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	writeXLSXRow(sheet, 1, exportColumns)
+	for index, review := range reviews {
+		writeXLSXRow(sheet, index+2, exportRow(review))
+	}
+
+	sheet.WriteString(`</sheetData></worksheet>`)
+	if err = sheet.Flush(); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+// writeXLSXRow writes a row of inline string cells, keeping the rating and sentiment columns numeric
+func writeXLSXRow(sheet *bufio.Writer, row int, values []string) {
+	fmt.Fprintf(sheet, `<row r="%d">`, row)
+	for column, value := range values {
+		ref := fmt.Sprintf("%c%d", 'A'+column, row)
+		if _, err := strconv.ParseFloat(value, 64); err == nil && (exportColumns[column] == "rating" || exportColumns[column] == "sentiment") {
+			fmt.Fprintf(sheet, `<c r="%s"><v>%s</v></c>`, ref, value)
+			continue
+		}
+		fmt.Fprintf(sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+		xml.EscapeText(sheet, []byte(value))
+		sheet.WriteString(`</t></is></c>`)
+	}
+	sheet.WriteString(`</row>`)
+}
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func exportTestReviews() AppReviews {
+	time1, _ := time.Parse(time.RFC3339, "2024-03-13T04:25:02-07:00")
+	time2, _ := time.Parse(time.RFC3339, "2024-03-09T08:50:25-07:00")
+	return AppReviews{
+		AppReview{
+			Author:  Author{Name: "Test Author Foo", Uri: "unused"},
+			Updated: time1,
+			Rating:  1,
+			Version: "1.0",
+			Id:      "11039586140",
+			Title:   "Test \"Quoted\", Title",
+			Content: "Test Review One",
+		},
+		AppReview{
+			Author:  Author{Name: "Review Author Blah", Uri: "unused"},
+			Updated: time2,
+			Rating:  3,
+			Version: "1.1",
+			Id:      "11026038445",
+			Title:   "Test <Review> & Title",
+			Content: "Test\nReview\nFive",
+			Tags:    []string{"crash", "login"},
+		},
+	}
+}
+
+func TestSaveReviewsCSV(t *testing.T) {
+	reviews := exportTestReviews()
+	out := bytes.NewBuffer([]byte{})
+	if err := SaveReviewsCSV(out, reviews); err != nil {
+		t.Fatalf("expected no error writing csv, got %s", err)
+	}
+
+	records, err := csv.NewReader(out).ReadAll()
+	if err != nil {
+		t.Fatalf("expected csv output to parse, got %s", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected a header and 2 rows, got %d records", len(records))
+	}
+	if strings.Join(records[0], ",") != strings.Join(exportColumns, ",") {
+		t.Errorf("unexpected header %v", records[0])
+	}
+	if records[1][4] != reviews[0].Title {
+		t.Errorf("expected quoted title %q to survive, got %q", reviews[0].Title, records[1][4])
+	}
+	if records[2][5] != reviews[1].Content {
+		t.Errorf("expected multiline content %q to survive, got %q", reviews[1].Content, records[2][5])
+	}
+	if records[2][1] != "2024-03-09T08:50:25-07:00" || records[2][2] != "3" {
+		t.Errorf("unexpected updated / rating columns %q %q", records[2][1], records[2][2])
+	}
+}
+
+func TestSaveReviewsNDJSON(t *testing.T) {
+	reviews := exportTestReviews()
+	out := bytes.NewBuffer([]byte{})
+	if err := SaveReviewsNDJSON(out, reviews); err != nil {
+		t.Fatalf("expected no error writing ndjson, got %s", err)
+	}
+
+	scanner := bufio.NewScanner(out)
+	lines := 0
+	for scanner.Scan() {
+		review := AppReview{}
+		if err := json.Unmarshal(scanner.Bytes(), &review); err != nil {
+			t.Errorf("expected line %d to be a json review, got %s", lines+1, err)
+		}
+		if review.Id != reviews[lines].Id {
+			t.Errorf("expected review %s on line %d, got %s", reviews[lines].Id, lines+1, review.Id)
+		}
+		lines++
+	}
+	if lines != 2 {
+		t.Errorf("expected 2 lines, got %d", lines)
+	}
+}
+
+func TestSaveReviewsXLSX(t *testing.T) {
+	out := bytes.NewBuffer([]byte{})
+	if err := SaveReviewsXLSX(out, exportTestReviews()); err != nil {
+		t.Fatalf("expected no error writing xlsx, got %s", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()))
+	if err != nil {
+		t.Fatalf("expected xlsx output to be a zip archive, got %s", err)
+	}
+
+	found := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %s", file.Name, err)
+		}
+		data, _ := io.ReadAll(reader)
+		reader.Close()
+		found[file.Name] = string(data)
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels"} {
+		if _, ok := found[name]; !ok {
+			t.Errorf("expected xlsx to contain %s", name)
+		}
+	}
+
+	sheet := found["xl/worksheets/sheet1.xml"]
+	if !strings.Contains(sheet, "Test &lt;Review&gt; &amp; Title") {
+		t.Errorf("expected title to be xml escaped in sheet:\n%s", sheet)
+	}
+	if !strings.Contains(sheet, `<c r="C3"><v>3</v></c>`) {
+		t.Errorf("expected rating to be a numeric cell in sheet:\n%s", sheet)
+	}
+}
+
+func TestExportFormats(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   string
+		expected ExportFormat
+		found    bool
+	}{
+		{"csv", "text/csv", FormatCSV, true},
+		{"ndjson with params", "application/x-ndjson; charset=utf-8", FormatNDJSON, true},
+		{"first match wins", "text/html, application/vnd.openxmlformats-officedocument.spreadsheetml.sheet;q=0.9, text/csv", FormatXLSX, true},
+		{"browser default", "text/html,application/xhtml+xml,*/*;q=0.8", "", false},
+		{"empty", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			format, found := FormatForAccept(tt.accept)
+			if format != tt.expected || found != tt.found {
+				t.Errorf("got %q (%v), want %q (%v)", format, found, tt.expected, tt.found)
+			}
+		})
+	}
+
+	if format, err := ParseExportFormat(" CSV "); err != nil || format != FormatCSV {
+		t.Errorf("expected to parse csv, got %q (%v)", format, err)
+	}
+	if _, err := ParseExportFormat("yaml"); err == nil {
+		t.Errorf("expected an error for an unsupported format")
+	}
+}
@@ -0,0 +1,224 @@
+package models
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/marcuswu/app-reviews/language"
+	"github.com/marcuswu/app-reviews/sentiment"
+)
+
+// ReviewFilter narrows down a list of app reviews. Zero values match every review.
+type ReviewFilter struct {
+	Ratings    []int             // only include reviews with one of these ratings
+	Keyword    string            // only include reviews whose title or content contains this (case insensitive)
+	Sentiments []sentiment.Label // only include reviews whose sentiment score has one of these labels
+	Tags       []string          // only include reviews with at least one of these tags (lowercase)
+	Languages  []string          // only include reviews in one of these languages, such as en or und
+	Spam       SpamFilter        // whether to include reviews suspected to be spam
+	Removed    RemovedFilter     // whether to include reviews that disappeared from Apple's feed
+}
+
+// SpamFilter chooses whether reviews suspected to be spam are included
+type SpamFilter string
+
+const (
+	SpamInclude SpamFilter = ""        // include every review
+	SpamExclude SpamFilter = "exclude" // leave out suspected spam
+	SpamOnly    SpamFilter = "only"    // only include suspected spam
+)
+
+// ParseSpamFilter returns the spam filter with a name such as "exclude". An empty name or "include" is SpamInclude.
+func ParseSpamFilter(name string) (SpamFilter, error) {
+	filter := SpamFilter(strings.ToLower(strings.TrimSpace(name)))
+	switch filter {
+	case "include":
+		return SpamInclude, nil
+	case SpamInclude, SpamExclude, SpamOnly:
+		return filter, nil
+	}
+	return "", fmt.Errorf("invalid spam filter %q, expected include, exclude or only", name)
+}
+
+// RemovedFilter chooses whether reviews that disappeared from Apple's feed are included. Unlike SpamFilter, they are
+// left out by default.
+type RemovedFilter string
+
+const (
+	RemovedExclude RemovedFilter = ""        // leave out removed reviews
+	RemovedInclude RemovedFilter = "include" // include every review
+	RemovedOnly    RemovedFilter = "only"    // only include removed reviews
+)
+
+// ParseRemovedFilter returns the removed review filter with a name such as "include". An empty name or "exclude" is
+// RemovedExclude.
+func ParseRemovedFilter(name string) (RemovedFilter, error) {
+	filter := RemovedFilter(strings.ToLower(strings.TrimSpace(name)))
+	switch filter {
+	case "exclude":
+		return RemovedExclude, nil
+	case RemovedExclude, RemovedInclude, RemovedOnly:
+		return filter, nil
+	}
+	return "", fmt.Errorf("invalid removed filter %q, expected include, exclude or only", name)
+}
+
+// ParseReviewFilter reads a filter from query parameters: rating (comma separated), q (keyword), sentiment
+// (comma separated labels), tag (comma separated), language (comma separated codes), spam (include, exclude or
+// only) and removed (exclude, include or only)
+func ParseReviewFilter(query url.Values) (ReviewFilter, error) {
+	filter := ReviewFilter{Keyword: strings.TrimSpace(query.Get("q"))}
+
+	for _, value := range strings.Split(query.Get("rating"), ",") {
+		value = strings.TrimSpace(value)
+		if len(value) < 1 {
+			continue
+		}
+		rating, err := strconv.Atoi(value)
+		if err != nil || rating < 1 || rating > 5 {
+			return filter, fmt.Errorf("invalid rating %q, expected a number from 1 to 5", value)
+		}
+		filter.Ratings = append(filter.Ratings, rating)
+	}
+
+	for _, value := range strings.Split(query.Get("sentiment"), ",") {
+		if len(strings.TrimSpace(value)) < 1 {
+			continue
+		}
+		label, err := sentiment.ParseLabel(value)
+		if err != nil {
+			return filter, err
+		}
+		filter.Sentiments = append(filter.Sentiments, label)
+	}
+
+	for _, value := range strings.Split(query.Get("tag"), ",") {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if len(value) > 0 {
+			filter.Tags = append(filter.Tags, value)
+		}
+	}
+
+	for _, value := range strings.Split(query.Get("language"), ",") {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if len(value) < 1 {
+			continue
+		}
+		if err := language.Check(value); err != nil {
+			return filter, err
+		}
+		filter.Languages = append(filter.Languages, value)
+	}
+
+	spam, err := ParseSpamFilter(query.Get("spam"))
+	if err != nil {
+		return filter, err
+	}
+	filter.Spam = spam
+
+	removed, err := ParseRemovedFilter(query.Get("removed"))
+	if err != nil {
+		return filter, err
+	}
+	filter.Removed = removed
+
+	return filter, nil
+}
+
+// Matches reports whether a single review passes the filter
+func (f ReviewFilter) Matches(review AppReview) bool {
+	if len(f.Ratings) > 0 {
+		found := false
+		for _, rating := range f.Ratings {
+			if review.Rating == rating {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(f.Keyword) > 0 {
+		keyword := strings.ToLower(f.Keyword)
+		if !strings.Contains(strings.ToLower(review.Title), keyword) &&
+			!strings.Contains(strings.ToLower(review.Content), keyword) {
+			return false
+		}
+	}
+
+	if len(f.Sentiments) > 0 && !slices.Contains(f.Sentiments, sentiment.Classify(review.Sentiment)) {
+		return false
+	}
+
+	if (f.Spam == SpamExclude && review.SuspectedSpam()) || (f.Spam == SpamOnly && !review.SuspectedSpam()) {
+		return false
+	}
+
+	if (f.Removed == RemovedExclude && review.Removed()) || (f.Removed == RemovedOnly && !review.Removed()) {
+		return false
+	}
+
+	if len(f.Languages) > 0 && !slices.Contains(f.Languages, review.LanguageOrUndetermined()) {
+		return false
+	}
+
+	if len(f.Tags) > 0 && !slices.ContainsFunc(review.Tags, func(tag string) bool { return slices.Contains(f.Tags, tag) }) {
+		return false
+	}
+
+	return true
+}
+
+// Filter returns the app reviews that pass the filter
+func (r AppReviews) Filter(filter ReviewFilter) AppReviews {
+	filtered := make(AppReviews, 0, len(r))
+	for _, review := range r {
+		if filter.Matches(review) {
+			filtered = append(filtered, review)
+		}
+	}
+	return filtered
+}
+
+// ReviewOrder is an order app reviews can be sorted in
+type ReviewOrder string
+
+const (
+	OrderNewest       ReviewOrder = "newest"
+	OrderOldest       ReviewOrder = "oldest"
+	OrderMostNegative ReviewOrder = "most-negative"
+	OrderMostPositive ReviewOrder = "most-positive"
+)
+
+// ParseReviewOrder returns the order with a name such as "most-negative". An empty name is OrderNewest.
+func ParseReviewOrder(name string) (ReviewOrder, error) {
+	order := ReviewOrder(strings.ToLower(strings.TrimSpace(name)))
+	switch order {
+	case "":
+		return OrderNewest, nil
+	case OrderNewest, OrderOldest, OrderMostNegative, OrderMostPositive:
+		return order, nil
+	}
+	return "", fmt.Errorf("invalid sort %q, expected newest, oldest, most-negative or most-positive", name)
+}
+
+// Sort sorts the app reviews in place. Reviews with the same sentiment are sorted newest first.
+func (r AppReviews) Sort(order ReviewOrder) {
+	sort.SliceStable(r, func(i, j int) bool {
+		switch order {
+		case OrderOldest:
+			return r[i].Updated.Before(r[j].Updated)
+		case OrderMostNegative, OrderMostPositive:
+			if r[i].Sentiment != r[j].Sentiment {
+				return (r[i].Sentiment < r[j].Sentiment) == (order == OrderMostNegative)
+			}
+		}
+		return r[i].Updated.After(r[j].Updated)
+	})
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// TrackRemoved compares reviews fetched from Apple with the app's previously cached reviews. Cached reviews that are
+// missing from reviews are added back, marked as removed at now, rather than dropped. Only cached reviews newer than
+// the oldest fetched review are compared, since older ones, and others left at the same time, may just be past the
+// last page fetched; those are dropped as before. Reviews already marked removed keep when they were first found
+// missing. It returns reviews with the removed reviews added and the reviews that were newly found missing.
+func (r AppReviews) TrackRemoved(previous AppReviews, now time.Time) (AppReviews, AppReviews) {
+	removed := AppReviews{}
+	if len(r) < 1 {
+		return r, removed
+	}
+	fetched := make(map[string]bool, len(r))
+	oldest := r[0].Updated
+	for _, review := range r {
+		fetched[review.Id] = true
+		if review.Updated.Before(oldest) {
+			oldest = review.Updated
+		}
+	}
+
+	for _, review := range previous {
+		if fetched[review.Id] || !review.Updated.After(oldest) {
+			continue
+		}
+		if !review.Removed() {
+			removedAt := now
+			review.RemovedAt = &removedAt
+			removed = append(removed, review)
+		}
+		r = append(r, review)
+	}
+	return r, removed
+}
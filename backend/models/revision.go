@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Revision is a version of a review its author has since edited
+type Revision struct {
+	Updated time.Time `json:"updated"`
+	Rating  int       `json:"rating"`
+	Version string    `json:"version"` // of the app when this revision was written
+	Title   string    `json:"title"`
+	Content string    `json:"content"`
+}
+
+// Revision returns the review's current title, content and rating as a revision
+func (r AppReview) Revision() Revision {
+	return Revision{Updated: r.Updated, Rating: r.Rating, Version: r.Version, Title: r.Title, Content: r.Content}
+}
+
+// Edited reports whether the review's title, content or rating differ from an earlier copy of it
+func (r AppReview) Edited(earlier AppReview) bool {
+	return r.Title != earlier.Title || r.Content != earlier.Content || r.Rating != earlier.Rating
+}
+
+// History returns every revision of the review, oldest first, ending with the current one
+func (r AppReview) History() []Revision {
+	history := make([]Revision, 0, len(r.Revisions)+1)
+	history = append(history, r.Revisions...)
+	return append(history, r.Revision())
+}
+
+// TrackRevisions compares reviews with the app's previously cached reviews, carrying over their earlier revisions and
+// adding the previous copy of any review whose title, content or rating changed. It returns how many reviews are new
+// and the reviews that were edited.
+func (r AppReviews) TrackRevisions(previous AppReviews) (int, AppReviews) {
+	byId := make(map[string]AppReview, len(previous))
+	for _, review := range previous {
+		byId[review.Id] = review
+	}
+	discovered := 0
+	edited := AppReviews{}
+	for i := range r {
+		earlier, ok := byId[r[i].Id]
+		if !ok {
+			discovered++
+			continue
+		}
+		r[i].Revisions = earlier.Revisions
+		if r[i].Edited(earlier) {
+			r[i].Revisions = append(append([]Revision{}, earlier.Revisions...), earlier.Revision())
+			edited = append(edited, r[i])
+		}
+	}
+	return discovered, edited
+}
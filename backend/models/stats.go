@@ -0,0 +1,126 @@
+package models
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/marcuswu/app-reviews/sentiment"
+)
+
+// VersionStats summarizes the reviews left for a single app version
+type VersionStats struct {
+	Version       string  `json:"version"`
+	Count         int     `json:"count"`
+	AverageRating float64 `json:"averageRating"`
+}
+
+// SentimentPeriod summarizes the sentiment of the reviews updated during one UTC day
+type SentimentPeriod struct {
+	Start            time.Time `json:"start"`
+	Count            int       `json:"count"`
+	AverageSentiment float64   `json:"averageSentiment"`
+}
+
+// ReviewStats is a summary of a list of app reviews
+type ReviewStats struct {
+	Count             int                     `json:"count"`
+	AverageRating     float64                 `json:"averageRating"`
+	Histogram         map[int]int             `json:"histogram"` // rating -> number of reviews
+	Versions          []VersionStats          `json:"versions"`
+	AverageSentiment  float64                 `json:"averageSentiment"`
+	Sentiments        map[sentiment.Label]int `json:"sentiments"`        // label -> number of reviews
+	SentimentOverTime []SentimentPeriod       `json:"sentimentOverTime"` // oldest day first
+	Languages         map[string]int          `json:"languages"`         // language code -> number of reviews
+	Spam              int                     `json:"spam"`              // reviews suspected to be spam
+}
+
+// Stats summarizes the app reviews by rating, app version, sentiment and language
+func (r AppReviews) Stats() ReviewStats {
+	stats := ReviewStats{
+		Histogram:         map[int]int{1: 0, 2: 0, 3: 0, 4: 0, 5: 0},
+		Versions:          []VersionStats{},
+		Sentiments:        map[sentiment.Label]int{sentiment.Negative: 0, sentiment.Neutral: 0, sentiment.Positive: 0},
+		SentimentOverTime: []SentimentPeriod{},
+		Languages:         map[string]int{},
+	}
+
+	total := 0
+	totalSentiment := 0.0
+	versions := map[string]*VersionStats{}
+	days := map[time.Time]*SentimentPeriod{}
+	for _, review := range r {
+		stats.Count++
+		total += review.Rating
+		stats.Histogram[review.Rating]++
+		totalSentiment += review.Sentiment
+		stats.Sentiments[sentiment.Classify(review.Sentiment)]++
+		stats.Languages[review.LanguageOrUndetermined()]++
+		if review.SuspectedSpam() {
+			stats.Spam++
+		}
+
+		start := review.Updated.UTC().Truncate(24 * time.Hour)
+		day, ok := days[start]
+		if !ok {
+			day = &SentimentPeriod{Start: start}
+			days[start] = day
+		}
+		// Keep a running total in AverageSentiment until we have the count
+		day.Count++
+		day.AverageSentiment += review.Sentiment
+
+		version, ok := versions[review.Version]
+		if !ok {
+			version = &VersionStats{Version: review.Version}
+			versions[review.Version] = version
+		}
+		// Keep a running total in AverageRating until we have the count
+		version.Count++
+		version.AverageRating += float64(review.Rating)
+	}
+
+	if stats.Count > 0 {
+		stats.AverageRating = float64(total) / float64(stats.Count)
+		stats.AverageSentiment = totalSentiment / float64(stats.Count)
+	}
+
+	for _, day := range days {
+		day.AverageSentiment /= float64(day.Count)
+		stats.SentimentOverTime = append(stats.SentimentOverTime, *day)
+	}
+	sort.Slice(stats.SentimentOverTime, func(i, j int) bool {
+		return stats.SentimentOverTime[i].Start.Before(stats.SentimentOverTime[j].Start)
+	})
+
+	for _, version := range versions {
+		version.AverageRating /= float64(version.Count)
+		stats.Versions = append(stats.Versions, *version)
+	}
+	sort.Slice(stats.Versions, func(i, j int) bool {
+		return compareVersions(stats.Versions[i].Version, stats.Versions[j].Version) > 0
+	})
+
+	return stats
+}
+
+// compareVersions compares dotted version strings numerically where possible so that 10.0 sorts after 9.0
+func compareVersions(a string, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+		if aErr != nil || bErr != nil {
+			if c := strings.Compare(aParts[i], bParts[i]); c != 0 {
+				return c
+			}
+			continue
+		}
+		if aNum != bNum {
+			return aNum - bNum
+		}
+	}
+	return len(aParts) - len(bParts)
+}
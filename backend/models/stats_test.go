@@ -0,0 +1,130 @@
+package models
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcuswu/app-reviews/sentiment"
+)
+
+func TestReviewStats(t *testing.T) {
+	reviews := AppReviews{
+		AppReview{Id: "1", Rating: 1, Version: "9.1"},
+		AppReview{Id: "2", Rating: 5, Version: "10.0"},
+		AppReview{Id: "3", Rating: 4, Version: "10.0"},
+		AppReview{Id: "4", Rating: 5, Version: "9.1"},
+	}
+
+	stats := reviews.Stats()
+	if stats.Count != 4 {
+		t.Errorf("expected 4 reviews, got %d", stats.Count)
+	}
+	if stats.AverageRating != 3.75 {
+		t.Errorf("expected an average rating of 3.75, got %f", stats.AverageRating)
+	}
+	if stats.Histogram[5] != 2 || stats.Histogram[4] != 1 || stats.Histogram[1] != 1 || stats.Histogram[2] != 0 {
+		t.Errorf("unexpected histogram %v", stats.Histogram)
+	}
+	if len(stats.Versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(stats.Versions))
+	}
+	if stats.Versions[0].Version != "10.0" || stats.Versions[0].AverageRating != 4.5 {
+		t.Errorf("expected version 10.0 first with an average of 4.5, got %s with %f",
+			stats.Versions[0].Version, stats.Versions[0].AverageRating)
+	}
+
+	empty := AppReviews{}.Stats()
+	if empty.Count != 0 || empty.AverageRating != 0 {
+		t.Errorf("expected empty stats, got %d reviews averaging %f", empty.Count, empty.AverageRating)
+	}
+}
+
+func TestSentimentStats(t *testing.T) {
+	day := time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC)
+	reviews := AppReviews{
+		AppReview{Id: "1", Rating: 3, Title: "Crashes constantly, useless", Updated: day.Add(2 * time.Hour)},
+		AppReview{Id: "2", Rating: 5, Title: "Love it", Updated: day.Add(20 * time.Hour)},
+		AppReview{Id: "3", Rating: 4, Title: "Works great", Updated: day.Add(26 * time.Hour)},
+		AppReview{Id: "4", Rating: 3, Title: "Updated today", Updated: day.Add(30 * time.Hour)},
+	}
+	reviews.ScoreSentiment()
+
+	stats := reviews.Stats()
+	if stats.Sentiments[sentiment.Negative] != 1 || stats.Sentiments[sentiment.Neutral] != 1 || stats.Sentiments[sentiment.Positive] != 2 {
+		t.Errorf("unexpected sentiment counts %v", stats.Sentiments)
+	}
+	if len(stats.SentimentOverTime) != 2 {
+		t.Fatalf("expected 2 days of sentiment, got %v", stats.SentimentOverTime)
+	}
+	first, second := stats.SentimentOverTime[0], stats.SentimentOverTime[1]
+	if !first.Start.Equal(day) || first.Count != 2 || second.Count != 2 {
+		t.Errorf("expected 2 reviews a day starting on %s, got %v", day, stats.SentimentOverTime)
+	}
+	if first.AverageSentiment != (reviews[0].Sentiment+reviews[1].Sentiment)/2 || second.AverageSentiment <= first.AverageSentiment {
+		t.Errorf("expected sentiment to improve on the second day, got %v", stats.SentimentOverTime)
+	}
+}
+
+func TestLanguageStats(t *testing.T) {
+	reviews := AppReviews{
+		AppReview{Id: "1", Title: "Crashes every time I open it"},
+		AppReview{Id: "2", Title: "Se cierra cada vez que la abro"},
+		AppReview{Id: "3", Title: "Great"},
+	}
+	reviews.DetectLanguage()
+	reviews = append(reviews, AppReview{Id: "4", Title: "Cached before languages were detected"})
+
+	stats := reviews.Stats()
+	if stats.Languages["en"] != 1 || stats.Languages["es"] != 1 || stats.Languages["und"] != 2 {
+		t.Errorf("unexpected language counts %v", stats.Languages)
+	}
+
+	query, _ := url.ParseQuery("language=es,und")
+	filter, err := ParseReviewFilter(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filtered := reviews.Filter(filter); len(filtered) != 3 || filtered[0].Id != "2" {
+		t.Errorf("expected the Spanish and undetermined reviews, got %v", filtered)
+	}
+	if _, err := ParseReviewFilter(url.Values{"language": {"xx"}}); err == nil {
+		t.Errorf("expected an error for an unsupported language")
+	}
+}
+
+func TestSortReviews(t *testing.T) {
+	start := time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC)
+	reviews := AppReviews{
+		AppReview{Id: "ok", Sentiment: 0, Updated: start.Add(time.Hour)},
+		AppReview{Id: "bad", Sentiment: -0.8, Updated: start},
+		AppReview{Id: "good", Sentiment: 0.6, Updated: start.Add(2 * time.Hour)},
+		AppReview{Id: "meh", Sentiment: 0, Updated: start.Add(3 * time.Hour)},
+	}
+	tests := map[string]string{
+		"":              "meh,good,ok,bad",
+		"newest":        "meh,good,ok,bad",
+		"oldest":        "bad,ok,good,meh",
+		"most-negative": "bad,meh,ok,good",
+		"most-positive": "good,meh,ok,bad",
+	}
+	for name, expected := range tests {
+		order, err := ParseReviewOrder(name)
+		if err != nil {
+			t.Fatalf("expected %q to parse, got %s", name, err)
+		}
+		reviews.Sort(order)
+		ids := []string{}
+		for _, review := range reviews {
+			ids = append(ids, review.Id)
+		}
+		if strings.Join(ids, ",") != expected {
+			t.Errorf("expected %s sorted as %s, got %v", name, expected, ids)
+		}
+	}
+
+	if _, err := ParseReviewOrder("rating"); err == nil {
+		t.Errorf("expected an error for an unknown sort")
+	}
+}
@@ -0,0 +1,184 @@
+package models
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// FeedInfo describes the feed app reviews are re-published in
+type FeedInfo struct {
+	AppId string
+	Title string
+	Link  string // URL the feed is served from
+}
+
+// feedId returns a stable id for the feed of an app
+func (fi FeedInfo) feedId() string {
+	return fmt.Sprintf("urn:app-reviews:app:%s", fi.AppId)
+}
+
+// reviewEntryId returns a stable id for a review so feed readers can tell which entries they have seen
+func reviewEntryId(review AppReview) string {
+	return fmt.Sprintf("urn:app-reviews:review:%s", review.Id)
+}
+
+// reviewEntryTitle adds the star rating to a review title since feed readers do not show custom fields
+func reviewEntryTitle(review AppReview) string {
+	rating := min(max(review.Rating, 0), 5)
+	return fmt.Sprintf("%s%s %s", strings.Repeat("★", rating), strings.Repeat("☆", 5-rating), review.Title)
+}
+
+// lastUpdated returns the most recent review update time, or now if there are no reviews
+func (r AppReviews) lastUpdated() time.Time {
+	latest := time.Time{}
+	for _, review := range r {
+		if review.Updated.After(latest) {
+			latest = review.Updated
+		}
+	}
+	if latest.IsZero() {
+		return now()
+	}
+	return latest
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+	Uri  string `xml:"uri,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	Id       string         `xml:"id"`
+	Title    string         `xml:"title"`
+	Updated  string         `xml:"updated"`
+	Author   atomPerson     `xml:"author"`
+	Link     *atomLink      `xml:"link,omitempty"`
+	Category []atomCategory `xml:"category"`
+	Content  atomText       `xml:"content"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Id      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// SaveReviewsAtom writes reviews to a stream as an Atom 1.0 feed
+func SaveReviewsAtom(stream io.Writer, reviews AppReviews, info FeedInfo) error {
+	feed := atomFeed{
+		Id:      info.feedId(),
+		Title:   info.Title,
+		Updated: reviews.lastUpdated().Format(time.RFC3339),
+		Link:    []atomLink{{Href: info.Link, Rel: "self", Type: "application/atom+xml"}},
+		Entries: make([]atomEntry, 0, len(reviews)),
+	}
+
+	for _, review := range reviews {
+		entry := atomEntry{
+			Id:      reviewEntryId(review),
+			Title:   reviewEntryTitle(review),
+			Updated: review.Updated.Format(time.RFC3339),
+			Author:  atomPerson{Name: review.Author.Name, Uri: review.Author.Uri},
+			Content: atomText{Type: "text", Body: review.Content},
+		}
+		if len(review.Link) > 0 {
+			entry.Link = &atomLink{Href: review.Link, Rel: "alternate", Type: "text/html"}
+		}
+		if len(review.Version) > 0 {
+			entry.Category = append(entry.Category, atomCategory{Term: "version:" + review.Version})
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	return writeFeed(stream, feed)
+}
+
+type rssGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description"`
+	Creator     string  `xml:"dc:creator,omitempty"`
+	Guid        rssGuid `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+// SaveReviewsRSS writes reviews to a stream as an RSS 2.0 feed
+func SaveReviewsRSS(stream io.Writer, reviews AppReviews, info FeedInfo) error {
+	feed := rssFeed{
+		Version: "2.0",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         info.Title,
+			Link:          info.Link,
+			Description:   fmt.Sprintf("Recent App Store reviews for app %s", info.AppId),
+			LastBuildDate: reviews.lastUpdated().Format(time.RFC1123Z),
+			Items:         make([]rssItem, 0, len(reviews)),
+		},
+	}
+
+	for _, review := range reviews {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       reviewEntryTitle(review),
+			Link:        review.Link,
+			Description: review.Content,
+			Creator:     review.Author.Name,
+			Guid:        rssGuid{IsPermaLink: false, Value: reviewEntryId(review)},
+			PubDate:     review.Updated.Format(time.RFC1123Z),
+		})
+	}
+
+	return writeFeed(stream, feed)
+}
+
+func writeFeed(stream io.Writer, feed any) error {
+	if _, err := io.WriteString(stream, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(stream)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(feed); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
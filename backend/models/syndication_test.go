@@ -0,0 +1,115 @@
+package models
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestReviewFilter(t *testing.T) {
+	reviews := exportTestReviews()
+	tests := []struct {
+		name     string
+		query    string
+		expected []string
+		error    bool
+	}{
+		{"no filter", "", []string{"11039586140", "11026038445"}, false},
+		{"one rating", "rating=3", []string{"11026038445"}, false},
+		{"several ratings", "rating=1,3", []string{"11039586140", "11026038445"}, false},
+		{"keyword in content", "q=review+five", []string{}, false},
+		{"keyword case", "q=QUOTED", []string{"11039586140"}, false},
+		{"keyword and rating", "q=title&rating=1", []string{"11039586140"}, false},
+		{"neutral sentiment", "sentiment=neutral", []string{"11039586140", "11026038445"}, false},
+		{"other sentiments", "sentiment=negative,positive", []string{}, false},
+		{"tag", "tag=Login", []string{"11026038445"}, false},
+		{"any tag", "tag=billing,crash", []string{"11026038445"}, false},
+		{"missing tag", "tag=billing", []string{}, false},
+		{"bad rating", "rating=6", nil, true},
+		{"bad sentiment", "sentiment=angry", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, _ := url.ParseQuery(tt.query)
+			filter, err := ParseReviewFilter(query)
+			if tt.error {
+				if err == nil {
+					t.Errorf("expected an error parsing %q", tt.query)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected no error parsing %q, got %s", tt.query, err)
+			}
+
+			ids := []string{}
+			for _, review := range reviews.Filter(filter) {
+				ids = append(ids, review.Id)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("got %v, want %v", ids, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSaveReviewsAtom(t *testing.T) {
+	reviews := exportTestReviews()
+	reviews[0].Link = "https://itunes.apple.com/us/review?id=1"
+	out := bytes.NewBuffer([]byte{})
+	info := FeedInfo{AppId: "1234", Title: "Test Feed", Link: "http://localhost/1234/atom"}
+	if err := SaveReviewsAtom(out, reviews, info); err != nil {
+		t.Fatalf("expected no error writing atom, got %s", err)
+	}
+
+	feed := atomFeed{}
+	if err := xml.Unmarshal(out.Bytes(), &feed); err != nil {
+		t.Fatalf("expected atom output to parse, got %s", err)
+	}
+	if feed.Id != "urn:app-reviews:app:1234" || feed.Updated != "2024-03-13T04:25:02-07:00" {
+		t.Errorf("unexpected feed id %s or updated %s", feed.Id, feed.Updated)
+	}
+	if len(feed.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(feed.Entries))
+	}
+	if feed.Entries[0].Id != "urn:app-reviews:review:11039586140" || feed.Entries[0].Link.Href != reviews[0].Link {
+		t.Errorf("unexpected entry id %s or link %v", feed.Entries[0].Id, feed.Entries[0].Link)
+	}
+	if feed.Entries[1].Title != "★★★☆☆ Test <Review> & Title" || feed.Entries[1].Content.Body != "Test\nReview\nFive" {
+		t.Errorf("unexpected entry title %q or content %q", feed.Entries[1].Title, feed.Entries[1].Content.Body)
+	}
+}
+
+func TestSaveReviewsRSS(t *testing.T) {
+	out := bytes.NewBuffer([]byte{})
+	info := FeedInfo{AppId: "1234", Title: "Test Feed", Link: "http://localhost/1234/rss"}
+	if err := SaveReviewsRSS(out, exportTestReviews(), info); err != nil {
+		t.Fatalf("expected no error writing rss, got %s", err)
+	}
+
+	var feed struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			LastBuildDate string `xml:"lastBuildDate"`
+			Items         []struct {
+				Guid    string `xml:"guid"`
+				PubDate string `xml:"pubDate"`
+				Creator string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(out.Bytes(), &feed); err != nil {
+		t.Fatalf("expected rss output to parse, got %s", err)
+	}
+	if feed.Version != "2.0" || feed.Channel.LastBuildDate != "Wed, 13 Mar 2024 04:25:02 -0700" {
+		t.Errorf("unexpected version %s or build date %s", feed.Version, feed.Channel.LastBuildDate)
+	}
+	if len(feed.Channel.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(feed.Channel.Items))
+	}
+	if feed.Channel.Items[1].Guid != "urn:app-reviews:review:11026038445" || feed.Channel.Items[1].Creator != "Review Author Blah" {
+		t.Errorf("unexpected guid %s or creator %s", feed.Channel.Items[1].Guid, feed.Channel.Items[1].Creator)
+	}
+}
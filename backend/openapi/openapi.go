@@ -0,0 +1,47 @@
+// Package openapi serves the OpenAPI 3 document describing the reviews API
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+)
+
+// Spec is the OpenAPI document as JSON. Tests in the main package check it against the routes and models.
+//
+//go:embed openapi.json
+var Spec []byte
+
+// Handler serves Spec
+func Handler() http.Handler {
+	return http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		res.Write(Spec)
+	})
+}
+
+// Document is the part of an OpenAPI document needed to check it against the server and client
+type Document struct {
+	Paths      map[string]map[string]Operation `json:"paths"` // by path then lower case method
+	Components struct {
+		Schemas map[string]Schema `json:"schemas"`
+	} `json:"components"`
+}
+
+// Operation is a method on a path
+type Operation struct {
+	OperationId string `json:"operationId"`
+}
+
+// Schema is an object schema
+type Schema struct {
+	Required   []string                   `json:"required"`
+	Properties map[string]json.RawMessage `json:"properties"`
+}
+
+// Parse decodes Spec
+func Parse() (Document, error) {
+	document := Document{}
+	err := json.Unmarshal(Spec, &document)
+	return document, err
+}
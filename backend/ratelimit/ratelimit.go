@@ -0,0 +1,174 @@
+// Package ratelimit provides token bucket rate limiting keyed by client
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/marcuswu/app-reviews/clock"
+)
+
+// pruneEvery is how many Allow calls pass between dropping idle buckets
+const pruneEvery = 1000
+
+// Result describes the outcome of asking a limiter for a token
+type Result struct {
+	Allowed    bool
+	Limit      int           // bucket size
+	Remaining  int           // whole tokens left after this request
+	RetryAfter time.Duration // how long until a token is available when not allowed
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// Limiter hands out tokens from a bucket per key. Buckets hold up to burst tokens and refill at perMinute tokens
+// a minute.
+type Limiter struct {
+	mu      sync.Mutex
+	clock   clock.Clock
+	rate    float64 // tokens per second
+	burst   int
+	buckets map[string]*bucket
+	calls   int
+}
+
+// NewLimiter creates a limiter allowing perMinute requests a minute per key with bursts of up to burst requests.
+// A burst below one uses perMinute.
+func NewLimiter(c clock.Clock, perMinute int, burst int) *Limiter {
+	if burst < 1 {
+		burst = perMinute
+	}
+	return &Limiter{
+		clock:   c,
+		rate:    float64(perMinute) / 60,
+		burst:   burst,
+		buckets: map[string]*bucket{},
+	}
+}
+
+// Allow takes a token from the key's bucket if one is available
+func (l *Limiter) Allow(key string) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.calls++
+	if l.calls%pruneEvery == 0 {
+		l.prune(now)
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(l.burst), b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+
+	result := Result{Limit: l.burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+		result.Remaining = int(b.tokens)
+		return result
+	}
+
+	if l.rate > 0 {
+		result.RetryAfter = time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	} else {
+		result.RetryAfter = time.Duration(math.MaxInt64)
+	}
+	return result
+}
+
+// Peek reports whether the key's bucket holds n tokens, and how long until it does if not, without taking any
+func (l *Limiter) Peek(key string, n int) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	tokens := float64(l.burst)
+	if b, ok := l.buckets[key]; ok {
+		tokens = math.Min(tokens, b.tokens+l.clock.Now().Sub(b.updated).Seconds()*l.rate)
+	}
+	result := Result{Limit: l.burst, Remaining: int(tokens)}
+	switch {
+	case tokens >= float64(n):
+		result.Allowed = true
+	case n > l.burst || l.rate <= 0:
+		result.RetryAfter = time.Duration(math.MaxInt64)
+	default:
+		result.RetryAfter = time.Duration((float64(n) - tokens) / l.rate * float64(time.Second))
+	}
+	return result
+}
+
+// prune drops buckets that have refilled completely since they are the same as a new bucket. The caller must hold
+// l.mu.
+func (l *Limiter) prune(now time.Time) {
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.updated).Seconds()*l.rate >= float64(l.burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// DistinctCap caps how many distinct keys are admitted within a sliding window
+type DistinctCap struct {
+	mu     sync.Mutex
+	clock  clock.Clock
+	limit  int
+	window time.Duration
+	seen   map[string]time.Time // when each key in the window was admitted
+}
+
+// NewDistinctCap creates a cap admitting up to limit distinct keys per window
+func NewDistinctCap(c clock.Clock, limit int, window time.Duration) *DistinctCap {
+	return &DistinctCap{clock: c, limit: limit, window: window, seen: map[string]time.Time{}}
+}
+
+// Allow reports whether key was admitted within the window already or there is room to admit it now
+func (d *DistinctCap) Allow(key string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.expire()
+	if _, ok := d.seen[key]; ok {
+		return true
+	}
+	if len(d.seen) >= d.limit {
+		return false
+	}
+	d.seen[key] = now
+	return true
+}
+
+// Fits reports whether every key was admitted within the window already or there is room to admit them all now,
+// without admitting any
+func (d *DistinctCap) Fits(keys []string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.expire()
+	unseen := map[string]bool{}
+	for _, key := range keys {
+		if _, ok := d.seen[key]; !ok {
+			unseen[key] = true
+		}
+	}
+	return len(d.seen)+len(unseen) <= d.limit
+}
+
+// expire forgets keys admitted before the window and returns the current time. The caller must hold d.mu.
+func (d *DistinctCap) expire() time.Time {
+	now := d.clock.Now()
+	for seen, at := range d.seen {
+		if now.Sub(at) >= d.window {
+			delete(d.seen, seen)
+		}
+	}
+	return now
+}
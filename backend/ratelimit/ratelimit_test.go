@@ -0,0 +1,76 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/marcuswu/app-reviews/clock/clocktest"
+)
+
+func TestLimiterRefills(t *testing.T) {
+	fake := clocktest.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	limiter := NewLimiter(fake, 60, 2)
+
+	for i := 0; i < 2; i++ {
+		if result := limiter.Allow("a"); !result.Allowed || result.Remaining != 1-i {
+			t.Fatalf("expected request %d to be allowed with %d remaining, got %+v", i+1, 1-i, result)
+		}
+	}
+	if peek := limiter.Peek("a", 1); peek.Allowed || peek.RetryAfter != time.Second {
+		t.Errorf("expected peeking at the empty bucket to wait a second, got %+v", peek)
+	}
+	if peek := limiter.Peek("b", 2); !peek.Allowed || peek.Remaining != 2 {
+		t.Errorf("expected a new bucket to hold 2 tokens, got %+v", peek)
+	}
+	result := limiter.Allow("a")
+	if result.Allowed || result.RetryAfter != time.Second {
+		t.Fatalf("expected third request to wait a second, got %+v", result)
+	}
+	if !limiter.Allow("b").Allowed {
+		t.Errorf("expected a different key to have its own bucket")
+	}
+
+	fake.Advance(time.Second)
+	if !limiter.Allow("a").Allowed {
+		t.Errorf("expected a token after a second")
+	}
+	if limiter.Allow("a").Allowed {
+		t.Errorf("expected only one token to refill after a second")
+	}
+}
+
+func TestLimiterPrunesFullBuckets(t *testing.T) {
+	fake := clocktest.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	limiter := NewLimiter(fake, 60, 0)
+	limiter.Allow("idle")
+	fake.Advance(time.Minute)
+	for i := 1; i < pruneEvery; i++ {
+		limiter.Allow("busy")
+	}
+	if _, ok := limiter.buckets["idle"]; ok {
+		t.Errorf("expected the refilled bucket to be pruned")
+	}
+}
+
+func TestDistinctCap(t *testing.T) {
+	fake := clocktest.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	apps := NewDistinctCap(fake, 2, time.Hour)
+
+	if !apps.Allow("1") || !apps.Allow("2") {
+		t.Fatalf("expected the first two keys to be admitted")
+	}
+	if !apps.Allow("1") {
+		t.Errorf("expected an admitted key to stay allowed")
+	}
+	if !apps.Fits([]string{"2", "1"}) || apps.Fits([]string{"1", "3"}) {
+		t.Errorf("expected only admitted keys to fit")
+	}
+	if apps.Allow("3") {
+		t.Errorf("expected a third key to be refused")
+	}
+
+	fake.Advance(time.Hour)
+	if !apps.Allow("3") {
+		t.Errorf("expected room once the window passed")
+	}
+}
@@ -0,0 +1,84 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/marcuswu/app-reviews/auth"
+	"github.com/marcuswu/app-reviews/rpc/reviewspb"
+)
+
+// methodScopes is the scope each method needs. Changing which apps are tracked needs admin.
+var methodScopes = map[string]auth.Scope{
+	reviewspb.Reviews_GetReviews_FullMethodName:        auth.ScopeRead,
+	reviewspb.Reviews_StreamNewReviews_FullMethodName:  auth.ScopeRead,
+	reviewspb.Reviews_GetStats_FullMethodName:          auth.ScopeRead,
+	reviewspb.Reviews_ManageTrackedApps_FullMethodName: auth.ScopeAdmin,
+}
+
+// authorize checks the API key sent in the authorization (as a bearer token) or x-api-key metadata against the
+// scope the method needs, and counts the call against the key's rate limit and daily quota
+func authorize(ctx context.Context, keyring *auth.Keyring, method string) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	presented := ""
+	if values := md.Get("authorization"); len(values) > 0 {
+		presented, _ = strings.CutPrefix(values[0], "Bearer ")
+	} else if values := md.Get("x-api-key"); len(values) > 0 {
+		presented = values[0]
+	}
+
+	key, ok := keyring.Authenticate(presented)
+	if !ok {
+		return ctx, status.Error(codes.Unauthenticated, "a valid API key is required")
+	}
+	scope, ok := methodScopes[method]
+	if !ok {
+		scope = auth.ScopeAdmin
+	}
+	if !key.HasScope(scope) {
+		return ctx, status.Errorf(codes.PermissionDenied, "API key %s does not have the %s scope", key.Name, scope)
+	}
+	if usage := keyring.Use(key); !usage.Allowed {
+		return ctx, status.Errorf(codes.ResourceExhausted, "%s exceeded, retry after %s", usage.Reason,
+			usage.RetryAfter.Round(time.Second))
+	}
+	return auth.WithKey(ctx, key), nil
+}
+
+// UnaryAuth requires unary calls to carry an API key with the method's scope
+func UnaryAuth(keyring *auth.Keyring) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authorize(ctx, keyring, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// authorizedStream replaces a stream's context with one carrying the authenticated key
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (as *authorizedStream) Context() context.Context {
+	return as.ctx
+}
+
+// StreamAuth requires streaming calls to carry an API key with the method's scope
+func StreamAuth(keyring *auth.Keyring) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authorize(stream.Context(), keyring, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authorizedStream{ServerStream: stream, ctx: ctx})
+	}
+}
@@ -0,0 +1,1504 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: reviews.proto
+
+// Recent App Store reviews, served from the same cache as the HTTP API
+
+package reviewspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Sentiment int32
+
+const (
+	Sentiment_SENTIMENT_UNSPECIFIED Sentiment = 0
+	Sentiment_SENTIMENT_NEGATIVE    Sentiment = 1
+	Sentiment_SENTIMENT_NEUTRAL     Sentiment = 2
+	Sentiment_SENTIMENT_POSITIVE    Sentiment = 3
+)
+
+// Enum value maps for Sentiment.
+var (
+	Sentiment_name = map[int32]string{
+		0: "SENTIMENT_UNSPECIFIED",
+		1: "SENTIMENT_NEGATIVE",
+		2: "SENTIMENT_NEUTRAL",
+		3: "SENTIMENT_POSITIVE",
+	}
+	Sentiment_value = map[string]int32{
+		"SENTIMENT_UNSPECIFIED": 0,
+		"SENTIMENT_NEGATIVE":    1,
+		"SENTIMENT_NEUTRAL":     2,
+		"SENTIMENT_POSITIVE":    3,
+	}
+)
+
+func (x Sentiment) Enum() *Sentiment {
+	p := new(Sentiment)
+	*p = x
+	return p
+}
+
+func (x Sentiment) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Sentiment) Descriptor() protoreflect.EnumDescriptor {
+	return file_reviews_proto_enumTypes[0].Descriptor()
+}
+
+func (Sentiment) Type() protoreflect.EnumType {
+	return &file_reviews_proto_enumTypes[0]
+}
+
+func (x Sentiment) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Sentiment.Descriptor instead.
+func (Sentiment) EnumDescriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{0}
+}
+
+type SpamFilter int32
+
+const (
+	SpamFilter_SPAM_FILTER_INCLUDE SpamFilter = 0
+	// Leave out reviews suspected to be spam, such as to keep them out of stats
+	SpamFilter_SPAM_FILTER_EXCLUDE SpamFilter = 1
+	SpamFilter_SPAM_FILTER_ONLY    SpamFilter = 2
+)
+
+// Enum value maps for SpamFilter.
+var (
+	SpamFilter_name = map[int32]string{
+		0: "SPAM_FILTER_INCLUDE",
+		1: "SPAM_FILTER_EXCLUDE",
+		2: "SPAM_FILTER_ONLY",
+	}
+	SpamFilter_value = map[string]int32{
+		"SPAM_FILTER_INCLUDE": 0,
+		"SPAM_FILTER_EXCLUDE": 1,
+		"SPAM_FILTER_ONLY":    2,
+	}
+)
+
+func (x SpamFilter) Enum() *SpamFilter {
+	p := new(SpamFilter)
+	*p = x
+	return p
+}
+
+func (x SpamFilter) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SpamFilter) Descriptor() protoreflect.EnumDescriptor {
+	return file_reviews_proto_enumTypes[1].Descriptor()
+}
+
+func (SpamFilter) Type() protoreflect.EnumType {
+	return &file_reviews_proto_enumTypes[1]
+}
+
+func (x SpamFilter) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SpamFilter.Descriptor instead.
+func (SpamFilter) EnumDescriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{1}
+}
+
+// Whether to include reviews that disappeared from Apple's feed, which are left out by default
+type RemovedFilter int32
+
+const (
+	RemovedFilter_REMOVED_FILTER_EXCLUDE RemovedFilter = 0
+	RemovedFilter_REMOVED_FILTER_INCLUDE RemovedFilter = 1
+	RemovedFilter_REMOVED_FILTER_ONLY    RemovedFilter = 2
+)
+
+// Enum value maps for RemovedFilter.
+var (
+	RemovedFilter_name = map[int32]string{
+		0: "REMOVED_FILTER_EXCLUDE",
+		1: "REMOVED_FILTER_INCLUDE",
+		2: "REMOVED_FILTER_ONLY",
+	}
+	RemovedFilter_value = map[string]int32{
+		"REMOVED_FILTER_EXCLUDE": 0,
+		"REMOVED_FILTER_INCLUDE": 1,
+		"REMOVED_FILTER_ONLY":    2,
+	}
+)
+
+func (x RemovedFilter) Enum() *RemovedFilter {
+	p := new(RemovedFilter)
+	*p = x
+	return p
+}
+
+func (x RemovedFilter) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RemovedFilter) Descriptor() protoreflect.EnumDescriptor {
+	return file_reviews_proto_enumTypes[2].Descriptor()
+}
+
+func (RemovedFilter) Type() protoreflect.EnumType {
+	return &file_reviews_proto_enumTypes[2]
+}
+
+func (x RemovedFilter) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RemovedFilter.Descriptor instead.
+func (RemovedFilter) EnumDescriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{2}
+}
+
+type ReviewOrder int32
+
+const (
+	ReviewOrder_REVIEW_ORDER_NEWEST        ReviewOrder = 0
+	ReviewOrder_REVIEW_ORDER_OLDEST        ReviewOrder = 1
+	ReviewOrder_REVIEW_ORDER_MOST_NEGATIVE ReviewOrder = 2
+	ReviewOrder_REVIEW_ORDER_MOST_POSITIVE ReviewOrder = 3
+)
+
+// Enum value maps for ReviewOrder.
+var (
+	ReviewOrder_name = map[int32]string{
+		0: "REVIEW_ORDER_NEWEST",
+		1: "REVIEW_ORDER_OLDEST",
+		2: "REVIEW_ORDER_MOST_NEGATIVE",
+		3: "REVIEW_ORDER_MOST_POSITIVE",
+	}
+	ReviewOrder_value = map[string]int32{
+		"REVIEW_ORDER_NEWEST":        0,
+		"REVIEW_ORDER_OLDEST":        1,
+		"REVIEW_ORDER_MOST_NEGATIVE": 2,
+		"REVIEW_ORDER_MOST_POSITIVE": 3,
+	}
+)
+
+func (x ReviewOrder) Enum() *ReviewOrder {
+	p := new(ReviewOrder)
+	*p = x
+	return p
+}
+
+func (x ReviewOrder) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ReviewOrder) Descriptor() protoreflect.EnumDescriptor {
+	return file_reviews_proto_enumTypes[3].Descriptor()
+}
+
+func (ReviewOrder) Type() protoreflect.EnumType {
+	return &file_reviews_proto_enumTypes[3]
+}
+
+func (x ReviewOrder) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ReviewOrder.Descriptor instead.
+func (ReviewOrder) EnumDescriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{3}
+}
+
+type ManageTrackedAppsRequest_Action int32
+
+const (
+	ManageTrackedAppsRequest_ACTION_LIST   ManageTrackedAppsRequest_Action = 0
+	ManageTrackedAppsRequest_ACTION_ADD    ManageTrackedAppsRequest_Action = 1
+	ManageTrackedAppsRequest_ACTION_REMOVE ManageTrackedAppsRequest_Action = 2
+)
+
+// Enum value maps for ManageTrackedAppsRequest_Action.
+var (
+	ManageTrackedAppsRequest_Action_name = map[int32]string{
+		0: "ACTION_LIST",
+		1: "ACTION_ADD",
+		2: "ACTION_REMOVE",
+	}
+	ManageTrackedAppsRequest_Action_value = map[string]int32{
+		"ACTION_LIST":   0,
+		"ACTION_ADD":    1,
+		"ACTION_REMOVE": 2,
+	}
+)
+
+func (x ManageTrackedAppsRequest_Action) Enum() *ManageTrackedAppsRequest_Action {
+	p := new(ManageTrackedAppsRequest_Action)
+	*p = x
+	return p
+}
+
+func (x ManageTrackedAppsRequest_Action) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ManageTrackedAppsRequest_Action) Descriptor() protoreflect.EnumDescriptor {
+	return file_reviews_proto_enumTypes[4].Descriptor()
+}
+
+func (ManageTrackedAppsRequest_Action) Type() protoreflect.EnumType {
+	return &file_reviews_proto_enumTypes[4]
+}
+
+func (x ManageTrackedAppsRequest_Action) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ManageTrackedAppsRequest_Action.Descriptor instead.
+func (ManageTrackedAppsRequest_Action) EnumDescriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{11, 0}
+}
+
+type Author struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Uri           string                 `protobuf:"bytes,2,opt,name=uri,proto3" json:"uri,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Author) Reset() {
+	*x = Author{}
+	mi := &file_reviews_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Author) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Author) ProtoMessage() {}
+
+func (x *Author) ProtoReflect() protoreflect.Message {
+	mi := &file_reviews_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Author.ProtoReflect.Descriptor instead.
+func (*Author) Descriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Author) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Author) GetUri() string {
+	if x != nil {
+		return x.Uri
+	}
+	return ""
+}
+
+type AppReview struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Author  *Author                `protobuf:"bytes,1,opt,name=author,proto3" json:"author,omitempty"`
+	Updated *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=updated,proto3" json:"updated,omitempty"`
+	Rating  int32                  `protobuf:"varint,3,opt,name=rating,proto3" json:"rating,omitempty"`
+	Version string                 `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	Id      string                 `protobuf:"bytes,5,opt,name=id,proto3" json:"id,omitempty"`
+	Title   string                 `protobuf:"bytes,6,opt,name=title,proto3" json:"title,omitempty"`
+	Content string                 `protobuf:"bytes,7,opt,name=content,proto3" json:"content,omitempty"`
+	Link    string                 `protobuf:"bytes,8,opt,name=link,proto3" json:"link,omitempty"`
+	// Sentiment of the title and content from -1 (negative) to 1 (positive)
+	Sentiment float64 `protobuf:"fixed64,9,opt,name=sentiment,proto3" json:"sentiment,omitempty"`
+	// Tags assigned by the server's tag rules, such as crash or billing
+	Tags []string `protobuf:"bytes,10,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Language detected from the title and content, such as en or ja, or und when it could not be identified
+	Language string `protobuf:"bytes,11,opt,name=language,proto3" json:"language,omitempty"`
+	// Why the review looks like spam: duplicate, repeated-text or author-burst. Empty when it doesn't.
+	Spam []string `protobuf:"bytes,12,rep,name=spam,proto3" json:"spam,omitempty"`
+	// Earlier versions the author has since edited, oldest first
+	Revisions []*Revision `protobuf:"bytes,13,rep,name=revisions,proto3" json:"revisions,omitempty"`
+	// When the review was found missing from Apple's feed, unset while it is in the feed
+	RemovedAt     *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=removed_at,json=removedAt,proto3" json:"removed_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AppReview) Reset() {
+	*x = AppReview{}
+	mi := &file_reviews_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AppReview) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AppReview) ProtoMessage() {}
+
+func (x *AppReview) ProtoReflect() protoreflect.Message {
+	mi := &file_reviews_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AppReview.ProtoReflect.Descriptor instead.
+func (*AppReview) Descriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *AppReview) GetAuthor() *Author {
+	if x != nil {
+		return x.Author
+	}
+	return nil
+}
+
+func (x *AppReview) GetUpdated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Updated
+	}
+	return nil
+}
+
+func (x *AppReview) GetRating() int32 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+func (x *AppReview) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *AppReview) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AppReview) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *AppReview) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *AppReview) GetLink() string {
+	if x != nil {
+		return x.Link
+	}
+	return ""
+}
+
+func (x *AppReview) GetSentiment() float64 {
+	if x != nil {
+		return x.Sentiment
+	}
+	return 0
+}
+
+func (x *AppReview) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *AppReview) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *AppReview) GetSpam() []string {
+	if x != nil {
+		return x.Spam
+	}
+	return nil
+}
+
+func (x *AppReview) GetRevisions() []*Revision {
+	if x != nil {
+		return x.Revisions
+	}
+	return nil
+}
+
+func (x *AppReview) GetRemovedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RemovedAt
+	}
+	return nil
+}
+
+// Revision is a version of a review its author has since edited
+type Revision struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Updated       *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=updated,proto3" json:"updated,omitempty"`
+	Rating        int32                  `protobuf:"varint,2,opt,name=rating,proto3" json:"rating,omitempty"`
+	Version       string                 `protobuf:"bytes,3,opt,name=version,proto3" json:"version,omitempty"`
+	Title         string                 `protobuf:"bytes,4,opt,name=title,proto3" json:"title,omitempty"`
+	Content       string                 `protobuf:"bytes,5,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Revision) Reset() {
+	*x = Revision{}
+	mi := &file_reviews_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Revision) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Revision) ProtoMessage() {}
+
+func (x *Revision) ProtoReflect() protoreflect.Message {
+	mi := &file_reviews_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Revision.ProtoReflect.Descriptor instead.
+func (*Revision) Descriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Revision) GetUpdated() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Updated
+	}
+	return nil
+}
+
+func (x *Revision) GetRating() int32 {
+	if x != nil {
+		return x.Rating
+	}
+	return 0
+}
+
+func (x *Revision) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *Revision) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Revision) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+// ReviewFilter matches the rating, q, sentiment, tag, language and spam query parameters of the HTTP API
+type ReviewFilter struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Ratings    []int32                `protobuf:"varint,1,rep,packed,name=ratings,proto3" json:"ratings,omitempty"`
+	Keyword    string                 `protobuf:"bytes,2,opt,name=keyword,proto3" json:"keyword,omitempty"`
+	Sentiments []Sentiment            `protobuf:"varint,3,rep,packed,name=sentiments,proto3,enum=appreviews.v1.Sentiment" json:"sentiments,omitempty"`
+	// Reviews with any of these tags
+	Tags []string `protobuf:"bytes,4,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Reviews in any of these languages, such as en or und
+	Languages     []string      `protobuf:"bytes,5,rep,name=languages,proto3" json:"languages,omitempty"`
+	Spam          SpamFilter    `protobuf:"varint,6,opt,name=spam,proto3,enum=appreviews.v1.SpamFilter" json:"spam,omitempty"`
+	Removed       RemovedFilter `protobuf:"varint,7,opt,name=removed,proto3,enum=appreviews.v1.RemovedFilter" json:"removed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReviewFilter) Reset() {
+	*x = ReviewFilter{}
+	mi := &file_reviews_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReviewFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReviewFilter) ProtoMessage() {}
+
+func (x *ReviewFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_reviews_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReviewFilter.ProtoReflect.Descriptor instead.
+func (*ReviewFilter) Descriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ReviewFilter) GetRatings() []int32 {
+	if x != nil {
+		return x.Ratings
+	}
+	return nil
+}
+
+func (x *ReviewFilter) GetKeyword() string {
+	if x != nil {
+		return x.Keyword
+	}
+	return ""
+}
+
+func (x *ReviewFilter) GetSentiments() []Sentiment {
+	if x != nil {
+		return x.Sentiments
+	}
+	return nil
+}
+
+func (x *ReviewFilter) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *ReviewFilter) GetLanguages() []string {
+	if x != nil {
+		return x.Languages
+	}
+	return nil
+}
+
+func (x *ReviewFilter) GetSpam() SpamFilter {
+	if x != nil {
+		return x.Spam
+	}
+	return SpamFilter_SPAM_FILTER_INCLUDE
+}
+
+func (x *ReviewFilter) GetRemoved() RemovedFilter {
+	if x != nil {
+		return x.Removed
+	}
+	return RemovedFilter_REMOVED_FILTER_EXCLUDE
+}
+
+type GetReviewsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	AppId string                 `protobuf:"bytes,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	// Only include reviews updated within this many hours. Defaults to 48.
+	Hours         int32         `protobuf:"varint,2,opt,name=hours,proto3" json:"hours,omitempty"`
+	Filter        *ReviewFilter `protobuf:"bytes,3,opt,name=filter,proto3" json:"filter,omitempty"`
+	Order         ReviewOrder   `protobuf:"varint,4,opt,name=order,proto3,enum=appreviews.v1.ReviewOrder" json:"order,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReviewsRequest) Reset() {
+	*x = GetReviewsRequest{}
+	mi := &file_reviews_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReviewsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReviewsRequest) ProtoMessage() {}
+
+func (x *GetReviewsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_reviews_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReviewsRequest.ProtoReflect.Descriptor instead.
+func (*GetReviewsRequest) Descriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetReviewsRequest) GetAppId() string {
+	if x != nil {
+		return x.AppId
+	}
+	return ""
+}
+
+func (x *GetReviewsRequest) GetHours() int32 {
+	if x != nil {
+		return x.Hours
+	}
+	return 0
+}
+
+func (x *GetReviewsRequest) GetFilter() *ReviewFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *GetReviewsRequest) GetOrder() ReviewOrder {
+	if x != nil {
+		return x.Order
+	}
+	return ReviewOrder_REVIEW_ORDER_NEWEST
+}
+
+type GetReviewsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reviews       []*AppReview           `protobuf:"bytes,1,rep,name=reviews,proto3" json:"reviews,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetReviewsResponse) Reset() {
+	*x = GetReviewsResponse{}
+	mi := &file_reviews_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetReviewsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetReviewsResponse) ProtoMessage() {}
+
+func (x *GetReviewsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_reviews_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetReviewsResponse.ProtoReflect.Descriptor instead.
+func (*GetReviewsResponse) Descriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetReviewsResponse) GetReviews() []*AppReview {
+	if x != nil {
+		return x.Reviews
+	}
+	return nil
+}
+
+type StreamNewReviewsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	AppId  string                 `protobuf:"bytes,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	Filter *ReviewFilter          `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Also send cached reviews updated after this time. Defaults to when the stream starts.
+	Since         *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=since,proto3" json:"since,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamNewReviewsRequest) Reset() {
+	*x = StreamNewReviewsRequest{}
+	mi := &file_reviews_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamNewReviewsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamNewReviewsRequest) ProtoMessage() {}
+
+func (x *StreamNewReviewsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_reviews_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamNewReviewsRequest.ProtoReflect.Descriptor instead.
+func (*StreamNewReviewsRequest) Descriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StreamNewReviewsRequest) GetAppId() string {
+	if x != nil {
+		return x.AppId
+	}
+	return ""
+}
+
+func (x *StreamNewReviewsRequest) GetFilter() *ReviewFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *StreamNewReviewsRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+type GetStatsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	AppId string                 `protobuf:"bytes,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	// Only include reviews updated within this many hours. Defaults to 48.
+	Hours         int32         `protobuf:"varint,2,opt,name=hours,proto3" json:"hours,omitempty"`
+	Filter        *ReviewFilter `protobuf:"bytes,3,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsRequest) Reset() {
+	*x = GetStatsRequest{}
+	mi := &file_reviews_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsRequest) ProtoMessage() {}
+
+func (x *GetStatsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_reviews_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsRequest.ProtoReflect.Descriptor instead.
+func (*GetStatsRequest) Descriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetStatsRequest) GetAppId() string {
+	if x != nil {
+		return x.AppId
+	}
+	return ""
+}
+
+func (x *GetStatsRequest) GetHours() int32 {
+	if x != nil {
+		return x.Hours
+	}
+	return 0
+}
+
+func (x *GetStatsRequest) GetFilter() *ReviewFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type VersionStats struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	AverageRating float64                `protobuf:"fixed64,3,opt,name=average_rating,json=averageRating,proto3" json:"average_rating,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VersionStats) Reset() {
+	*x = VersionStats{}
+	mi := &file_reviews_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VersionStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VersionStats) ProtoMessage() {}
+
+func (x *VersionStats) ProtoReflect() protoreflect.Message {
+	mi := &file_reviews_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VersionStats.ProtoReflect.Descriptor instead.
+func (*VersionStats) Descriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *VersionStats) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *VersionStats) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *VersionStats) GetAverageRating() float64 {
+	if x != nil {
+		return x.AverageRating
+	}
+	return 0
+}
+
+// SentimentPeriod summarizes the sentiment of the reviews updated during one UTC day
+type SentimentPeriod struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Start            *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=start,proto3" json:"start,omitempty"`
+	Count            int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	AverageSentiment float64                `protobuf:"fixed64,3,opt,name=average_sentiment,json=averageSentiment,proto3" json:"average_sentiment,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *SentimentPeriod) Reset() {
+	*x = SentimentPeriod{}
+	mi := &file_reviews_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SentimentPeriod) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SentimentPeriod) ProtoMessage() {}
+
+func (x *SentimentPeriod) ProtoReflect() protoreflect.Message {
+	mi := &file_reviews_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SentimentPeriod.ProtoReflect.Descriptor instead.
+func (*SentimentPeriod) Descriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SentimentPeriod) GetStart() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Start
+	}
+	return nil
+}
+
+func (x *SentimentPeriod) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *SentimentPeriod) GetAverageSentiment() float64 {
+	if x != nil {
+		return x.AverageSentiment
+	}
+	return 0
+}
+
+type GetStatsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         int32                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	AverageRating float64                `protobuf:"fixed64,2,opt,name=average_rating,json=averageRating,proto3" json:"average_rating,omitempty"`
+	// Number of reviews by star rating
+	Histogram map[int32]int32 `protobuf:"bytes,3,rep,name=histogram,proto3" json:"histogram,omitempty" protobuf_key:"varint,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// Newest version first
+	Versions         []*VersionStats `protobuf:"bytes,4,rep,name=versions,proto3" json:"versions,omitempty"`
+	AverageSentiment float64         `protobuf:"fixed64,5,opt,name=average_sentiment,json=averageSentiment,proto3" json:"average_sentiment,omitempty"`
+	// Number of reviews by sentiment label: negative, neutral or positive
+	Sentiments map[string]int32 `protobuf:"bytes,6,rep,name=sentiments,proto3" json:"sentiments,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// Oldest day first
+	SentimentOverTime []*SentimentPeriod `protobuf:"bytes,7,rep,name=sentiment_over_time,json=sentimentOverTime,proto3" json:"sentiment_over_time,omitempty"`
+	// Number of reviews by detected language, with und for reviews whose language could not be identified
+	Languages map[string]int32 `protobuf:"bytes,8,rep,name=languages,proto3" json:"languages,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	// Number of reviews suspected to be spam
+	Spam          int32 `protobuf:"varint,9,opt,name=spam,proto3" json:"spam,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatsResponse) Reset() {
+	*x = GetStatsResponse{}
+	mi := &file_reviews_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatsResponse) ProtoMessage() {}
+
+func (x *GetStatsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_reviews_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatsResponse.ProtoReflect.Descriptor instead.
+func (*GetStatsResponse) Descriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *GetStatsResponse) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetAverageRating() float64 {
+	if x != nil {
+		return x.AverageRating
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetHistogram() map[int32]int32 {
+	if x != nil {
+		return x.Histogram
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetVersions() []*VersionStats {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetAverageSentiment() float64 {
+	if x != nil {
+		return x.AverageSentiment
+	}
+	return 0
+}
+
+func (x *GetStatsResponse) GetSentiments() map[string]int32 {
+	if x != nil {
+		return x.Sentiments
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetSentimentOverTime() []*SentimentPeriod {
+	if x != nil {
+		return x.SentimentOverTime
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetLanguages() map[string]int32 {
+	if x != nil {
+		return x.Languages
+	}
+	return nil
+}
+
+func (x *GetStatsResponse) GetSpam() int32 {
+	if x != nil {
+		return x.Spam
+	}
+	return 0
+}
+
+type ManageTrackedAppsRequest struct {
+	state  protoimpl.MessageState          `protogen:"open.v1"`
+	Action ManageTrackedAppsRequest_Action `protobuf:"varint,1,opt,name=action,proto3,enum=appreviews.v1.ManageTrackedAppsRequest_Action" json:"action,omitempty"`
+	// The app to add or remove
+	AppId         string `protobuf:"bytes,2,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ManageTrackedAppsRequest) Reset() {
+	*x = ManageTrackedAppsRequest{}
+	mi := &file_reviews_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ManageTrackedAppsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ManageTrackedAppsRequest) ProtoMessage() {}
+
+func (x *ManageTrackedAppsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_reviews_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ManageTrackedAppsRequest.ProtoReflect.Descriptor instead.
+func (*ManageTrackedAppsRequest) Descriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ManageTrackedAppsRequest) GetAction() ManageTrackedAppsRequest_Action {
+	if x != nil {
+		return x.Action
+	}
+	return ManageTrackedAppsRequest_ACTION_LIST
+}
+
+func (x *ManageTrackedAppsRequest) GetAppId() string {
+	if x != nil {
+		return x.AppId
+	}
+	return ""
+}
+
+type TrackedApp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AppId         string                 `protobuf:"bytes,1,opt,name=app_id,json=appId,proto3" json:"app_id,omitempty"`
+	CacheModified *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=cache_modified,json=cacheModified,proto3" json:"cache_modified,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TrackedApp) Reset() {
+	*x = TrackedApp{}
+	mi := &file_reviews_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TrackedApp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TrackedApp) ProtoMessage() {}
+
+func (x *TrackedApp) ProtoReflect() protoreflect.Message {
+	mi := &file_reviews_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TrackedApp.ProtoReflect.Descriptor instead.
+func (*TrackedApp) Descriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TrackedApp) GetAppId() string {
+	if x != nil {
+		return x.AppId
+	}
+	return ""
+}
+
+func (x *TrackedApp) GetCacheModified() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CacheModified
+	}
+	return nil
+}
+
+type ManageTrackedAppsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Every tracked app after the action
+	Apps          []*TrackedApp `protobuf:"bytes,1,rep,name=apps,proto3" json:"apps,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ManageTrackedAppsResponse) Reset() {
+	*x = ManageTrackedAppsResponse{}
+	mi := &file_reviews_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ManageTrackedAppsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ManageTrackedAppsResponse) ProtoMessage() {}
+
+func (x *ManageTrackedAppsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_reviews_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ManageTrackedAppsResponse.ProtoReflect.Descriptor instead.
+func (*ManageTrackedAppsResponse) Descriptor() ([]byte, []int) {
+	return file_reviews_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ManageTrackedAppsResponse) GetApps() []*TrackedApp {
+	if x != nil {
+		return x.Apps
+	}
+	return nil
+}
+
+var File_reviews_proto protoreflect.FileDescriptor
+
+const file_reviews_proto_rawDesc = "" +
+	"\n" +
+	"\rreviews.proto\x12\rappreviews.v1\x1a\x1fgoogle/protobuf/timestamp.proto\".\n" +
+	"\x06Author\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x10\n" +
+	"\x03uri\x18\x02 \x01(\tR\x03uri\"\xca\x03\n" +
+	"\tAppReview\x12-\n" +
+	"\x06author\x18\x01 \x01(\v2\x15.appreviews.v1.AuthorR\x06author\x124\n" +
+	"\aupdated\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\aupdated\x12\x16\n" +
+	"\x06rating\x18\x03 \x01(\x05R\x06rating\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\tR\aversion\x12\x0e\n" +
+	"\x02id\x18\x05 \x01(\tR\x02id\x12\x14\n" +
+	"\x05title\x18\x06 \x01(\tR\x05title\x12\x18\n" +
+	"\acontent\x18\a \x01(\tR\acontent\x12\x12\n" +
+	"\x04link\x18\b \x01(\tR\x04link\x12\x1c\n" +
+	"\tsentiment\x18\t \x01(\x01R\tsentiment\x12\x12\n" +
+	"\x04tags\x18\n" +
+	" \x03(\tR\x04tags\x12\x1a\n" +
+	"\blanguage\x18\v \x01(\tR\blanguage\x12\x12\n" +
+	"\x04spam\x18\f \x03(\tR\x04spam\x125\n" +
+	"\trevisions\x18\r \x03(\v2\x17.appreviews.v1.RevisionR\trevisions\x129\n" +
+	"\n" +
+	"removed_at\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\tremovedAt\"\xa2\x01\n" +
+	"\bRevision\x124\n" +
+	"\aupdated\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\aupdated\x12\x16\n" +
+	"\x06rating\x18\x02 \x01(\x05R\x06rating\x12\x18\n" +
+	"\aversion\x18\x03 \x01(\tR\aversion\x12\x14\n" +
+	"\x05title\x18\x04 \x01(\tR\x05title\x12\x18\n" +
+	"\acontent\x18\x05 \x01(\tR\acontent\"\x95\x02\n" +
+	"\fReviewFilter\x12\x18\n" +
+	"\aratings\x18\x01 \x03(\x05R\aratings\x12\x18\n" +
+	"\akeyword\x18\x02 \x01(\tR\akeyword\x128\n" +
+	"\n" +
+	"sentiments\x18\x03 \x03(\x0e2\x18.appreviews.v1.SentimentR\n" +
+	"sentiments\x12\x12\n" +
+	"\x04tags\x18\x04 \x03(\tR\x04tags\x12\x1c\n" +
+	"\tlanguages\x18\x05 \x03(\tR\tlanguages\x12-\n" +
+	"\x04spam\x18\x06 \x01(\x0e2\x19.appreviews.v1.SpamFilterR\x04spam\x126\n" +
+	"\aremoved\x18\a \x01(\x0e2\x1c.appreviews.v1.RemovedFilterR\aremoved\"\xa7\x01\n" +
+	"\x11GetReviewsRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\tR\x05appId\x12\x14\n" +
+	"\x05hours\x18\x02 \x01(\x05R\x05hours\x123\n" +
+	"\x06filter\x18\x03 \x01(\v2\x1b.appreviews.v1.ReviewFilterR\x06filter\x120\n" +
+	"\x05order\x18\x04 \x01(\x0e2\x1a.appreviews.v1.ReviewOrderR\x05order\"H\n" +
+	"\x12GetReviewsResponse\x122\n" +
+	"\areviews\x18\x01 \x03(\v2\x18.appreviews.v1.AppReviewR\areviews\"\x97\x01\n" +
+	"\x17StreamNewReviewsRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\tR\x05appId\x123\n" +
+	"\x06filter\x18\x02 \x01(\v2\x1b.appreviews.v1.ReviewFilterR\x06filter\x120\n" +
+	"\x05since\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\"s\n" +
+	"\x0fGetStatsRequest\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\tR\x05appId\x12\x14\n" +
+	"\x05hours\x18\x02 \x01(\x05R\x05hours\x123\n" +
+	"\x06filter\x18\x03 \x01(\v2\x1b.appreviews.v1.ReviewFilterR\x06filter\"e\n" +
+	"\fVersionStats\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\x12%\n" +
+	"\x0eaverage_rating\x18\x03 \x01(\x01R\raverageRating\"\x86\x01\n" +
+	"\x0fSentimentPeriod\x120\n" +
+	"\x05start\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05start\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\x12+\n" +
+	"\x11average_sentiment\x18\x03 \x01(\x01R\x10averageSentiment\"\xc1\x05\n" +
+	"\x10GetStatsResponse\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x05R\x05count\x12%\n" +
+	"\x0eaverage_rating\x18\x02 \x01(\x01R\raverageRating\x12L\n" +
+	"\thistogram\x18\x03 \x03(\v2..appreviews.v1.GetStatsResponse.HistogramEntryR\thistogram\x127\n" +
+	"\bversions\x18\x04 \x03(\v2\x1b.appreviews.v1.VersionStatsR\bversions\x12+\n" +
+	"\x11average_sentiment\x18\x05 \x01(\x01R\x10averageSentiment\x12O\n" +
+	"\n" +
+	"sentiments\x18\x06 \x03(\v2/.appreviews.v1.GetStatsResponse.SentimentsEntryR\n" +
+	"sentiments\x12N\n" +
+	"\x13sentiment_over_time\x18\a \x03(\v2\x1e.appreviews.v1.SentimentPeriodR\x11sentimentOverTime\x12L\n" +
+	"\tlanguages\x18\b \x03(\v2..appreviews.v1.GetStatsResponse.LanguagesEntryR\tlanguages\x12\x12\n" +
+	"\x04spam\x18\t \x01(\x05R\x04spam\x1a<\n" +
+	"\x0eHistogramEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\x05R\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\x1a=\n" +
+	"\x0fSentimentsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\x1a<\n" +
+	"\x0eLanguagesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x05R\x05value:\x028\x01\"\xb7\x01\n" +
+	"\x18ManageTrackedAppsRequest\x12F\n" +
+	"\x06action\x18\x01 \x01(\x0e2..appreviews.v1.ManageTrackedAppsRequest.ActionR\x06action\x12\x15\n" +
+	"\x06app_id\x18\x02 \x01(\tR\x05appId\"<\n" +
+	"\x06Action\x12\x0f\n" +
+	"\vACTION_LIST\x10\x00\x12\x0e\n" +
+	"\n" +
+	"ACTION_ADD\x10\x01\x12\x11\n" +
+	"\rACTION_REMOVE\x10\x02\"f\n" +
+	"\n" +
+	"TrackedApp\x12\x15\n" +
+	"\x06app_id\x18\x01 \x01(\tR\x05appId\x12A\n" +
+	"\x0ecache_modified\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\rcacheModified\"J\n" +
+	"\x19ManageTrackedAppsResponse\x12-\n" +
+	"\x04apps\x18\x01 \x03(\v2\x19.appreviews.v1.TrackedAppR\x04apps*m\n" +
+	"\tSentiment\x12\x19\n" +
+	"\x15SENTIMENT_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12SENTIMENT_NEGATIVE\x10\x01\x12\x15\n" +
+	"\x11SENTIMENT_NEUTRAL\x10\x02\x12\x16\n" +
+	"\x12SENTIMENT_POSITIVE\x10\x03*T\n" +
+	"\n" +
+	"SpamFilter\x12\x17\n" +
+	"\x13SPAM_FILTER_INCLUDE\x10\x00\x12\x17\n" +
+	"\x13SPAM_FILTER_EXCLUDE\x10\x01\x12\x14\n" +
+	"\x10SPAM_FILTER_ONLY\x10\x02*`\n" +
+	"\rRemovedFilter\x12\x1a\n" +
+	"\x16REMOVED_FILTER_EXCLUDE\x10\x00\x12\x1a\n" +
+	"\x16REMOVED_FILTER_INCLUDE\x10\x01\x12\x17\n" +
+	"\x13REMOVED_FILTER_ONLY\x10\x02*\x7f\n" +
+	"\vReviewOrder\x12\x17\n" +
+	"\x13REVIEW_ORDER_NEWEST\x10\x00\x12\x17\n" +
+	"\x13REVIEW_ORDER_OLDEST\x10\x01\x12\x1e\n" +
+	"\x1aREVIEW_ORDER_MOST_NEGATIVE\x10\x02\x12\x1e\n" +
+	"\x1aREVIEW_ORDER_MOST_POSITIVE\x10\x032\xe9\x02\n" +
+	"\aReviews\x12Q\n" +
+	"\n" +
+	"GetReviews\x12 .appreviews.v1.GetReviewsRequest\x1a!.appreviews.v1.GetReviewsResponse\x12V\n" +
+	"\x10StreamNewReviews\x12&.appreviews.v1.StreamNewReviewsRequest\x1a\x18.appreviews.v1.AppReview0\x01\x12K\n" +
+	"\bGetStats\x12\x1e.appreviews.v1.GetStatsRequest\x1a\x1f.appreviews.v1.GetStatsResponse\x12f\n" +
+	"\x11ManageTrackedApps\x12'.appreviews.v1.ManageTrackedAppsRequest\x1a(.appreviews.v1.ManageTrackedAppsResponseB/Z-github.com/marcuswu/app-reviews/rpc/reviewspbb\x06proto3"
+
+var (
+	file_reviews_proto_rawDescOnce sync.Once
+	file_reviews_proto_rawDescData []byte
+)
+
+func file_reviews_proto_rawDescGZIP() []byte {
+	file_reviews_proto_rawDescOnce.Do(func() {
+		file_reviews_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_reviews_proto_rawDesc), len(file_reviews_proto_rawDesc)))
+	})
+	return file_reviews_proto_rawDescData
+}
+
+var file_reviews_proto_enumTypes = make([]protoimpl.EnumInfo, 5)
+var file_reviews_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_reviews_proto_goTypes = []any{
+	(Sentiment)(0),                       // 0: appreviews.v1.Sentiment
+	(SpamFilter)(0),                      // 1: appreviews.v1.SpamFilter
+	(RemovedFilter)(0),                   // 2: appreviews.v1.RemovedFilter
+	(ReviewOrder)(0),                     // 3: appreviews.v1.ReviewOrder
+	(ManageTrackedAppsRequest_Action)(0), // 4: appreviews.v1.ManageTrackedAppsRequest.Action
+	(*Author)(nil),                       // 5: appreviews.v1.Author
+	(*AppReview)(nil),                    // 6: appreviews.v1.AppReview
+	(*Revision)(nil),                     // 7: appreviews.v1.Revision
+	(*ReviewFilter)(nil),                 // 8: appreviews.v1.ReviewFilter
+	(*GetReviewsRequest)(nil),            // 9: appreviews.v1.GetReviewsRequest
+	(*GetReviewsResponse)(nil),           // 10: appreviews.v1.GetReviewsResponse
+	(*StreamNewReviewsRequest)(nil),      // 11: appreviews.v1.StreamNewReviewsRequest
+	(*GetStatsRequest)(nil),              // 12: appreviews.v1.GetStatsRequest
+	(*VersionStats)(nil),                 // 13: appreviews.v1.VersionStats
+	(*SentimentPeriod)(nil),              // 14: appreviews.v1.SentimentPeriod
+	(*GetStatsResponse)(nil),             // 15: appreviews.v1.GetStatsResponse
+	(*ManageTrackedAppsRequest)(nil),     // 16: appreviews.v1.ManageTrackedAppsRequest
+	(*TrackedApp)(nil),                   // 17: appreviews.v1.TrackedApp
+	(*ManageTrackedAppsResponse)(nil),    // 18: appreviews.v1.ManageTrackedAppsResponse
+	nil,                                  // 19: appreviews.v1.GetStatsResponse.HistogramEntry
+	nil,                                  // 20: appreviews.v1.GetStatsResponse.SentimentsEntry
+	nil,                                  // 21: appreviews.v1.GetStatsResponse.LanguagesEntry
+	(*timestamppb.Timestamp)(nil),        // 22: google.protobuf.Timestamp
+}
+var file_reviews_proto_depIdxs = []int32{
+	5,  // 0: appreviews.v1.AppReview.author:type_name -> appreviews.v1.Author
+	22, // 1: appreviews.v1.AppReview.updated:type_name -> google.protobuf.Timestamp
+	7,  // 2: appreviews.v1.AppReview.revisions:type_name -> appreviews.v1.Revision
+	22, // 3: appreviews.v1.AppReview.removed_at:type_name -> google.protobuf.Timestamp
+	22, // 4: appreviews.v1.Revision.updated:type_name -> google.protobuf.Timestamp
+	0,  // 5: appreviews.v1.ReviewFilter.sentiments:type_name -> appreviews.v1.Sentiment
+	1,  // 6: appreviews.v1.ReviewFilter.spam:type_name -> appreviews.v1.SpamFilter
+	2,  // 7: appreviews.v1.ReviewFilter.removed:type_name -> appreviews.v1.RemovedFilter
+	8,  // 8: appreviews.v1.GetReviewsRequest.filter:type_name -> appreviews.v1.ReviewFilter
+	3,  // 9: appreviews.v1.GetReviewsRequest.order:type_name -> appreviews.v1.ReviewOrder
+	6,  // 10: appreviews.v1.GetReviewsResponse.reviews:type_name -> appreviews.v1.AppReview
+	8,  // 11: appreviews.v1.StreamNewReviewsRequest.filter:type_name -> appreviews.v1.ReviewFilter
+	22, // 12: appreviews.v1.StreamNewReviewsRequest.since:type_name -> google.protobuf.Timestamp
+	8,  // 13: appreviews.v1.GetStatsRequest.filter:type_name -> appreviews.v1.ReviewFilter
+	22, // 14: appreviews.v1.SentimentPeriod.start:type_name -> google.protobuf.Timestamp
+	19, // 15: appreviews.v1.GetStatsResponse.histogram:type_name -> appreviews.v1.GetStatsResponse.HistogramEntry
+	13, // 16: appreviews.v1.GetStatsResponse.versions:type_name -> appreviews.v1.VersionStats
+	20, // 17: appreviews.v1.GetStatsResponse.sentiments:type_name -> appreviews.v1.GetStatsResponse.SentimentsEntry
+	14, // 18: appreviews.v1.GetStatsResponse.sentiment_over_time:type_name -> appreviews.v1.SentimentPeriod
+	21, // 19: appreviews.v1.GetStatsResponse.languages:type_name -> appreviews.v1.GetStatsResponse.LanguagesEntry
+	4,  // 20: appreviews.v1.ManageTrackedAppsRequest.action:type_name -> appreviews.v1.ManageTrackedAppsRequest.Action
+	22, // 21: appreviews.v1.TrackedApp.cache_modified:type_name -> google.protobuf.Timestamp
+	17, // 22: appreviews.v1.ManageTrackedAppsResponse.apps:type_name -> appreviews.v1.TrackedApp
+	9,  // 23: appreviews.v1.Reviews.GetReviews:input_type -> appreviews.v1.GetReviewsRequest
+	11, // 24: appreviews.v1.Reviews.StreamNewReviews:input_type -> appreviews.v1.StreamNewReviewsRequest
+	12, // 25: appreviews.v1.Reviews.GetStats:input_type -> appreviews.v1.GetStatsRequest
+	16, // 26: appreviews.v1.Reviews.ManageTrackedApps:input_type -> appreviews.v1.ManageTrackedAppsRequest
+	10, // 27: appreviews.v1.Reviews.GetReviews:output_type -> appreviews.v1.GetReviewsResponse
+	6,  // 28: appreviews.v1.Reviews.StreamNewReviews:output_type -> appreviews.v1.AppReview
+	15, // 29: appreviews.v1.Reviews.GetStats:output_type -> appreviews.v1.GetStatsResponse
+	18, // 30: appreviews.v1.Reviews.ManageTrackedApps:output_type -> appreviews.v1.ManageTrackedAppsResponse
+	27, // [27:31] is the sub-list for method output_type
+	23, // [23:27] is the sub-list for method input_type
+	23, // [23:23] is the sub-list for extension type_name
+	23, // [23:23] is the sub-list for extension extendee
+	0,  // [0:23] is the sub-list for field type_name
+}
+
+func init() { file_reviews_proto_init() }
+func file_reviews_proto_init() {
+	if File_reviews_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_reviews_proto_rawDesc), len(file_reviews_proto_rawDesc)),
+			NumEnums:      5,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_reviews_proto_goTypes,
+		DependencyIndexes: file_reviews_proto_depIdxs,
+		EnumInfos:         file_reviews_proto_enumTypes,
+		MessageInfos:      file_reviews_proto_msgTypes,
+	}.Build()
+	File_reviews_proto = out.File
+	file_reviews_proto_goTypes = nil
+	file_reviews_proto_depIdxs = nil
+}
@@ -0,0 +1,249 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: reviews.proto
+
+// Recent App Store reviews, served from the same cache as the HTTP API
+
+package reviewspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Reviews_GetReviews_FullMethodName        = "/appreviews.v1.Reviews/GetReviews"
+	Reviews_StreamNewReviews_FullMethodName  = "/appreviews.v1.Reviews/StreamNewReviews"
+	Reviews_GetStats_FullMethodName          = "/appreviews.v1.Reviews/GetStats"
+	Reviews_ManageTrackedApps_FullMethodName = "/appreviews.v1.Reviews/ManageTrackedApps"
+)
+
+// ReviewsClient is the client API for Reviews service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ReviewsClient interface {
+	// GetReviews returns an app's recent reviews, newest first
+	GetReviews(ctx context.Context, in *GetReviewsRequest, opts ...grpc.CallOption) (*GetReviewsResponse, error)
+	// StreamNewReviews sends reviews as they appear in an app's cache until the client cancels
+	StreamNewReviews(ctx context.Context, in *StreamNewReviewsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AppReview], error)
+	// GetStats summarizes an app's recent reviews
+	GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error)
+	// ManageTrackedApps lists, adds or removes the apps kept in the cache
+	ManageTrackedApps(ctx context.Context, in *ManageTrackedAppsRequest, opts ...grpc.CallOption) (*ManageTrackedAppsResponse, error)
+}
+
+type reviewsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewReviewsClient(cc grpc.ClientConnInterface) ReviewsClient {
+	return &reviewsClient{cc}
+}
+
+func (c *reviewsClient) GetReviews(ctx context.Context, in *GetReviewsRequest, opts ...grpc.CallOption) (*GetReviewsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetReviewsResponse)
+	err := c.cc.Invoke(ctx, Reviews_GetReviews_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reviewsClient) StreamNewReviews(ctx context.Context, in *StreamNewReviewsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[AppReview], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Reviews_ServiceDesc.Streams[0], Reviews_StreamNewReviews_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamNewReviewsRequest, AppReview]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Reviews_StreamNewReviewsClient = grpc.ServerStreamingClient[AppReview]
+
+func (c *reviewsClient) GetStats(ctx context.Context, in *GetStatsRequest, opts ...grpc.CallOption) (*GetStatsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStatsResponse)
+	err := c.cc.Invoke(ctx, Reviews_GetStats_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *reviewsClient) ManageTrackedApps(ctx context.Context, in *ManageTrackedAppsRequest, opts ...grpc.CallOption) (*ManageTrackedAppsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ManageTrackedAppsResponse)
+	err := c.cc.Invoke(ctx, Reviews_ManageTrackedApps_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ReviewsServer is the server API for Reviews service.
+// All implementations must embed UnimplementedReviewsServer
+// for forward compatibility.
+type ReviewsServer interface {
+	// GetReviews returns an app's recent reviews, newest first
+	GetReviews(context.Context, *GetReviewsRequest) (*GetReviewsResponse, error)
+	// StreamNewReviews sends reviews as they appear in an app's cache until the client cancels
+	StreamNewReviews(*StreamNewReviewsRequest, grpc.ServerStreamingServer[AppReview]) error
+	// GetStats summarizes an app's recent reviews
+	GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error)
+	// ManageTrackedApps lists, adds or removes the apps kept in the cache
+	ManageTrackedApps(context.Context, *ManageTrackedAppsRequest) (*ManageTrackedAppsResponse, error)
+	mustEmbedUnimplementedReviewsServer()
+}
+
+// UnimplementedReviewsServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedReviewsServer struct{}
+
+func (UnimplementedReviewsServer) GetReviews(context.Context, *GetReviewsRequest) (*GetReviewsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetReviews not implemented")
+}
+func (UnimplementedReviewsServer) StreamNewReviews(*StreamNewReviewsRequest, grpc.ServerStreamingServer[AppReview]) error {
+	return status.Error(codes.Unimplemented, "method StreamNewReviews not implemented")
+}
+func (UnimplementedReviewsServer) GetStats(context.Context, *GetStatsRequest) (*GetStatsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedReviewsServer) ManageTrackedApps(context.Context, *ManageTrackedAppsRequest) (*ManageTrackedAppsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ManageTrackedApps not implemented")
+}
+func (UnimplementedReviewsServer) mustEmbedUnimplementedReviewsServer() {}
+func (UnimplementedReviewsServer) testEmbeddedByValue()                 {}
+
+// UnsafeReviewsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ReviewsServer will
+// result in compilation errors.
+type UnsafeReviewsServer interface {
+	mustEmbedUnimplementedReviewsServer()
+}
+
+func RegisterReviewsServer(s grpc.ServiceRegistrar, srv ReviewsServer) {
+	// If the following call panics, it indicates UnimplementedReviewsServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Reviews_ServiceDesc, srv)
+}
+
+func _Reviews_GetReviews_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReviewsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReviewsServer).GetReviews(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reviews_GetReviews_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReviewsServer).GetReviews(ctx, req.(*GetReviewsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reviews_StreamNewReviews_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamNewReviewsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ReviewsServer).StreamNewReviews(m, &grpc.GenericServerStream[StreamNewReviewsRequest, AppReview]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Reviews_StreamNewReviewsServer = grpc.ServerStreamingServer[AppReview]
+
+func _Reviews_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReviewsServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reviews_GetStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReviewsServer).GetStats(ctx, req.(*GetStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Reviews_ManageTrackedApps_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ManageTrackedAppsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ReviewsServer).ManageTrackedApps(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Reviews_ManageTrackedApps_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ReviewsServer).ManageTrackedApps(ctx, req.(*ManageTrackedAppsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Reviews_ServiceDesc is the grpc.ServiceDesc for Reviews service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Reviews_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "appreviews.v1.Reviews",
+	HandlerType: (*ReviewsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetReviews",
+			Handler:    _Reviews_GetReviews_Handler,
+		},
+		{
+			MethodName: "GetStats",
+			Handler:    _Reviews_GetStats_Handler,
+		},
+		{
+			MethodName: "ManageTrackedApps",
+			Handler:    _Reviews_ManageTrackedApps_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamNewReviews",
+			Handler:       _Reviews_StreamNewReviews_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "reviews.proto",
+}
@@ -0,0 +1,358 @@
+// Package rpc serves the reviews API over gRPC, sharing the updater and cache with the HTTP handlers.
+//
+// reviewspb is generated from reviewspb/reviews.proto with protoc-gen-go and protoc-gen-go-grpc.
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative reviewspb/reviews.proto
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/marcuswu/app-reviews/clock"
+	"github.com/marcuswu/app-reviews/config"
+	"github.com/marcuswu/app-reviews/language"
+	"github.com/marcuswu/app-reviews/models"
+	"github.com/marcuswu/app-reviews/rpc/reviewspb"
+	"github.com/marcuswu/app-reviews/sentiment"
+	"github.com/marcuswu/app-reviews/tagging"
+	"github.com/marcuswu/app-reviews/updater"
+)
+
+// LoadFunc returns an app's reviews from the cache, fetching and caching them when the cache is missing or stale.
+// Errors that are already gRPC statuses are returned to clients as they are.
+type LoadFunc func(ctx context.Context, appId string) (models.AppReviews, error)
+
+// Server implements the Reviews gRPC service. Create one with NewServer.
+type Server struct {
+	reviewspb.UnimplementedReviewsServer
+	updater      *updater.Updater
+	load         LoadFunc
+	clock        clock.Clock
+	pollInterval time.Duration
+	stopping     <-chan struct{}
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithClock sets the clock streams poll the cache with
+func WithClock(c clock.Clock) Option {
+	return func(s *Server) { s.clock = c }
+}
+
+// WithPollInterval sets how often streams check the cache for new reviews
+func WithPollInterval(interval time.Duration) Option {
+	return func(s *Server) { s.pollInterval = interval }
+}
+
+// WithShutdown ends open streams once ctx is done so the server can stop gracefully
+func WithShutdown(ctx context.Context) Option {
+	return func(s *Server) { s.stopping = ctx.Done() }
+}
+
+// NewServer creates a Server that manages apps in u's store and loads reviews with load
+func NewServer(u *updater.Updater, load LoadFunc, options ...Option) *Server {
+	s := &Server{
+		updater:      u,
+		load:         load,
+		clock:        clock.System,
+		pollInterval: time.Duration(config.STREAM_POLL_SECONDS) * time.Second,
+	}
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+// toProto converts a review to its protobuf message
+func toProto(review models.AppReview) *reviewspb.AppReview {
+	var removedAt *timestamppb.Timestamp
+	if review.Removed() {
+		removedAt = timestamppb.New(*review.RemovedAt)
+	}
+	revisions := make([]*reviewspb.Revision, len(review.Revisions))
+	for i, revision := range review.Revisions {
+		revisions[i] = &reviewspb.Revision{Updated: timestamppb.New(revision.Updated), Rating: int32(revision.Rating),
+			Version: revision.Version, Title: revision.Title, Content: revision.Content}
+	}
+	return &reviewspb.AppReview{
+		Author:    &reviewspb.Author{Name: review.Author.Name, Uri: review.Author.Uri},
+		Updated:   timestamppb.New(review.Updated),
+		Rating:    int32(review.Rating),
+		Version:   review.Version,
+		Id:        review.Id,
+		Title:     review.Title,
+		Content:   review.Content,
+		Link:      review.Link,
+		Sentiment: review.Sentiment,
+		Tags:      review.Tags,
+		Language:  review.LanguageOrUndetermined(),
+		Spam:      review.Spam,
+		Revisions: revisions,
+		RemovedAt: removedAt,
+	}
+}
+
+// sentimentLabels maps protobuf sentiments to labels
+var sentimentLabels = map[reviewspb.Sentiment]sentiment.Label{
+	reviewspb.Sentiment_SENTIMENT_NEGATIVE: sentiment.Negative,
+	reviewspb.Sentiment_SENTIMENT_NEUTRAL:  sentiment.Neutral,
+	reviewspb.Sentiment_SENTIMENT_POSITIVE: sentiment.Positive,
+}
+
+// reviewOrders maps protobuf orders to models.ReviewOrder
+var reviewOrders = map[reviewspb.ReviewOrder]models.ReviewOrder{
+	reviewspb.ReviewOrder_REVIEW_ORDER_NEWEST:        models.OrderNewest,
+	reviewspb.ReviewOrder_REVIEW_ORDER_OLDEST:        models.OrderOldest,
+	reviewspb.ReviewOrder_REVIEW_ORDER_MOST_NEGATIVE: models.OrderMostNegative,
+	reviewspb.ReviewOrder_REVIEW_ORDER_MOST_POSITIVE: models.OrderMostPositive,
+}
+
+// spamFilters maps protobuf spam filters to models.SpamFilter
+var spamFilters = map[reviewspb.SpamFilter]models.SpamFilter{
+	reviewspb.SpamFilter_SPAM_FILTER_INCLUDE: models.SpamInclude,
+	reviewspb.SpamFilter_SPAM_FILTER_EXCLUDE: models.SpamExclude,
+	reviewspb.SpamFilter_SPAM_FILTER_ONLY:    models.SpamOnly,
+}
+
+// removedFilters maps protobuf removed review filters to models.RemovedFilter
+var removedFilters = map[reviewspb.RemovedFilter]models.RemovedFilter{
+	reviewspb.RemovedFilter_REMOVED_FILTER_EXCLUDE: models.RemovedExclude,
+	reviewspb.RemovedFilter_REMOVED_FILTER_INCLUDE: models.RemovedInclude,
+	reviewspb.RemovedFilter_REMOVED_FILTER_ONLY:    models.RemovedOnly,
+}
+
+// reviewFilter converts a protobuf filter, which may be nil, to a models.ReviewFilter
+func reviewFilter(filter *reviewspb.ReviewFilter) (models.ReviewFilter, error) {
+	result := models.ReviewFilter{Keyword: filter.GetKeyword()}
+	for _, rating := range filter.GetRatings() {
+		if rating < 1 || rating > 5 {
+			return result, status.Errorf(codes.InvalidArgument, "invalid rating %d, expected a number from 1 to 5", rating)
+		}
+		result.Ratings = append(result.Ratings, int(rating))
+	}
+	for _, value := range filter.GetSentiments() {
+		label, ok := sentimentLabels[value]
+		if !ok {
+			return result, status.Errorf(codes.InvalidArgument, "invalid sentiment %s", value)
+		}
+		result.Sentiments = append(result.Sentiments, label)
+	}
+	for _, tag := range filter.GetTags() {
+		if tag = tagging.NormalizeTag(tag); len(tag) > 0 {
+			result.Tags = append(result.Tags, tag)
+		}
+	}
+	for _, code := range filter.GetLanguages() {
+		code = strings.ToLower(strings.TrimSpace(code))
+		if err := language.Check(code); err != nil {
+			return result, status.Error(codes.InvalidArgument, err.Error())
+		}
+		result.Languages = append(result.Languages, code)
+	}
+	spamFilter, ok := spamFilters[filter.GetSpam()]
+	if !ok {
+		return result, status.Errorf(codes.InvalidArgument, "invalid spam filter %s", filter.GetSpam())
+	}
+	result.Spam = spamFilter
+	removedFilter, ok := removedFilters[filter.GetRemoved()]
+	if !ok {
+		return result, status.Errorf(codes.InvalidArgument, "invalid removed filter %s", filter.GetRemoved())
+	}
+	result.Removed = removedFilter
+	return result, nil
+}
+
+// window returns the requested hours as a duration, defaulting to config.OLDEST_REVIEW_HOURS
+func window(hours int32) time.Duration {
+	if hours < 1 {
+		hours = config.OLDEST_REVIEW_HOURS
+	}
+	return time.Duration(hours) * time.Hour
+}
+
+func checkAppId(appId string) error {
+	if len(appId) < 1 {
+		return status.Error(codes.InvalidArgument, "app_id is required")
+	}
+	if !models.ValidAppId(appId) {
+		return status.Errorf(codes.InvalidArgument, "invalid app_id %q, expected digits", appId)
+	}
+	return nil
+}
+
+// loadError converts an error loading reviews to a gRPC status
+func loadError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	switch {
+	case errors.Is(err, updater.ErrNoFeed):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(err).Err()
+	}
+	return status.Errorf(codes.Unavailable, "failed to fetch app reviews: %s", err)
+}
+
+// filteredReviews loads an app's reviews and applies a filter
+func (s *Server) filteredReviews(ctx context.Context, appId string, filter *reviewspb.ReviewFilter) (models.AppReviews, error) {
+	if err := checkAppId(appId); err != nil {
+		return nil, err
+	}
+	reviewFilter, err := reviewFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := s.load(ctx, appId)
+	if err != nil {
+		return nil, loadError(err)
+	}
+	return reviews.Filter(reviewFilter), nil
+}
+
+// GetReviews returns an app's recent reviews in the requested order, newest first by default
+func (s *Server) GetReviews(ctx context.Context, req *reviewspb.GetReviewsRequest) (*reviewspb.GetReviewsResponse, error) {
+	order, ok := reviewOrders[req.GetOrder()]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid order %s", req.GetOrder())
+	}
+	reviews, err := s.filteredReviews(ctx, req.GetAppId(), req.GetFilter())
+	if err != nil {
+		return nil, err
+	}
+	reviews = reviews.Within(window(req.GetHours()))
+	reviews.Sort(order)
+	res := &reviewspb.GetReviewsResponse{}
+	for _, review := range reviews {
+		res.Reviews = append(res.Reviews, toProto(review))
+	}
+	return res, nil
+}
+
+// StreamNewReviews polls the app's cache and sends reviews it has not sent yet that were updated after the
+// requested time, oldest first, until the client cancels or the server shuts down
+func (s *Server) StreamNewReviews(req *reviewspb.StreamNewReviewsRequest, stream reviewspb.Reviews_StreamNewReviewsServer) error {
+	ctx := stream.Context()
+	since := s.clock.Now()
+	if req.GetSince() != nil {
+		since = req.GetSince().AsTime()
+	}
+	sent := map[string]bool{}
+
+	for polls := 0; ; polls++ {
+		reviews, err := s.filteredReviews(ctx, req.GetAppId(), req.GetFilter())
+		switch {
+		case err != nil && polls == 0:
+			return err
+		case err != nil:
+			// Later failures are usually temporary, so keep the stream open and try again next poll
+			slog.WarnContext(ctx, "stream failed to load reviews", "app", req.GetAppId(), "error", err)
+		default:
+			reviews = reviews.After(since)
+			for i := len(reviews) - 1; i >= 0; i-- {
+				if sent[reviews[i].Id] {
+					continue
+				}
+				if err := stream.Send(toProto(reviews[i])); err != nil {
+					return err
+				}
+				sent[reviews[i].Id] = true
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-s.stopping:
+			return status.Error(codes.Unavailable, "server is shutting down")
+		case <-s.clock.After(s.pollInterval):
+		}
+	}
+}
+
+// GetStats summarizes an app's recent reviews
+func (s *Server) GetStats(ctx context.Context, req *reviewspb.GetStatsRequest) (*reviewspb.GetStatsResponse, error) {
+	reviews, err := s.filteredReviews(ctx, req.GetAppId(), req.GetFilter())
+	if err != nil {
+		return nil, err
+	}
+	stats := reviews.Within(window(req.GetHours())).Stats()
+	res := &reviewspb.GetStatsResponse{
+		Count:            int32(stats.Count),
+		AverageRating:    stats.AverageRating,
+		Histogram:        map[int32]int32{},
+		AverageSentiment: stats.AverageSentiment,
+		Sentiments:       map[string]int32{},
+		Languages:        map[string]int32{},
+		Spam:             int32(stats.Spam),
+	}
+	for rating, count := range stats.Histogram {
+		res.Histogram[int32(rating)] = int32(count)
+	}
+	for _, version := range stats.Versions {
+		res.Versions = append(res.Versions, &reviewspb.VersionStats{
+			Version:       version.Version,
+			Count:         int32(version.Count),
+			AverageRating: version.AverageRating,
+		})
+	}
+	for label, count := range stats.Sentiments {
+		res.Sentiments[string(label)] = int32(count)
+	}
+	for code, count := range stats.Languages {
+		res.Languages[code] = int32(count)
+	}
+	for _, day := range stats.SentimentOverTime {
+		res.SentimentOverTime = append(res.SentimentOverTime, &reviewspb.SentimentPeriod{
+			Start:            timestamppb.New(day.Start),
+			Count:            int32(day.Count),
+			AverageSentiment: day.AverageSentiment,
+		})
+	}
+	return res, nil
+}
+
+// ManageTrackedApps lists the cached apps after adding or removing one. Adding an app fetches its reviews into the
+// cache so the refresher keeps them up to date.
+func (s *Server) ManageTrackedApps(ctx context.Context, req *reviewspb.ManageTrackedAppsRequest) (*reviewspb.ManageTrackedAppsResponse, error) {
+	switch req.GetAction() {
+	case reviewspb.ManageTrackedAppsRequest_ACTION_LIST:
+	case reviewspb.ManageTrackedAppsRequest_ACTION_ADD:
+		if err := checkAppId(req.GetAppId()); err != nil {
+			return nil, err
+		}
+		if _, err := s.load(ctx, req.GetAppId()); err != nil {
+			return nil, loadError(err)
+		}
+	case reviewspb.ManageTrackedAppsRequest_ACTION_REMOVE:
+		if err := checkAppId(req.GetAppId()); err != nil {
+			return nil, err
+		}
+		if err := s.updater.RemoveReviews(ctx, req.GetAppId()); err != nil {
+			return nil, status.Errorf(codes.NotFound, "app %s is not tracked", req.GetAppId())
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown action %s", req.GetAction())
+	}
+
+	apps, err := s.updater.ListApps(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list tracked apps: %s", err)
+	}
+	sort.Slice(apps, func(i, j int) bool { return apps[i].AppId < apps[j].AppId })
+	res := &reviewspb.ManageTrackedAppsResponse{}
+	for _, app := range apps {
+		res.Apps = append(res.Apps, &reviewspb.TrackedApp{AppId: app.AppId, CacheModified: timestamppb.New(app.Modified)})
+	}
+	return res, nil
+}
@@ -0,0 +1,313 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/marcuswu/app-reviews/auth"
+	"github.com/marcuswu/app-reviews/clock/clocktest"
+	"github.com/marcuswu/app-reviews/models"
+	"github.com/marcuswu/app-reviews/rpc/reviewspb"
+	"github.com/marcuswu/app-reviews/updater"
+)
+
+var start = time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+
+// testReviews are the reviews served for app 1234, newest first
+func testReviews() models.AppReviews {
+	return models.AppReviews{
+		{Id: "3", Rating: 5, Version: "2.0", Title: "Great", Updated: start.Add(-time.Hour)},
+		{Id: "2", Rating: 1, Version: "2.0", Title: "Crashes", Content: "It crashes when I open it", Updated: start.Add(-2 * time.Hour)},
+		{Id: "1", Rating: 4, Version: "1.0", Title: "Good", Updated: start.Add(-72 * time.Hour)},
+	}
+}
+
+// startServer serves a Server over an in-memory connection and returns a client for it. The load function serves
+// *reviews for app 1234, caches apps it is asked for and returns the cache.
+func startServer(t *testing.T, fake *clocktest.Fake, reviews *models.AppReviews, options ...grpc.ServerOption) reviewspb.ReviewsClient {
+	models.SetClock(fake)
+	t.Cleanup(func() { models.SetClock(nil) })
+
+	u := updater.New(updater.WithStore(updater.NewFileStore(t.TempDir())), updater.WithClock(fake))
+	load := func(ctx context.Context, appId string) (models.AppReviews, error) {
+		if appId != "1234" {
+			return nil, updater.ErrNoFeed
+		}
+		loaded := append(models.AppReviews{}, (*reviews)...)
+		if err := u.SaveReviews(ctx, appId, loaded); err != nil {
+			return nil, err
+		}
+		// Stand in for the server's periodic spam check
+		if _, err := u.DetectSpam(ctx); err != nil {
+			return nil, err
+		}
+		return u.LoadReviews(ctx, appId)
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(options...)
+	reviewspb.RegisterReviewsServer(server, NewServer(u, load, WithClock(fake), WithPollInterval(time.Minute)))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return reviewspb.NewReviewsClient(conn)
+}
+
+func TestGetReviewsAndStats(t *testing.T) {
+	reviews := testReviews()
+	client := startServer(t, clocktest.NewFake(start), &reviews)
+	ctx := context.Background()
+
+	res, err := client.GetReviews(ctx, &reviewspb.GetReviewsRequest{AppId: "1234"})
+	if err != nil || len(res.Reviews) != 2 || res.Reviews[0].Id != "3" || !res.Reviews[0].Updated.AsTime().Equal(start.Add(-time.Hour)) {
+		t.Errorf("expected the 2 reviews within 48 hours newest first, got %v (%v)", res, err)
+	}
+	res, err = client.GetReviews(ctx, &reviewspb.GetReviewsRequest{AppId: "1234", Hours: 100,
+		Filter: &reviewspb.ReviewFilter{Ratings: []int32{4, 5}}})
+	if err != nil || len(res.Reviews) != 2 {
+		t.Errorf("expected the 4 and 5 star reviews within 100 hours, got %v (%v)", res, err)
+	}
+
+	res, err = client.GetReviews(ctx, &reviewspb.GetReviewsRequest{AppId: "1234", Order: reviewspb.ReviewOrder_REVIEW_ORDER_MOST_NEGATIVE})
+	if err != nil || len(res.Reviews) != 2 || res.Reviews[0].Id != "2" || res.Reviews[0].Sentiment >= 0 {
+		t.Errorf("expected the negative review first, got %v (%v)", res, err)
+	}
+	res, err = client.GetReviews(ctx, &reviewspb.GetReviewsRequest{AppId: "1234",
+		Filter: &reviewspb.ReviewFilter{Sentiments: []reviewspb.Sentiment{reviewspb.Sentiment_SENTIMENT_POSITIVE}}})
+	if err != nil || len(res.Reviews) != 1 || res.Reviews[0].Id != "3" {
+		t.Errorf("expected only the positive review, got %v (%v)", res, err)
+	}
+	res, err = client.GetReviews(ctx, &reviewspb.GetReviewsRequest{AppId: "1234",
+		Filter: &reviewspb.ReviewFilter{Tags: []string{"Crash"}}})
+	if err != nil || len(res.Reviews) != 1 || res.Reviews[0].Id != "2" || len(res.Reviews[0].Tags) != 1 {
+		t.Errorf("expected only the review tagged crash, got %v (%v)", res, err)
+	}
+	res, err = client.GetReviews(ctx, &reviewspb.GetReviewsRequest{AppId: "1234",
+		Filter: &reviewspb.ReviewFilter{Languages: []string{"en"}}})
+	if err != nil || len(res.Reviews) != 1 || res.Reviews[0].Id != "2" || res.Reviews[0].Language != "en" {
+		t.Errorf("expected only the English review, got %v (%v)", res, err)
+	}
+	if _, err = client.GetReviews(ctx, &reviewspb.GetReviewsRequest{AppId: "1234",
+		Filter: &reviewspb.ReviewFilter{Languages: []string{"klingon"}}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected an invalid argument error for an unknown language, got %v", err)
+	}
+
+	stats, err := client.GetStats(ctx, &reviewspb.GetStatsRequest{AppId: "1234"})
+	if err != nil || stats.Count != 2 || stats.AverageRating != 3 || stats.Histogram[1] != 1 || len(stats.Versions) != 1 {
+		t.Errorf("unexpected stats %v (%v)", stats, err)
+	}
+	if stats.Sentiments["negative"] != 1 || stats.Sentiments["positive"] != 1 || len(stats.SentimentOverTime) != 1 ||
+		stats.SentimentOverTime[0].Count != 2 {
+		t.Errorf("unexpected sentiment stats %v", stats)
+	}
+	if stats.Languages["en"] != 1 || stats.Languages["und"] != 1 {
+		t.Errorf("unexpected language stats %v", stats.Languages)
+	}
+
+	_, err = client.GetReviews(ctx, &reviewspb.GetReviewsRequest{AppId: "4321"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound for an app without a feed, got %v", err)
+	}
+	_, err = client.GetReviews(ctx, &reviewspb.GetReviewsRequest{AppId: "../etc"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a bad app id, got %v", err)
+	}
+	_, err = client.GetStats(ctx, &reviewspb.GetStatsRequest{AppId: "1234", Filter: &reviewspb.ReviewFilter{Ratings: []int32{6}}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected InvalidArgument for a bad rating, got %v", err)
+	}
+}
+
+func TestStreamNewReviews(t *testing.T) {
+	fake := clocktest.NewFake(start)
+	reviews := testReviews()
+	client := startServer(t, fake, &reviews)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.StreamNewReviews(ctx, &reviewspb.StreamNewReviewsRequest{
+		AppId: "1234", Since: timestamppb.New(start.Add(-90 * time.Minute)),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if review, err := stream.Recv(); err != nil || review.Id != "3" {
+		t.Fatalf("expected the review since the requested time, got %v (%v)", review, err)
+	}
+
+	reviews = append(models.AppReviews{{Id: "4", Rating: 2, Updated: start.Add(time.Minute)}}, reviews...)
+	for fake.Waiters() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	fake.Advance(time.Minute)
+	if review, err := stream.Recv(); err != nil || review.Id != "4" {
+		t.Errorf("expected the new review on the next poll, got %v (%v)", review, err)
+	}
+}
+
+func TestManageTrackedApps(t *testing.T) {
+	reviews := testReviews()
+	client := startServer(t, clocktest.NewFake(start), &reviews)
+	ctx := context.Background()
+
+	res, err := client.ManageTrackedApps(ctx, &reviewspb.ManageTrackedAppsRequest{
+		Action: reviewspb.ManageTrackedAppsRequest_ACTION_ADD, AppId: "1234"})
+	if err != nil || len(res.Apps) != 1 || res.Apps[0].AppId != "1234" {
+		t.Errorf("expected the added app to be tracked, got %v (%v)", res, err)
+	}
+	res, err = client.ManageTrackedApps(ctx, &reviewspb.ManageTrackedAppsRequest{
+		Action: reviewspb.ManageTrackedAppsRequest_ACTION_REMOVE, AppId: "1234"})
+	if err != nil || len(res.Apps) != 0 {
+		t.Errorf("expected no tracked apps after removing it, got %v (%v)", res, err)
+	}
+	_, err = client.ManageTrackedApps(ctx, &reviewspb.ManageTrackedAppsRequest{
+		Action: reviewspb.ManageTrackedAppsRequest_ACTION_REMOVE, AppId: "1234"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("expected NotFound removing an untracked app, got %v", err)
+	}
+}
+
+func TestAuthInterceptors(t *testing.T) {
+	keyring, err := auth.LoadKeys(strings.NewReader(`{"keys": [
+		{"name": "reader", "key": "reader-0123456789", "scopes": ["read"]}
+	]}`), clocktest.NewFake(start))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reviews := testReviews()
+	client := startServer(t, clocktest.NewFake(start), &reviews,
+		grpc.UnaryInterceptor(UnaryAuth(keyring)), grpc.StreamInterceptor(StreamAuth(keyring)))
+
+	_, err = client.GetReviews(context.Background(), &reviewspb.GetReviewsRequest{AppId: "1234"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("expected Unauthenticated without a key, got %v", err)
+	}
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "reader-0123456789")
+	if _, err := client.GetReviews(ctx, &reviewspb.GetReviewsRequest{AppId: "1234"}); err != nil {
+		t.Errorf("expected a reader to get reviews, got %v", err)
+	}
+	_, err = client.ManageTrackedApps(ctx, &reviewspb.ManageTrackedAppsRequest{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("expected PermissionDenied managing apps without admin, got %v", err)
+	}
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer reader-0123456789")
+	stream, err := client.StreamNewReviews(ctx, &reviewspb.StreamNewReviewsRequest{
+		AppId: "1234", Since: timestamppb.New(start.Add(-90 * time.Minute))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Errorf("expected a reader to stream reviews, got %v", err)
+	}
+}
+
+func TestAuthInterceptorsEnforceLimits(t *testing.T) {
+	keyring, err := auth.LoadKeys(strings.NewReader(`{"keys": [
+		{"name": "reader", "key": "reader-0123456789", "scopes": ["read"], "dailyQuota": 2}
+	]}`), clocktest.NewFake(start))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reviews := testReviews()
+	client := startServer(t, clocktest.NewFake(start), &reviews,
+		grpc.UnaryInterceptor(UnaryAuth(keyring)), grpc.StreamInterceptor(StreamAuth(keyring)))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "reader-0123456789")
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetStats(ctx, &reviewspb.GetStatsRequest{AppId: "1234"}); err != nil {
+			t.Fatalf("expected call %d to be within the quota, got %v", i+1, err)
+		}
+	}
+	_, err = client.GetStats(ctx, &reviewspb.GetStatsRequest{AppId: "1234"})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("expected ResourceExhausted over the daily quota, got %v", err)
+	}
+}
+
+func TestSpamFilter(t *testing.T) {
+	pasted := "Download now and use code FREE100 to get one hundred coins for free today"
+	reviews := models.AppReviews{
+		{Id: "3", Rating: 5, Author: models.Author{Name: "bot1"}, Content: pasted, Updated: start.Add(-time.Hour)},
+		{Id: "2", Rating: 5, Author: models.Author{Name: "bot2"}, Content: pasted, Updated: start.Add(-2 * time.Hour)},
+		{Id: "1", Rating: 2, Author: models.Author{Name: "someone"}, Content: "Too slow", Updated: start.Add(-3 * time.Hour)},
+	}
+	client := startServer(t, clocktest.NewFake(start), &reviews)
+	ctx := context.Background()
+
+	res, err := client.GetReviews(ctx, &reviewspb.GetReviewsRequest{AppId: "1234",
+		Filter: &reviewspb.ReviewFilter{Spam: reviewspb.SpamFilter_SPAM_FILTER_ONLY}})
+	if err != nil || len(res.Reviews) != 2 || len(res.Reviews[0].Spam) != 1 || res.Reviews[0].Spam[0] != "duplicate" {
+		t.Errorf("expected the two copied reviews, got %v (%v)", res, err)
+	}
+
+	stats, err := client.GetStats(ctx, &reviewspb.GetStatsRequest{AppId: "1234"})
+	if err != nil || stats.Count != 3 || stats.Spam != 2 {
+		t.Errorf("expected 2 of 3 reviews to be suspected spam, got %v (%v)", stats, err)
+	}
+	stats, err = client.GetStats(ctx, &reviewspb.GetStatsRequest{AppId: "1234",
+		Filter: &reviewspb.ReviewFilter{Spam: reviewspb.SpamFilter_SPAM_FILTER_EXCLUDE}})
+	if err != nil || stats.Count != 1 || stats.AverageRating != 2 || stats.Spam != 0 {
+		t.Errorf("expected stats without the suspected spam, got %v (%v)", stats, err)
+	}
+}
+
+func TestRevisions(t *testing.T) {
+	reviews := testReviews()
+	client := startServer(t, clocktest.NewFake(start), &reviews)
+	ctx := context.Background()
+	if _, err := client.GetReviews(ctx, &reviewspb.GetReviewsRequest{AppId: "1234"}); err != nil {
+		t.Fatal(err)
+	}
+
+	reviews[1].Rating = 4
+	reviews[1].Content = "Fixed in the update"
+	res, err := client.GetReviews(ctx, &reviewspb.GetReviewsRequest{AppId: "1234"})
+	if err != nil || len(res.Reviews) != 2 {
+		t.Fatalf("expected 2 reviews, got %v (%v)", res, err)
+	}
+	revisions := res.Reviews[1].Revisions
+	if len(res.Reviews[0].Revisions) != 0 || len(revisions) != 1 || revisions[0].Rating != 1 ||
+		revisions[0].Content != "It crashes when I open it" || !revisions[0].Updated.AsTime().Equal(reviews[1].Updated) {
+		t.Errorf("expected review 2 to have its earlier revision, got %v", res.Reviews)
+	}
+}
+
+func TestRemovedReviews(t *testing.T) {
+	reviews := testReviews()
+	fake := clocktest.NewFake(start)
+	client := startServer(t, fake, &reviews)
+	ctx := context.Background()
+	if _, err := client.GetReviews(ctx, &reviewspb.GetReviewsRequest{AppId: "1234"}); err != nil {
+		t.Fatal(err)
+	}
+
+	reviews = models.AppReviews{reviews[0], reviews[2]}
+	res, err := client.GetReviews(ctx, &reviewspb.GetReviewsRequest{AppId: "1234"})
+	if err != nil || len(res.Reviews) != 1 || res.Reviews[0].Id != "3" || res.Reviews[0].RemovedAt != nil {
+		t.Errorf("expected removed reviews to be left out, got %v (%v)", res, err)
+	}
+	res, err = client.GetReviews(ctx, &reviewspb.GetReviewsRequest{AppId: "1234",
+		Filter: &reviewspb.ReviewFilter{Removed: reviewspb.RemovedFilter_REMOVED_FILTER_ONLY}})
+	if err != nil || len(res.Reviews) != 1 || res.Reviews[0].Id != "2" || !res.Reviews[0].RemovedAt.AsTime().Equal(start) {
+		t.Errorf("expected review 2 to be removed, got %v (%v)", res, err)
+	}
+}
@@ -0,0 +1,174 @@
+// Package sentiment scores the sentiment of review text offline with a word lexicon. Negations ("not good") flip
+// the words that follow them, intensifiers ("very") and dampeners ("slightly") scale them, words after "but" count
+// for more than words before it and exclamation marks add emphasis.
+package sentiment
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Label buckets a score into negative, neutral or positive
+type Label string
+
+const (
+	Negative Label = "negative"
+	Neutral  Label = "neutral"
+	Positive Label = "positive"
+)
+
+// Labels lists every label from most negative to most positive
+var Labels = []Label{Negative, Neutral, Positive}
+
+// threshold is how far from zero a score must be to not be neutral
+const threshold = 0.05
+
+//go:embed lexicon.txt
+var lexiconFile string
+
+// lexicon maps lowercase words to their valence
+var lexicon = parseLexicon(lexiconFile)
+
+var negations = map[string]bool{
+	"not": true, "no": true, "never": true, "nothing": true, "nobody": true, "none": true, "neither": true,
+	"nor": true, "without": true, "cannot": true, "cant": true, "can't": true, "dont": true, "don't": true,
+	"doesnt": true, "doesn't": true, "didnt": true, "didn't": true, "isnt": true, "isn't": true, "wasnt": true,
+	"wasn't": true, "arent": true, "aren't": true, "wont": true, "won't": true, "wouldnt": true, "wouldn't": true,
+	"shouldnt": true, "shouldn't": true, "hardly": true, "barely": true,
+}
+
+// boosters scale the valence of the next sentiment word
+var boosters = map[string]float64{
+	"very": 1.5, "really": 1.5, "so": 1.3, "extremely": 1.8, "super": 1.5, "totally": 1.5, "absolutely": 1.8,
+	"completely": 1.5, "incredibly": 1.8, "truly": 1.3, "highly": 1.5, "most": 1.3, "too": 1.3,
+	"slightly": 0.5, "somewhat": 0.6, "kinda": 0.6, "kind": 0.6, "little": 0.6, "bit": 0.6, "fairly": 0.8,
+}
+
+// negationScope is how many words after a negation it applies to
+const negationScope = 3
+
+// negationFactor scales and flips negated words, so "not great" is less negative than "terrible". Negated
+// negative words are faint praise, so "not bad" is scaled down further.
+const (
+	negationFactor        = -0.75
+	negatedNegativeFactor = -0.5
+)
+
+// normalization controls how quickly scores approach -1 or 1 as valences add up
+const normalization = 15
+
+func parseLexicon(file string) map[string]float64 {
+	words := map[string]float64{}
+	scanner := bufio.NewScanner(strings.NewReader(file))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if len(text) < 1 || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Fields(text)
+		if len(fields) != 2 {
+			panic(fmt.Sprintf("sentiment lexicon line %d: expected a word and a valence", line))
+		}
+		valence, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			panic(fmt.Sprintf("sentiment lexicon line %d: %s", line, err))
+		}
+		words[fields[0]] = valence
+	}
+	return words
+}
+
+// tokenize splits text into lowercase words, keeping apostrophes within words. Exclamation marks are counted.
+func tokenize(text string) ([]string, int) {
+	exclamations := strings.Count(text, "!")
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '’'
+	})
+	for i, word := range words {
+		words[i] = strings.Trim(strings.ReplaceAll(word, "’", "'"), "'")
+	}
+	return words, exclamations
+}
+
+// Score rates the sentiment of text from -1 (most negative) to 1 (most positive). Text without any words from the
+// lexicon scores 0.
+func Score(text string) float64 {
+	words, exclamations := tokenize(text)
+
+	valences := []float64{}
+	butAt := -1
+	negatedUntil := -1
+	boost := 1.0
+	for i, word := range words {
+		if word == "but" {
+			butAt = len(valences)
+			continue
+		}
+		if negations[word] {
+			negatedUntil = i + negationScope
+			continue
+		}
+		if factor, ok := boosters[word]; ok {
+			boost *= factor
+			continue
+		}
+
+		valence, ok := lexicon[word]
+		if !ok {
+			continue
+		}
+		valence *= boost
+		boost = 1
+		if i <= negatedUntil && valence < 0 {
+			valence *= negatedNegativeFactor
+		} else if i <= negatedUntil {
+			valence *= negationFactor
+		}
+		valences = append(valences, valence)
+	}
+
+	sum := 0.0
+	for i, valence := range valences {
+		switch {
+		case butAt < 0:
+		case i < butAt:
+			valence *= 0.5
+		default:
+			valence *= 1.5
+		}
+		sum += valence
+	}
+	if sum != 0 {
+		sum += math.Copysign(0.3*float64(min(exclamations, 4)), sum)
+	}
+
+	return sum / math.Sqrt(sum*sum+normalization)
+}
+
+// Classify returns the label for a score
+func Classify(score float64) Label {
+	switch {
+	case score <= -threshold:
+		return Negative
+	case score >= threshold:
+		return Positive
+	default:
+		return Neutral
+	}
+}
+
+// ParseLabel returns the label with a name such as "negative"
+func ParseLabel(name string) (Label, error) {
+	label := Label(strings.ToLower(strings.TrimSpace(name)))
+	for _, known := range Labels {
+		if label == known {
+			return label, nil
+		}
+	}
+	return "", fmt.Errorf("invalid sentiment %q, expected negative, neutral or positive", name)
+}
@@ -0,0 +1,57 @@
+package sentiment
+
+import (
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected Label
+	}{
+		{"Love it. Works great and the new design is beautiful", Positive},
+		{"Crashes every time I open it. Useless", Negative},
+		{"I updated the app yesterday", Neutral},
+		{"", Neutral},
+		{"Not good", Negative},
+		{"No crashes since the update", Positive},
+		{"Doesn't work anymore", Negative},
+		{"The design is great but it crashes constantly and support is useless", Negative},
+		{"It was slow at first but now it's fast and reliable", Positive},
+		{"Nice app, but the latest update is TERRIBLE!!!", Negative},
+	}
+	for _, test := range tests {
+		score := Score(test.text)
+		if score < -1 || score > 1 {
+			t.Errorf("expected a score from -1 to 1 for %q, got %f", test.text, score)
+		}
+		if label := Classify(score); label != test.expected {
+			t.Errorf("expected %q to be %s, got %s (%f)", test.text, test.expected, label, score)
+		}
+	}
+}
+
+func TestScoreModifiers(t *testing.T) {
+	if Score("very good") <= Score("good") || Score("slightly good") >= Score("good") {
+		t.Errorf("expected boosters to scale scores: very %f, plain %f, slightly %f",
+			Score("very good"), Score("good"), Score("slightly good"))
+	}
+	if Score("great!!") <= Score("great") || Score("awful!!") >= Score("awful") {
+		t.Errorf("expected exclamation marks to emphasize scores")
+	}
+	if Score("not terrible") <= 0 || Score("not terrible") >= Score("great") {
+		t.Errorf("expected a negated negative word to be mildly positive, got %f", Score("not terrible"))
+	}
+	if Score("It’s not bad") <= 0 {
+		t.Errorf("expected curly apostrophes to be handled, got %f", Score("It’s not bad"))
+	}
+}
+
+func TestParseLabel(t *testing.T) {
+	if label, err := ParseLabel(" Negative "); err != nil || label != Negative {
+		t.Errorf("expected negative, got %q (%v)", label, err)
+	}
+	if _, err := ParseLabel("angry"); err == nil {
+		t.Errorf("expected an error for an unknown label")
+	}
+}
@@ -0,0 +1,238 @@
+// Package spam flags reviews that look like spam. Near duplicates are found by comparing MinHash signatures of the
+// word shingles (runs of three words) in each review's content, with locality sensitive hashing so only likely
+// pairs are compared. Authors are suspect when they post many reviews in a short time or the same text twice.
+package spam
+
+import (
+	"hash/fnv"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/marcuswu/app-reviews/config"
+	"github.com/marcuswu/app-reviews/models"
+)
+
+// Reasons a review is suspected to be spam, in the order they are listed on a review
+const (
+	Duplicate    = "duplicate"     // a near duplicate of a review by another author
+	RepeatedText = "repeated-text" // its author posted the same text more than once
+	AuthorBurst  = "author-burst"  // its author posted many reviews in a short time
+)
+
+var reasons = []string{Duplicate, RepeatedText, AuthorBurst}
+
+const (
+	shingleWords = 3  // words in each shingle
+	bands        = 16 // locality sensitive hashing bands
+	rows         = 4  // signature values in each band
+	numHashes    = bands * rows
+)
+
+// Options configures Detect. Zero values use the defaults from config.
+type Options struct {
+	Similarity    float64       // estimated Jaccard similarity at which reviews are near duplicates
+	MinWords      int           // words a review's content needs before it is compared with others
+	AuthorReviews int           // reviews by one author within AuthorWindow that look like spam
+	AuthorWindow  time.Duration // window AuthorReviews are counted in
+}
+
+// entry is a review being checked and what is known about it so far
+type entry struct {
+	appId     string
+	review    *models.AppReview
+	author    string
+	signature []uint64 // nil when the content is too short to compare
+	reasons   map[string]bool
+}
+
+// Detect sets the Spam reasons of every review in apps, a map of app ids to their reviews, comparing reviews within
+// and across apps. It returns how many reviews' reasons changed in each app that had changes.
+func Detect(apps map[string]models.AppReviews, options Options) map[string]int {
+	if options.Similarity <= 0 {
+		options.Similarity = config.DUPLICATE_SIMILARITY
+	}
+	if options.MinWords < 1 {
+		options.MinWords = config.DUPLICATE_MIN_WORDS
+	}
+	if options.AuthorReviews < 1 {
+		options.AuthorReviews = config.SPAM_AUTHOR_REVIEWS
+	}
+	if options.AuthorWindow <= 0 {
+		options.AuthorWindow = time.Duration(config.SPAM_AUTHOR_WINDOW_HOURS) * time.Hour
+	}
+
+	// Go through apps in order so results don't depend on map iteration
+	appIds := make([]string, 0, len(apps))
+	for appId := range apps {
+		appIds = append(appIds, appId)
+	}
+	sort.Strings(appIds)
+	entries := []*entry{}
+	for _, appId := range appIds {
+		reviews := apps[appId]
+		for i := range reviews {
+			e := &entry{appId: appId, review: &reviews[i], author: authorKey(reviews[i]), reasons: map[string]bool{}}
+			if tokens := words(reviews[i].Content); len(tokens) >= options.MinWords {
+				e.signature = signature(tokens)
+			}
+			entries = append(entries, e)
+		}
+	}
+
+	flagDuplicates(entries, options.Similarity)
+	flagBursts(entries, options.AuthorReviews, options.AuthorWindow)
+
+	changed := map[string]int{}
+	for _, e := range entries {
+		flagged := []string{}
+		for _, reason := range reasons {
+			if e.reasons[reason] {
+				flagged = append(flagged, reason)
+			}
+		}
+		if e.review.Spam == nil || !slices.Equal(flagged, e.review.Spam) {
+			changed[e.appId]++
+		}
+		e.review.Spam = flagged
+	}
+	return changed
+}
+
+// authorKey identifies a review's author by their profile link, or their name when there is no link
+func authorKey(review models.AppReview) string {
+	if len(review.Author.Uri) > 0 {
+		return review.Author.Uri
+	}
+	return strings.ToLower(strings.TrimSpace(review.Author.Name))
+}
+
+// words splits text into lowercase words of letters and digits
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// signature returns the MinHash signature of the word shingles in words: for each of numHashes hash functions, the
+// smallest hash of any shingle. The fraction of equal values in two signatures estimates their Jaccard similarity.
+func signature(words []string) []uint64 {
+	shingles := map[uint64]bool{}
+	for i := 0; i+shingleWords <= len(words); i++ {
+		hash := fnv.New64a()
+		hash.Write([]byte(strings.Join(words[i:i+shingleWords], " ")))
+		shingles[hash.Sum64()] = true
+	}
+
+	result := make([]uint64, numHashes)
+	for k := range result {
+		result[k] = ^uint64(0)
+	}
+	for shingle := range shingles {
+		for k := range result {
+			if hashed := mix(shingle ^ seeds[k]); hashed < result[k] {
+				result[k] = hashed
+			}
+		}
+	}
+	return result
+}
+
+// seeds turn one shingle hash into numHashes independent ones
+var seeds = func() []uint64 {
+	result := make([]uint64, numHashes)
+	for k := range result {
+		result[k] = mix(uint64(k + 1))
+	}
+	return result
+}()
+
+// mix is the splitmix64 finalizer, which spreads the bits of x over the whole result
+func mix(x uint64) uint64 {
+	x += 0x9e3779b97f4a7c15
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// similarity estimates the Jaccard similarity of the shingles behind two signatures
+func similarity(a []uint64, b []uint64) float64 {
+	equal := 0
+	for k := range a {
+		if a[k] == b[k] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(a))
+}
+
+// flagDuplicates flags reviews with a near duplicate. Reviews sharing every value in any band of their signatures are
+// candidates, which are then compared in full.
+func flagDuplicates(entries []*entry, threshold float64) {
+	compared := map[[2]int]bool{}
+	for band := 0; band < bands; band++ {
+		buckets := map[[rows]uint64][]int{}
+		for i, e := range entries {
+			if e.signature == nil {
+				continue
+			}
+			key := [rows]uint64{}
+			copy(key[:], e.signature[band*rows:(band+1)*rows])
+			buckets[key] = append(buckets[key], i)
+		}
+
+		for _, bucket := range buckets {
+			for x := 0; x < len(bucket); x++ {
+				for y := x + 1; y < len(bucket); y++ {
+					pair := [2]int{bucket[x], bucket[y]}
+					if compared[pair] {
+						continue
+					}
+					compared[pair] = true
+					a, b := entries[pair[0]], entries[pair[1]]
+					if a.appId == b.appId && a.review.Id == b.review.Id {
+						continue
+					}
+					if similarity(a.signature, b.signature) < threshold {
+						continue
+					}
+					reason := Duplicate
+					if len(a.author) > 0 && a.author == b.author {
+						reason = RepeatedText
+					}
+					a.reasons[reason] = true
+					b.reasons[reason] = true
+				}
+			}
+		}
+	}
+}
+
+// flagBursts flags the reviews of authors who posted at least count reviews within window
+func flagBursts(entries []*entry, count int, window time.Duration) {
+	byAuthor := map[string][]*entry{}
+	for _, e := range entries {
+		if len(e.author) > 0 {
+			byAuthor[e.author] = append(byAuthor[e.author], e)
+		}
+	}
+	for _, posts := range byAuthor {
+		if len(posts) < count {
+			continue
+		}
+		sort.Slice(posts, func(i, j int) bool { return posts[i].review.Updated.Before(posts[j].review.Updated) })
+		start := 0
+		for end := range posts {
+			for posts[end].review.Updated.Sub(posts[start].review.Updated) > window {
+				start++
+			}
+			if end-start+1 >= count {
+				for _, e := range posts[start : end+1] {
+					e.reasons[AuthorBurst] = true
+				}
+			}
+		}
+	}
+}
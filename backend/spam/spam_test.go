@@ -0,0 +1,73 @@
+package spam
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/marcuswu/app-reviews/models"
+)
+
+func TestDetect(t *testing.T) {
+	start := time.Date(2024, 3, 12, 0, 0, 0, 0, time.UTC)
+	author := func(name string) models.Author {
+		return models.Author{Name: name, Uri: "https://itunes.apple.com/us/reviews/" + name}
+	}
+	pasted := "Best app ever, download it now and use code FREE100 to get one hundred coins for free today"
+	apps := map[string]models.AppReviews{
+		"1": {
+			{Id: "a", Author: author("bot1"), Content: pasted, Updated: start},
+			// A near duplicate: one word changed
+			{Id: "b", Author: author("bot2"), Content: strings.Replace(pasted, "today", "tonight", 1), Updated: start},
+			{Id: "c", Author: author("someone"), Content: "The sync keeps failing since the update and support never answered my emails", Updated: start},
+			{Id: "d", Author: author("fan"), Content: "Great app", Updated: start},
+			{Id: "e", Author: author("busy"), Content: "Nice and simple", Updated: start.Add(time.Hour)},
+		},
+		"2": {
+			// The same author pasting the same text into another app
+			{Id: "f", Author: author("someone"), Content: "The sync keeps failing since the update and support never answered my emails!", Updated: start.Add(time.Hour)},
+			{Id: "g", Author: author("other"), Content: "Great app", Updated: start},
+			{Id: "h", Author: author("busy"), Content: "Does what it says", Updated: start.Add(2 * time.Hour)},
+		},
+		"3": {
+			{Id: "i", Author: author("busy"), Content: "Works fine for me", Updated: start.Add(3 * time.Hour)},
+			{Id: "j", Author: author("fan"), Content: "Love it", Updated: start.Add(72 * time.Hour)},
+		},
+	}
+
+	changed := Detect(apps, Options{})
+	if changed["1"] != 5 || changed["2"] != 3 || changed["3"] != 2 {
+		t.Errorf("expected every review to be flagged for the first time, got %v", changed)
+	}
+
+	expected := map[string]string{
+		"a": Duplicate, "b": Duplicate, "c": RepeatedText, "d": "", "e": AuthorBurst,
+		"f": RepeatedText, "g": "", "h": AuthorBurst, "i": AuthorBurst, "j": "",
+	}
+	for _, reviews := range apps {
+		for _, review := range reviews {
+			if reasons := strings.Join(review.Spam, ","); reasons != expected[review.Id] {
+				t.Errorf("expected review %s to be flagged %q, got %q", review.Id, expected[review.Id], reasons)
+			}
+		}
+	}
+
+	if changed := Detect(apps, Options{}); len(changed) != 0 {
+		t.Errorf("expected nothing to change detecting again, got %v", changed)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	a := signature(words("the quick brown fox jumps over the lazy dog and runs away into the woods"))
+	b := signature(words("the quick brown fox jumps over the lazy dog and runs away into the forest"))
+	c := signature(words("my notes disappeared after syncing with my laptop this morning"))
+	if s := similarity(a, a); s != 1 {
+		t.Errorf("expected identical text to be identical, got %f", s)
+	}
+	if s := similarity(a, b); s < 0.6 || s >= 1 {
+		t.Errorf("expected one changed word to be similar, got %f", s)
+	}
+	if s := similarity(a, c); s > 0.2 {
+		t.Errorf("expected unrelated text to be dissimilar, got %f", s)
+	}
+}
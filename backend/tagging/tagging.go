@@ -0,0 +1,199 @@
+// Package tagging assigns tags such as "crash" or "billing" to reviews with configurable rules so support can triage
+// them. A rule matches a review when every condition it sets matches: any of its keywords or patterns in the title
+// or content, a rating range and app versions.
+package tagging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/marcuswu/app-reviews/models"
+)
+
+//go:embed default_rules.json
+var defaultRules []byte
+
+// Rule assigns Tag to reviews matching every condition it sets
+type Rule struct {
+	Tag       string   `json:"tag"`
+	Keywords  []string `json:"keywords,omitempty"`  // words or phrases matched as whole words, ignoring case
+	Patterns  []string `json:"patterns,omitempty"`  // regular expressions, use (?i) to ignore case
+	MinRating int      `json:"minRating,omitempty"` // zero for no minimum
+	MaxRating int      `json:"maxRating,omitempty"` // zero for no maximum
+	Versions  []string `json:"versions,omitempty"`  // app versions, a trailing * matches a prefix such as 2.*
+
+	patterns []*regexp.Regexp
+}
+
+// RuleSet is a list of rules and a version that changes whenever they do
+type RuleSet struct {
+	Rules   []Rule `json:"rules"`
+	Version string `json:"-"` // short hash of the rules file
+}
+
+// Parse reads and checks a rule set from JSON
+func Parse(data []byte) (*RuleSet, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	rules := &RuleSet{}
+	if err := decoder.Decode(rules); err != nil {
+		return nil, fmt.Errorf("invalid tag rules: %w", err)
+	}
+
+	for i := range rules.Rules {
+		rule := &rules.Rules[i]
+		rule.Tag = NormalizeTag(rule.Tag)
+		if len(rule.Tag) < 1 {
+			return nil, fmt.Errorf("tag rule %d has no tag", i+1)
+		}
+		if len(rule.Keywords) < 1 && len(rule.Patterns) < 1 && rule.MinRating == 0 && rule.MaxRating == 0 &&
+			len(rule.Versions) < 1 {
+			return nil, fmt.Errorf("tag rule %d (%s) has no conditions", i+1, rule.Tag)
+		}
+		if rule.MinRating < 0 || rule.MinRating > 5 || rule.MaxRating < 0 || rule.MaxRating > 5 ||
+			(rule.MaxRating > 0 && rule.MinRating > rule.MaxRating) {
+			return nil, fmt.Errorf("tag rule %d (%s) has an invalid rating range", i+1, rule.Tag)
+		}
+		for j, keyword := range rule.Keywords {
+			rule.Keywords[j] = strings.ToLower(strings.TrimSpace(keyword))
+			if len(rule.Keywords[j]) < 1 {
+				return nil, fmt.Errorf("tag rule %d (%s) has an empty keyword", i+1, rule.Tag)
+			}
+		}
+		for _, pattern := range rule.Patterns {
+			compiled, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("tag rule %d (%s): %w", i+1, rule.Tag, err)
+			}
+			rule.patterns = append(rule.patterns, compiled)
+		}
+	}
+
+	hash := sha256.Sum256(data)
+	rules.Version = hex.EncodeToString(hash[:6])
+	return rules, nil
+}
+
+// LoadFile reads a rule set from a JSON file
+func LoadFile(filename string) (*RuleSet, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Default returns the built in rules for crash, billing, login, feature request and performance reviews
+func Default() *RuleSet {
+	rules, err := Parse(defaultRules)
+	if err != nil {
+		// The default rules are embedded, so this only happens if they are broken
+		panic(err)
+	}
+	return rules
+}
+
+// NormalizeTag trims and lowercases a tag so tags from rules and from requests compare equal
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// containsWord reports whether text contains phrase with no letter or digit immediately before or after it
+func containsWord(text string, phrase string) bool {
+	for offset := 0; offset < len(text); {
+		index := strings.Index(text[offset:], phrase)
+		if index < 0 {
+			return false
+		}
+		start, end := offset+index, offset+index+len(phrase)
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(text[start:])
+		offset = start + size
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+// matchesVersion reports whether version is one of versions, where a trailing * matches a prefix
+func matchesVersion(versions []string, version string) bool {
+	for _, pattern := range versions {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasPrefix(version, prefix) {
+			return true
+		}
+		if pattern == version {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether a review meets every condition of the rule. text is the review's title and content.
+func (r *Rule) matches(review models.AppReview, text string, lowerText string) bool {
+	if r.MinRating > 0 && review.Rating < r.MinRating {
+		return false
+	}
+	if r.MaxRating > 0 && review.Rating > r.MaxRating {
+		return false
+	}
+	if len(r.Versions) > 0 && !matchesVersion(r.Versions, review.Version) {
+		return false
+	}
+	if len(r.Keywords) < 1 && len(r.patterns) < 1 {
+		return true
+	}
+	for _, keyword := range r.Keywords {
+		if containsWord(lowerText, keyword) {
+			return true
+		}
+	}
+	for _, pattern := range r.patterns {
+		if pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// Tags returns the tags of the rules a review matches, in rule order without duplicates
+func (rs *RuleSet) Tags(review models.AppReview) []string {
+	text := review.Title + "\n" + review.Content
+	lowerText := strings.ToLower(text)
+	tags := []string{}
+	for i := range rs.Rules {
+		rule := &rs.Rules[i]
+		if !slices.Contains(tags, rule.Tag) && rule.matches(review, text, lowerText) {
+			tags = append(tags, rule.Tag)
+		}
+	}
+	return tags
+}
+
+// Apply sets the tags of every review and returns how many reviews' tags changed
+func (rs *RuleSet) Apply(reviews models.AppReviews) int {
+	changed := 0
+	for i := range reviews {
+		tags := rs.Tags(reviews[i])
+		if reviews[i].Tags == nil || !slices.Equal(tags, reviews[i].Tags) {
+			changed++
+		}
+		reviews[i].Tags = tags
+	}
+	return changed
+}
@@ -0,0 +1,73 @@
+package tagging
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/marcuswu/app-reviews/models"
+)
+
+func TestTags(t *testing.T) {
+	rules, err := Parse([]byte(`{"rules": [
+		{"tag": "Crash", "keywords": ["crash", "force close"], "patterns": ["(?i)won'?t open"]},
+		{"tag": "angry", "maxRating": 1},
+		{"tag": "happy", "minRating": 4, "maxRating": 5},
+		{"tag": "beta", "versions": ["2.1", "3.*"]},
+		{"tag": "sesión", "keywords": ["sesión"], "maxRating": 3},
+		{"tag": "crash", "keywords": ["freezes"]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		review   models.AppReview
+		expected string
+	}{
+		{"keyword", models.AppReview{Rating: 1, Version: "1.0", Content: "Every CRASH loses my work"}, "crash,angry"},
+		{"phrase", models.AppReview{Rating: 2, Title: "Force close", Version: "3.0.1"}, "crash,beta"},
+		{"pattern", models.AppReview{Rating: 4, Content: "It wont open", Version: "2.1"}, "crash,happy,beta"},
+		{"whole words", models.AppReview{Rating: 3, Content: "No crashes, no crashing", Version: "2.10"}, ""},
+		{"accents", models.AppReview{Rating: 2, Content: "No puedo iniciar sesión"}, "sesión"},
+		{"rating excludes", models.AppReview{Rating: 5, Content: "Cerré la sesión"}, "happy"},
+		{"duplicate tag", models.AppReview{Rating: 3, Content: "It freezes"}, "crash"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tags := strings.Join(rules.Tags(tt.review), ","); tags != tt.expected {
+				t.Errorf("expected tags %q, got %q", tt.expected, tags)
+			}
+		})
+	}
+
+	reviews := models.AppReviews{{Rating: 1}, {Rating: 3, Tags: []string{}}}
+	if changed := rules.Apply(reviews); changed != 1 || reviews[1].Tags == nil {
+		t.Errorf("expected only the untagged review to change, got %d", changed)
+	}
+}
+
+func TestParse(t *testing.T) {
+	invalid := map[string]string{
+		"no tag":        `{"rules": [{"keywords": ["crash"]}]}`,
+		"no conditions": `{"rules": [{"tag": "crash"}]}`,
+		"bad pattern":   `{"rules": [{"tag": "crash", "patterns": ["("]}]}`,
+		"bad ratings":   `{"rules": [{"tag": "crash", "minRating": 4, "maxRating": 2}]}`,
+		"unknown field": `{"rules": [{"tag": "crash", "keyword": ["crash"]}]}`,
+		"empty keyword": `{"rules": [{"tag": "crash", "keywords": [" "]}]}`,
+	}
+	for name, data := range invalid {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	a, _ := Parse([]byte(`{"rules": [{"tag": "a", "minRating": 1}]}`))
+	b, _ := Parse([]byte(`{"rules": [{"tag": "b", "minRating": 1}]}`))
+	if a.Version == b.Version || len(a.Version) < 1 {
+		t.Errorf("expected different rules to have different versions, got %q and %q", a.Version, b.Version)
+	}
+	if len(Default().Rules) < 1 {
+		t.Errorf("expected default rules")
+	}
+}
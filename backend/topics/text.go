@@ -0,0 +1,125 @@
+package topics
+
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+//go:embed stopwords/*.txt
+var stopWordFiles embed.FS
+
+// stopWords maps language codes such as "en" to their stop words
+var stopWords = loadStopWords()
+
+func loadStopWords() map[string]map[string]bool {
+	files, err := stopWordFiles.ReadDir("stopwords")
+	if err != nil {
+		panic(err)
+	}
+	languages := map[string]map[string]bool{}
+	for _, file := range files {
+		data, err := stopWordFiles.ReadFile(path.Join("stopwords", file.Name()))
+		if err != nil {
+			panic(err)
+		}
+		words := map[string]bool{}
+		scanner := bufio.NewScanner(strings.NewReader(string(data)))
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(line, "#") {
+				continue
+			}
+			for _, word := range strings.Fields(line) {
+				words[strings.ToLower(word)] = true
+			}
+		}
+		languages[strings.TrimSuffix(file.Name(), ".txt")] = words
+	}
+	return languages
+}
+
+// Languages returns the codes of the languages with stop word lists, sorted
+func Languages() []string {
+	languages := make([]string, 0, len(stopWords))
+	for language := range stopWords {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// CheckLanguage returns an error unless there is a stop word list for language
+func CheckLanguage(language string) error {
+	if _, ok := stopWords[language]; !ok {
+		return fmt.Errorf("unsupported language %q, expected one of %s", language, strings.Join(Languages(), ", "))
+	}
+	return nil
+}
+
+// IsStopWord reports whether a lowercase word is a stop word in language
+func IsStopWord(language string, word string) bool {
+	return stopWords[language][word]
+}
+
+// Tokenize splits text into sentences of lowercase words. Apostrophes within words are kept so contractions match
+// stop words, and words made only of digits are dropped.
+func Tokenize(text string) [][]string {
+	sentences := [][]string{}
+	sentence := []string{}
+	word := strings.Builder{}
+	endWord := func() {
+		token := strings.Trim(word.String(), "'")
+		word.Reset()
+		if len(token) < 1 || strings.IndexFunc(token, unicode.IsLetter) < 0 {
+			return
+		}
+		sentence = append(sentence, token)
+	}
+	endSentence := func() {
+		endWord()
+		if len(sentence) > 0 {
+			sentences = append(sentences, sentence)
+			sentence = []string{}
+		}
+	}
+
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word.WriteRune(r)
+		case r == '\'' || r == '’':
+			word.WriteRune('\'')
+		case r == '.' || r == '!' || r == '?' || r == ';' || r == ',' || r == ':' || r == '\n' || r == '(' || r == ')':
+			endSentence()
+		default:
+			endWord()
+		}
+	}
+	endSentence()
+	return sentences
+}
+
+// terms returns the words in text that aren't stop words, and the pairs of adjacent words within a sentence where
+// neither is a stop word
+func terms(text string, language string) (words []string, bigrams []string) {
+	for _, sentence := range Tokenize(text) {
+		previous := ""
+		for _, word := range sentence {
+			if IsStopWord(language, word) || len([]rune(word)) < 2 {
+				previous = ""
+				continue
+			}
+			words = append(words, word)
+			if len(previous) > 0 {
+				bigrams = append(bigrams, previous+" "+word)
+			}
+			previous = word
+		}
+	}
+	return words, bigrams
+}
@@ -0,0 +1,179 @@
+// Package topics finds what reviews are talking about. Review text is tokenized, stop words for the review's
+// language are removed and the remaining words and bigrams (pairs of adjacent words) are scored with TF-IDF over a
+// time window, then compared with the window before it to find trending terms.
+package topics
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/marcuswu/app-reviews/config"
+)
+
+// Document is a piece of text to find topics in, such as a review's title and content
+type Document struct {
+	Text     string
+	Language string // stop word language, Options.Language when empty or unsupported
+	Time     time.Time
+}
+
+// Options configures Analyze. Zero values use the defaults.
+type Options struct {
+	Language     string // stop word language for documents without one, defaults to en
+	Limit        int    // how many terms each list holds, defaults to config.TOPIC_LIMIT
+	MinDocuments int    // how many documents in the window a term must appear in, defaults to config.TOPIC_MIN_REVIEWS
+}
+
+// Term is a word or bigram and how much it was talked about in the window and the window before it
+type Term struct {
+	Term              string  `json:"term"`
+	Score             float64 `json:"score"`     // mean TF-IDF per document in the window
+	Count             int     `json:"count"`     // occurrences in the window
+	Documents         int     `json:"documents"` // documents in the window mentioning the term
+	PreviousScore     float64 `json:"previousScore"`
+	PreviousDocuments int     `json:"previousDocuments"`
+	Change            float64 `json:"change"` // Score - PreviousScore
+	New               bool    `json:"new"`    // the previous window had documents but none mentioned the term
+}
+
+// Report lists the top terms in a window and those that grew the most since the previous window
+type Report struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	PreviousStart   time.Time `json:"previousStart"`
+	Reviews         int       `json:"reviews"`
+	PreviousReviews int       `json:"previousReviews"`
+	Terms           []Term    `json:"terms"`    // single words by score
+	Bigrams         []Term    `json:"bigrams"`  // word pairs by score
+	Trending        []Term    `json:"trending"` // words and bigrams by increase in score
+}
+
+// termCounts holds how often each term appears in one document
+type termCounts map[string]int
+
+// windowStats accumulates term statistics over the documents in one window
+type windowStats struct {
+	documents int
+	counts    map[string]int // term -> occurrences
+	frequency map[string]int // term -> documents containing it
+	tfidf     map[string]float64
+}
+
+func newWindowStats() *windowStats {
+	return &windowStats{counts: map[string]int{}, frequency: map[string]int{}, tfidf: map[string]float64{}}
+}
+
+// Analyze finds the top terms in documents from the window ending at end and compares them with the window of the
+// same length before it. Documents outside both windows are ignored.
+func Analyze(documents []Document, end time.Time, window time.Duration, options Options) Report {
+	if len(options.Language) < 1 {
+		options.Language = "en"
+	}
+	if options.Limit < 1 {
+		options.Limit = config.TOPIC_LIMIT
+	}
+	if options.MinDocuments < 1 {
+		options.MinDocuments = config.TOPIC_MIN_REVIEWS
+	}
+	report := Report{
+		Start:         end.Add(-window),
+		End:           end,
+		PreviousStart: end.Add(-2 * window),
+		Terms:         []Term{},
+		Bigrams:       []Term{},
+		Trending:      []Term{},
+	}
+
+	// Count terms per document, keeping track of which window each belongs to
+	type counted struct {
+		terms   termCounts
+		current bool
+	}
+	corpus := []counted{}
+	frequency := map[string]int{}
+	bigrams := map[string]bool{}
+	for _, document := range documents {
+		if document.Time.After(end) || document.Time.Before(report.PreviousStart) {
+			continue
+		}
+		language := document.Language
+		if CheckLanguage(language) != nil {
+			language = options.Language
+		}
+		words, pairs := terms(document.Text, language)
+		counts := termCounts{}
+		for _, word := range words {
+			counts[word]++
+		}
+		for _, pair := range pairs {
+			counts[pair]++
+			bigrams[pair] = true
+		}
+		for term := range counts {
+			frequency[term]++
+		}
+		corpus = append(corpus, counted{terms: counts, current: !document.Time.Before(report.Start)})
+	}
+
+	// Score with inverse document frequencies from both windows so terms that are always mentioned rank low
+	current, previous := newWindowStats(), newWindowStats()
+	for _, document := range corpus {
+		stats := previous
+		if document.current {
+			stats = current
+		}
+		stats.documents++
+		for term, count := range document.terms {
+			idf := math.Log(float64(1+len(corpus))/float64(1+frequency[term])) + 1
+			stats.counts[term] += count
+			stats.frequency[term]++
+			stats.tfidf[term] += (1 + math.Log(float64(count))) * idf
+		}
+	}
+	report.Reviews = current.documents
+	report.PreviousReviews = previous.documents
+
+	for term, documents := range current.frequency {
+		if documents < options.MinDocuments {
+			continue
+		}
+		t := Term{
+			Term:              term,
+			Score:             current.tfidf[term] / float64(current.documents),
+			Count:             current.counts[term],
+			Documents:         documents,
+			PreviousDocuments: previous.frequency[term],
+			New:               previous.documents > 0 && previous.frequency[term] == 0,
+		}
+		if previous.documents > 0 {
+			t.PreviousScore = previous.tfidf[term] / float64(previous.documents)
+		}
+		t.Change = t.Score - t.PreviousScore
+
+		if bigrams[term] {
+			report.Bigrams = append(report.Bigrams, t)
+		} else {
+			report.Terms = append(report.Terms, t)
+		}
+		if t.Change > 0 {
+			report.Trending = append(report.Trending, t)
+		}
+	}
+
+	report.Terms = top(report.Terms, options.Limit, func(t Term) float64 { return t.Score })
+	report.Bigrams = top(report.Bigrams, options.Limit, func(t Term) float64 { return t.Score })
+	report.Trending = top(report.Trending, options.Limit, func(t Term) float64 { return t.Change })
+	return report
+}
+
+// top sorts terms by key, highest first and then alphabetically, and returns at most limit of them
+func top(terms []Term, limit int, key func(Term) float64) []Term {
+	sort.Slice(terms, func(i, j int) bool {
+		if key(terms[i]) != key(terms[j]) {
+			return key(terms[i]) > key(terms[j])
+		}
+		return terms[i].Term < terms[j].Term
+	})
+	return terms[:min(limit, len(terms))]
+}
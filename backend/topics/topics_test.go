@@ -0,0 +1,96 @@
+package topics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTokenize(t *testing.T) {
+	sentences := Tokenize("Dark mode doesn't work! Crashes on iOS 17.2, every time… (really)")
+	expected := [][]string{{"dark", "mode", "doesn't", "work"}, {"crashes", "on", "ios"}, {"every", "time"}, {"really"}}
+	if !reflect.DeepEqual(sentences, expected) {
+		t.Errorf("expected %v, got %v", expected, sentences)
+	}
+}
+
+func TestTerms(t *testing.T) {
+	words, bigrams := terms("The dark mode is great, but the sync button crashes", "en")
+	if !reflect.DeepEqual(words, []string{"dark", "mode", "great", "sync", "button", "crashes"}) {
+		t.Errorf("expected stop words to be removed, got %v", words)
+	}
+	if !reflect.DeepEqual(bigrams, []string{"dark mode", "sync button", "button crashes"}) {
+		t.Errorf("expected bigrams of adjacent words within a clause, got %v", bigrams)
+	}
+
+	words, _ = terms("La aplicación es muy lenta y se cierra", "es")
+	if !reflect.DeepEqual(words, []string{"lenta", "cierra"}) {
+		t.Errorf("expected Spanish stop words to be removed, got %v", words)
+	}
+	if err := CheckLanguage("xx"); err == nil {
+		t.Errorf("expected an error for a language without stop words")
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	end := time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC)
+	week := 7 * 24 * time.Hour
+	documents := []Document{
+		// This week people are talking about the login bug and dark mode
+		{Text: "Login bug locks me out", Time: end.Add(-time.Hour)},
+		{Text: "The login bug is back after the update", Time: end.Add(-2 * time.Hour)},
+		{Text: "Can't sign in because of the login bug", Time: end.Add(-3 * time.Hour)},
+		{Text: "Love the new dark mode", Time: end.Add(-24 * time.Hour)},
+		{Text: "Dark mode looks great", Time: end.Add(-48 * time.Hour)},
+		{Text: "Sync is slow", Time: end.Add(-50 * time.Hour)},
+		{Text: "Sync stopped working", Time: end.Add(-60 * time.Hour)},
+		// Last week sync was the problem
+		{Text: "Sync is slow and sync fails", Time: end.Add(-week - time.Hour)},
+		{Text: "Slow sync again", Time: end.Add(-week - 2*time.Hour)},
+		{Text: "Dark mode please", Time: end.Add(-week - 3*time.Hour)},
+		// Too old for either window
+		{Text: "Login bug login bug login bug", Time: end.Add(-3 * week)},
+		// In Spanish, so the Spanish stop words apply
+		{Text: "El login bug es un problema", Language: "es", Time: end.Add(-4 * time.Hour)},
+		{Text: "El login no funciona", Language: "es", Time: end.Add(-5 * time.Hour)},
+	}
+
+	report := Analyze(documents, end, week, Options{Limit: 5})
+	if report.Reviews != 9 || report.PreviousReviews != 3 || !report.Start.Equal(end.Add(-week)) {
+		t.Fatalf("unexpected windows %+v", report)
+	}
+
+	if len(report.Terms) < 2 || report.Terms[0].Term != "login" || report.Terms[0].Documents != 5 ||
+		!report.Terms[0].New || report.Terms[1].Term != "bug" {
+		t.Errorf("expected login and bug to be the top new terms, got %+v", report.Terms)
+	}
+	if len(report.Bigrams) < 2 || report.Bigrams[0].Term != "login bug" || report.Bigrams[1].Term != "dark mode" {
+		t.Errorf("expected login bug then dark mode as bigrams, got %+v", report.Bigrams)
+	}
+	sync := false
+	for _, term := range report.Terms {
+		if term.Term == "sync" {
+			sync = true
+			if term.Change >= 0 || term.PreviousDocuments != 2 {
+				t.Errorf("expected sync to be talked about less than last week, got %+v", term)
+			}
+		}
+		if term.Term == "el" {
+			t.Errorf("expected %q to be filtered out", term.Term)
+		}
+	}
+	if !sync {
+		t.Errorf("expected sync in the terms, got %+v", report.Terms)
+	}
+	if len(report.Trending) < 1 || report.Trending[0].Change <= 0 {
+		t.Errorf("expected trending terms with increasing scores, got %+v", report.Trending)
+	}
+	if len(report.Terms) > 5 || len(report.Trending) > 5 {
+		t.Errorf("expected at most 5 terms in each list")
+	}
+
+	empty := Analyze(nil, end, week, Options{})
+	if empty.Reviews != 0 || empty.Terms == nil || len(empty.Trending) != 0 {
+		t.Errorf("expected an empty report, got %+v", empty)
+	}
+}
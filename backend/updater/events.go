@@ -0,0 +1,145 @@
+package updater
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/marcuswu/app-reviews/anomaly"
+	"github.com/marcuswu/app-reviews/config"
+	"github.com/marcuswu/app-reviews/events"
+	"github.com/marcuswu/app-reviews/models"
+)
+
+// WithNotifier sets the notification channel events such as rating anomalies are sent to. Events are queued for
+// SendEvents to deliver, so saves don't wait on the channel. By default events are only logged, as they happen.
+func WithNotifier(notifier events.Notifier) Option {
+	return func(u *Updater) { u.notifier = notifier }
+}
+
+// WithEventQueue sets how many events may wait for SendEvents before new ones are dropped. By default
+// config.NOTIFY_QUEUE_SIZE.
+func WithEventQueue(size int) Option {
+	return func(u *Updater) { u.queue = make(chan events.Event, size) }
+}
+
+// SetNotifier replaces the notification channel events are sent to. It is not safe to call while reviews are being
+// saved or events sent.
+func (u *Updater) SetNotifier(notifier events.Notifier) {
+	u.notifier = notifier
+}
+
+// notify queues an event for the notification channel, dropping it with a warning if the queue is full so a slow
+// channel never holds up the save that caused the event. Without a channel the event is only logged.
+func (u *Updater) notify(ctx context.Context, event events.Event) {
+	eventsEmitted.Inc(event.Type)
+	if u.notifier == nil {
+		events.Log(u.log()).Notify(ctx, event)
+		return
+	}
+	select {
+	case u.queue <- event:
+	default:
+		eventsDropped.Inc(event.Type)
+		u.log().WarnContext(ctx, "notification queue is full, dropping event", "app", event.AppId, "event", event.Type)
+	}
+}
+
+// SendEvents delivers queued events to the notification channel until stopCtx is done, then delivers whatever is
+// still queued and returns. Each delivery uses workCtx and may take up to config.NOTIFY_TIMEOUT; errors are logged.
+func (u *Updater) SendEvents(stopCtx context.Context, workCtx context.Context) {
+	for {
+		select {
+		case <-stopCtx.Done():
+			for {
+				select {
+				case event := <-u.queue:
+					u.send(workCtx, event)
+				default:
+					return
+				}
+			}
+		case event := <-u.queue:
+			u.send(workCtx, event)
+		}
+	}
+}
+
+// send delivers one event to the notification channel, logging rather than returning any error
+func (u *Updater) send(ctx context.Context, event events.Event) {
+	ctx, cancel := context.WithTimeout(ctx, config.NOTIFY_TIMEOUT)
+	defer cancel()
+	if err := u.notifier.Notify(ctx, event); err != nil {
+		u.log().WarnContext(ctx, "unable to send event", "app", event.AppId, "event", event.Type, "error", err)
+	}
+}
+
+// checkAnomalies sends a rating anomaly event for each anomaly in an app's freshly saved reviews that hasn't already
+// been reported within the anomaly window
+func (u *Updater) checkAnomalies(ctx context.Context, appId string, reviews models.AppReviews) {
+	now := u.clock.Now()
+	for _, found := range anomaly.Detect(reviews, now, anomaly.Options{}) {
+		if !u.alerts.report(appId+"/"+found.Kind, now, time.Duration(config.ANOMALY_WINDOW_HOURS)*time.Hour) {
+			continue
+		}
+		u.notify(ctx, events.Event{
+			Type:    events.RatingAnomaly,
+			Kind:    found.Kind,
+			AppId:   appId,
+			Time:    now,
+			Message: found.Message(),
+			Details: map[string]float64{"score": found.Score, "recent": found.Recent, "baseline": found.Baseline},
+			Reviews: found.Reviews,
+		})
+	}
+}
+
+// alertCache stops the same anomaly from being reported after every refresh while it lasts
+type alertCache struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+func newAlertCache() *alertCache {
+	return &alertCache{until: map[string]time.Time{}}
+}
+
+// report returns whether an alert for key should be sent at now, and if so quiets it for the following period
+func (ac *alertCache) report(key string, now time.Time, period time.Duration) bool {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	if until, ok := ac.until[key]; ok && now.Before(until) {
+		return false
+	}
+	ac.until[key] = now.Add(period)
+	return true
+}
+
+// editEvent describes an edited review, whose last revision is the one before the edit
+func editEvent(appId string, review models.AppReview, now time.Time) events.Event {
+	earlier := review.Revisions[len(review.Revisions)-1]
+	event := events.Event{
+		Type:    events.ReviewEdited,
+		Kind:    events.TextChanged,
+		AppId:   appId,
+		Time:    now,
+		Message: fmt.Sprintf("review %s was edited", review.Id),
+		Details: map[string]float64{"previousRating": float64(earlier.Rating), "rating": float64(review.Rating)},
+		Reviews: models.AppReviews{review},
+	}
+	if earlier.Rating != review.Rating {
+		event.Kind = events.RatingChanged
+		event.Message = fmt.Sprintf("review %s rating changed from %d to %d", review.Id, earlier.Rating, review.Rating)
+	}
+	return event
+}
+
+// removedEvent describes reviews that disappeared from an app's feed
+func removedEvent(appId string, removed models.AppReviews, now time.Time) events.Event {
+	message := fmt.Sprintf("%d reviews disappeared from the feed", len(removed))
+	if len(removed) == 1 {
+		message = fmt.Sprintf("review %s disappeared from the feed", removed[0].Id)
+	}
+	return events.Event{Type: events.ReviewRemoved, AppId: appId, Time: now, Message: message, Reviews: removed}
+}
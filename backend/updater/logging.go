@@ -0,0 +1,18 @@
+package updater
+
+import "log/slog"
+
+var logger *slog.Logger
+
+// SetLogger sets the logger used by the updater package. A nil logger uses slog.Default()
+func SetLogger(l *slog.Logger) {
+	logger = l
+}
+
+// log returns the package logger
+func log() *slog.Logger {
+	if logger == nil {
+		return slog.Default()
+	}
+	return logger
+}
@@ -0,0 +1,24 @@
+package updater
+
+import "github.com/marcuswu/app-reviews/metrics"
+
+var (
+	upstreamRequests = metrics.NewCounter("app_reviews_upstream_requests_total",
+		"Requests made to the Apple review feed by app and response status code", "app", "code")
+	upstreamLatency = metrics.NewHistogram("app_reviews_upstream_request_duration_seconds",
+		"Latency of requests made to the Apple review feed by app", nil, "app")
+	pagesPerRefresh = metrics.NewHistogram("app_reviews_upstream_pages_per_refresh",
+		"Number of feed pages fetched each time an app's reviews are fetched", []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+	reviewsDiscovered = metrics.NewCounter("app_reviews_discovered_total",
+		"Reviews saved to cache that were not in the app's previous cache", "app")
+	refreshQueueDepth = metrics.NewGauge("app_reviews_refresh_queue_depth",
+		"Number of cached apps whose cache is stale and waiting to be refreshed")
+	noFeedHits = metrics.NewCounter("app_reviews_no_feed_cache_hits_total",
+		"Fetches skipped because the app was recently found to have no review feed")
+	eventsEmitted = metrics.NewCounter("app_reviews_events_total",
+		"Events, such as rating anomalies, sent to the notification channel by type", "type")
+	eventsDropped = metrics.NewCounter("app_reviews_events_dropped_total",
+		"Events dropped by type because the notification queue was full", "type")
+	refreshLag = metrics.NewGauge("app_reviews_refresh_lag_seconds",
+		"How long the stalest cached app has been waiting for a refresh past its maximum age")
+)
@@ -0,0 +1,57 @@
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNoFeed is returned when Apple serves no reviews for an app, usually because the app id does not exist
+var ErrNoFeed = errors.New("app has no review feed")
+
+// UpstreamError is returned when Apple answers a review request with an error other than 404, such as 429 when it is
+// throttling requests. Unlike ErrNoFeed it says nothing about whether the app has reviews.
+type UpstreamError struct {
+	StatusCode int
+	Page       int
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("apple answered page %d with status %d", e.Page, e.StatusCode)
+}
+
+// noFeedCache remembers apps without a review feed so repeated requests for them don't reach Apple. The refresher
+// also uses one to remember apps whose last refresh failed.
+type noFeedCache struct {
+	mu    sync.Mutex
+	until map[string]time.Time // by storefront and app id
+}
+
+func newNoFeedCache() *noFeedCache {
+	return &noFeedCache{until: map[string]time.Time{}}
+}
+
+func noFeedKey(appId string, storefront string) string {
+	return storefront + "/" + appId
+}
+
+// has reports whether an app is known to have no feed at now, dropping the entry if it has expired
+func (nf *noFeedCache) has(appId string, storefront string, now time.Time) bool {
+	nf.mu.Lock()
+	defer nf.mu.Unlock()
+	key := noFeedKey(appId, storefront)
+	until, ok := nf.until[key]
+	if ok && !now.Before(until) {
+		delete(nf.until, key)
+		return false
+	}
+	return ok
+}
+
+// add remembers that an app has no feed until the provided time
+func (nf *noFeedCache) add(appId string, storefront string, until time.Time) {
+	nf.mu.Lock()
+	defer nf.mu.Unlock()
+	nf.until[noFeedKey(appId, storefront)] = until
+}
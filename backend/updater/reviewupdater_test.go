@@ -1,9 +1,16 @@
 package updater
 
 import (
+	"context"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/marcuswu/app-reviews/models"
+	"github.com/marcuswu/app-reviews/spam"
+	"github.com/marcuswu/app-reviews/tagging"
 )
 
 func TestAppFileMatching(t *testing.T) {
@@ -94,3 +101,135 @@ func TestNextApp(t *testing.T) {
 		}
 	}
 }
+
+func TestRefreshQueueMetrics(t *testing.T) {
+	apps := []appWithAge{
+		{"123456789", 601},
+		{"987654321", 302},
+		{"1234567890", 1001},
+	}
+	setupNextAppTest(apps)
+	defer teardownNextAppTest(apps)
+
+	files := make([]string, 0, len(apps))
+	for _, app := range apps {
+		files = append(files, fileForAppId(app.id))
+	}
+	nextApp(files)
+
+	if refreshQueueDepth.Value() != 2 {
+		t.Errorf("expected 2 stale apps in the queue, got %f", refreshQueueDepth.Value())
+	}
+	// The stalest app is 1001 seconds old with a 600 second maximum age
+	if lag := refreshLag.Value(); lag < 400 || lag > 402 {
+		t.Errorf("expected about 401 seconds of lag, got %f", lag)
+	}
+}
+
+func TestSaveReviewsAtomically(t *testing.T) {
+	appId := "555555555"
+	defer os.Remove(fileForAppId(appId))
+
+	reviews := models.AppReviews{models.AppReview{Id: "1", Title: "First"}}
+	if err := SaveReviews(context.Background(), appId, reviews); err != nil {
+		t.Fatalf("expected no error saving reviews, got %s", err)
+	}
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	updated := models.AppReviews{models.AppReview{Id: "2", Title: "Second"}}
+	if err := SaveReviews(cancelled, appId, updated); err == nil {
+		t.Errorf("expected an error saving with a cancelled context")
+	}
+
+	saved, _, err := Default().Store().Load(appId)
+	if err != nil || len(saved) != 1 || saved[0].Id != "1" {
+		t.Errorf("expected the first save to be left in place, got %v (%v)", saved, err)
+	}
+
+	leftovers, _ := filepath.Glob(".App-*.tmp")
+	if len(leftovers) > 0 {
+		t.Errorf("expected no temporary files to be left behind, found %v", leftovers)
+	}
+}
+
+func TestRetagReviews(t *testing.T) {
+	dir := t.TempDir()
+	u := New(WithStore(NewFileStore(dir)))
+	appId := "666666666"
+	reviews := models.AppReviews{
+		{Id: "1", Rating: 1, Title: "Crashes", Content: "It crashes on launch"},
+		{Id: "2", Rating: 2, Title: "Refund", Content: "I was charged twice"},
+	}
+	if err := u.SaveReviews(context.Background(), appId, reviews); err != nil {
+		t.Fatal(err)
+	}
+	saved, _, _ := u.Store().Load(appId)
+	if len(saved) != 2 || strings.Join(saved[0].Tags, ",") != "crash" || strings.Join(saved[1].Tags, ",") != "billing" {
+		t.Fatalf("expected reviews to be tagged when saved, got %+v", saved)
+	}
+
+	modified := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := u.Store().(*FileStore).SetModified(appId, modified); err != nil {
+		t.Fatal(err)
+	}
+	rules, err := tagging.Parse([]byte(`{"rules":[{"tag":"angry","maxRating":1}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u.SetTagRules(rules)
+	changed, err := u.RetagReviews(context.Background())
+	if err != nil || changed != 2 {
+		t.Fatalf("expected both reviews to be re-tagged, got %d (%v)", changed, err)
+	}
+	saved, savedAt, _ := u.Store().Load(appId)
+	if strings.Join(saved[0].Tags, ",") != "angry" || len(saved[1].Tags) != 0 || saved[1].Tags == nil {
+		t.Errorf("expected the new rules to be applied, got %+v", saved)
+	}
+	if !savedAt.Equal(modified) {
+		t.Errorf("expected re-tagging to keep the cache modified time %s, got %s", modified, savedAt)
+	}
+
+	if changed, err = u.RetagReviews(context.Background()); err != nil || changed != 0 {
+		t.Errorf("expected nothing to change re-tagging again, got %d (%v)", changed, err)
+	}
+}
+
+func TestDetectSpamAcrossApps(t *testing.T) {
+	u := New(WithStore(NewFileStore(t.TempDir())))
+	pasted := "Download now and use code FREE100 to get one hundred coins for free today"
+	copied := func(id string, author string) models.AppReviews {
+		return models.AppReviews{{Id: id, Author: models.Author{Name: author}, Content: pasted}}
+	}
+	modified := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for _, appId := range []string{"111111111", "222222222"} {
+		if err := u.SaveReviews(context.Background(), appId, copied(appId[:1], "bot"+appId[:1])); err != nil {
+			t.Fatal(err)
+		}
+		u.Store().(*FileStore).SetModified(appId, modified)
+	}
+	if saved, _, _ := u.Store().Load("222222222"); len(saved[0].Spam) != 0 {
+		t.Fatalf("expected saving not to flag spam, got %v", saved[0].Spam)
+	}
+
+	if changed, err := u.DetectSpam(context.Background()); err != nil || changed != 2 {
+		t.Fatalf("expected both copies to be flagged, got %d (%v)", changed, err)
+	}
+	for _, appId := range []string{"111111111", "222222222"} {
+		saved, savedAt, _ := u.Store().Load(appId)
+		if strings.Join(saved[0].Spam, ",") != spam.Duplicate || !savedAt.Equal(modified) {
+			t.Errorf("expected app %s to be flagged keeping its modified time, got %v at %s", appId, saved[0].Spam, savedAt)
+		}
+	}
+
+	// Fetched reviews have no flags, so saving them again keeps the flags the cache had
+	if err := u.SaveReviews(context.Background(), "222222222", copied("2", "bot2")); err != nil {
+		t.Fatal(err)
+	}
+	if saved, _, _ := u.Store().Load("222222222"); strings.Join(saved[0].Spam, ",") != spam.Duplicate {
+		t.Errorf("expected saving to keep the spam flags, got %v", saved[0].Spam)
+	}
+	if changed, err := u.DetectSpam(context.Background()); err != nil || changed != 0 {
+		t.Errorf("expected nothing to change checking again, got %d (%v)", changed, err)
+	}
+}
@@ -0,0 +1,163 @@
+package updater
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// upstreamWindow is how far back upstream request outcomes are kept for the error rate
+const upstreamWindow = time.Hour
+
+// AppStatus reports the state of a single app's cache
+type AppStatus struct {
+	AppId           string     `json:"appId"`
+	CacheAgeSeconds float64    `json:"cacheAgeSeconds"`
+	LastRefresh     *time.Time `json:"lastRefresh,omitempty"` // by the background refresher
+	LastError       string     `json:"lastError,omitempty"`
+}
+
+// Status reports the health of the background refresher, the cache and requests to Apple
+type Status struct {
+	RefresherLastRun  time.Time   `json:"refresherLastRun"`
+	Apps              []AppStatus `json:"apps"`
+	UpstreamRequests  int         `json:"upstreamRequests"` // within the last hour
+	UpstreamErrors    int         `json:"upstreamErrors"`   // within the last hour
+	UpstreamErrorRate float64     `json:"upstreamErrorRate"`
+	CacheWritable     bool        `json:"cacheWritable"`
+	CacheError        string      `json:"cacheError,omitempty"`
+}
+
+type appRefresh struct {
+	lastRefresh time.Time
+	lastError   string
+}
+
+type upstreamResult struct {
+	at     time.Time
+	failed bool
+}
+
+// statusTracker keeps the refresher and upstream history needed to report an Updater's status
+type statusTracker struct {
+	mu       sync.Mutex
+	lastRun  time.Time
+	apps     map[string]*appRefresh
+	upstream []upstreamResult
+}
+
+func newStatusTracker() *statusTracker {
+	return &statusTracker{apps: map[string]*appRefresh{}}
+}
+
+// recordRun notes that the refresher has run, whether or not it found anything to refresh
+func (st *statusTracker) recordRun(now time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.lastRun = now
+}
+
+// recordRefresh notes the outcome of refreshing an app's cache
+func (st *statusTracker) recordRefresh(appId string, now time.Time, err error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	refresh, ok := st.apps[appId]
+	if !ok {
+		refresh = &appRefresh{}
+		st.apps[appId] = refresh
+	}
+	refresh.lastRefresh = now
+	refresh.lastError = ""
+	if err != nil {
+		refresh.lastError = err.Error()
+	}
+}
+
+// recordUpstream notes the outcome of a request to Apple and drops outcomes older than upstreamWindow
+func (st *statusTracker) recordUpstream(now time.Time, failed bool) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.upstream = append(st.upstream, upstreamResult{at: now, failed: failed})
+
+	keep := 0
+	for keep < len(st.upstream) && now.Sub(st.upstream[keep].at) > upstreamWindow {
+		keep++
+	}
+	st.upstream = st.upstream[keep:]
+}
+
+// RefresherLastRun returns when the refresher last ran, or the zero time if it never has
+func (u *Updater) RefresherLastRun() time.Time {
+	u.status.mu.Lock()
+	defer u.status.mu.Unlock()
+	return u.status.lastRun
+}
+
+// CheckCacheWritable returns an error if the store can't be written to. Stores that can't tell are assumed to be
+// writable.
+func (u *Updater) CheckCacheWritable(ctx context.Context) error {
+	if checker, ok := u.store.(interface{ CheckWritable() error }); ok {
+		return checker.CheckWritable()
+	}
+	return nil
+}
+
+// Status reports the refresher, per app cache and upstream status
+func (u *Updater) Status(ctx context.Context) Status {
+	status := Status{Apps: []AppStatus{}, CacheWritable: true}
+	if err := u.CheckCacheWritable(ctx); err != nil {
+		status.CacheWritable = false
+		status.CacheError = err.Error()
+	}
+	apps, err := u.store.List()
+	if err != nil {
+		u.log().WarnContext(ctx, "failed to list cached apps", "error", err)
+	}
+
+	u.status.mu.Lock()
+	defer u.status.mu.Unlock()
+
+	now := u.clock.Now()
+	status.RefresherLastRun = u.status.lastRun
+	for _, cached := range apps {
+		app := AppStatus{AppId: cached.AppId, CacheAgeSeconds: now.Sub(cached.Modified).Seconds()}
+		if refresh, ok := u.status.apps[app.AppId]; ok {
+			lastRefresh := refresh.lastRefresh
+			app.LastRefresh = &lastRefresh
+			app.LastError = refresh.lastError
+		}
+		status.Apps = append(status.Apps, app)
+	}
+	sort.Slice(status.Apps, func(i, j int) bool { return status.Apps[i].AppId < status.Apps[j].AppId })
+
+	for _, result := range u.status.upstream {
+		if now.Sub(result.at) > upstreamWindow {
+			continue
+		}
+		status.UpstreamRequests++
+		if result.failed {
+			status.UpstreamErrors++
+		}
+	}
+	if status.UpstreamRequests > 0 {
+		status.UpstreamErrorRate = float64(status.UpstreamErrors) / float64(status.UpstreamRequests)
+	}
+
+	return status
+}
+
+// RefresherLastRun returns when the default updater's refresher last ran, or the zero time if it never has
+func RefresherLastRun() time.Time {
+	return defaultUpdater.RefresherLastRun()
+}
+
+// CheckCacheWritable returns an error if the cache directory cannot be written to
+func CheckCacheWritable() error {
+	return defaultUpdater.CheckCacheWritable(context.Background())
+}
+
+// CurrentStatus reports the default updater's refresher, per app cache and upstream status
+func CurrentStatus() Status {
+	return defaultUpdater.Status(context.Background())
+}
@@ -0,0 +1,63 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCurrentStatus(t *testing.T) {
+	apps := []appWithAge{{"123456789", 30}, {"987654321", 700}}
+	setupNextAppTest(apps)
+	defer teardownNextAppTest(apps)
+
+	now := time.Now()
+	defaultUpdater.status.recordRun(now)
+	defaultUpdater.status.recordRefresh("987654321", now, errors.New("upstream unavailable"))
+	defaultUpdater.status.recordUpstream(now, false)
+	defaultUpdater.status.recordUpstream(now, false)
+	defaultUpdater.status.recordUpstream(now, false)
+	defaultUpdater.status.recordUpstream(now, true)
+
+	status := CurrentStatus()
+	if status.RefresherLastRun.IsZero() || !status.RefresherLastRun.Equal(RefresherLastRun()) {
+		t.Errorf("expected the refresher run to be reported, got %s", status.RefresherLastRun)
+	}
+	if !status.CacheWritable {
+		t.Errorf("expected the cache directory to be writable: %s", status.CacheError)
+	}
+	if status.UpstreamRequests != 4 || status.UpstreamErrors != 1 || status.UpstreamErrorRate != 0.25 {
+		t.Errorf("expected 1 of 4 upstream requests to fail, got %d of %d (%f)",
+			status.UpstreamErrors, status.UpstreamRequests, status.UpstreamErrorRate)
+	}
+
+	if len(status.Apps) != 2 {
+		t.Fatalf("expected 2 apps, got %d", len(status.Apps))
+	}
+	if status.Apps[0].AppId != "123456789" || status.Apps[0].LastRefresh != nil || status.Apps[0].CacheAgeSeconds < 29 {
+		t.Errorf("unexpected status for the fresh app %+v", status.Apps[0])
+	}
+	if status.Apps[1].LastError != "upstream unavailable" || status.Apps[1].LastRefresh == nil {
+		t.Errorf("expected the failed refresh to be reported, got %+v", status.Apps[1])
+	}
+}
+
+func TestStatusCountsThrottlingAsUpstreamError(t *testing.T) {
+	statuses := map[string]int{"/us/rss/customerreviews/id=1/sortBy=mostRecent/page=1/json": http.StatusTooManyRequests,
+		"/us/rss/customerreviews/id=2/sortBy=mostRecent/page=1/json": http.StatusNotFound}
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(statuses[req.URL.Path])
+	}))
+	defer server.Close()
+
+	u := New(WithBaseURL(server.URL), WithHTTPClient(server.Client()), WithStore(newMemoryStore(time.Now)))
+	u.FetchAppReviews(context.Background(), "1")
+	u.FetchAppReviews(context.Background(), "2")
+	if status := u.Status(context.Background()); status.UpstreamRequests != 2 || status.UpstreamErrors != 1 {
+		t.Errorf("expected only the 429 to count as an upstream error, got %d of %d", status.UpstreamErrors,
+			status.UpstreamRequests)
+	}
+}
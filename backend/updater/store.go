@@ -0,0 +1,147 @@
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/marcuswu/app-reviews/models"
+)
+
+// CachedApp is an app with reviews in a store
+type CachedApp struct {
+	AppId    string
+	Modified time.Time // when the app's reviews were last saved
+}
+
+// Store is where app reviews are cached between fetches
+type Store interface {
+	// Load returns an app's cached reviews and when they were saved, regardless of how old they are
+	Load(appId string) (models.AppReviews, time.Time, error)
+	// Save replaces an app's cached reviews
+	Save(appId string, reviews models.AppReviews) error
+	// Remove removes an app's cached reviews
+	Remove(appId string) error
+	// List returns every app with cached reviews
+	List() ([]CachedApp, error)
+}
+
+// modifiedSetter is implemented by stores that can change when an app's reviews were saved, so rewriting them
+// without fetching (such as when re-tagging) doesn't change how fresh they look
+type modifiedSetter interface {
+	SetModified(appId string, modified time.Time) error
+}
+
+// FileStore caches each app's reviews in an App-{appId}.json file in a directory
+type FileStore struct {
+	Dir string
+}
+
+// NewFileStore creates a store keeping cache files in dir
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{Dir: dir}
+}
+
+func (fs *FileStore) path(appId string) string {
+	return filepath.Join(fs.Dir, fileForAppId(appId))
+}
+
+func (fs *FileStore) Load(appId string) (models.AppReviews, time.Time, error) {
+	filename := fs.path(appId)
+	fileInfo, err := os.Stat(filename)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	file, err := os.OpenFile(filename, os.O_RDONLY, 0000)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	defer file.Close()
+
+	reviews, err := models.LoadReviews(file)
+	return reviews, fileInfo.ModTime(), err
+}
+
+// Save writes to a temporary file and moves it into place so readers (and a shutdown part way through) never see
+// a partially written cache file
+func (fs *FileStore) Save(appId string, reviews models.AppReviews) error {
+	filename := fs.path(appId)
+	file, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if err = models.SaveReviews(file, reviews); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	if err = os.Chmod(file.Name(), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), filename)
+}
+
+// SetModified sets the modified time of an app's cache file
+func (fs *FileStore) SetModified(appId string, modified time.Time) error {
+	return os.Chtimes(fs.path(appId), modified, modified)
+}
+
+func (fs *FileStore) Remove(appId string) error {
+	return os.Remove(fs.path(appId))
+}
+
+func (fs *FileStore) List() ([]CachedApp, error) {
+	files, err := filepath.Glob(filepath.Join(fs.Dir, "App-[0-9]*.json"))
+	if err != nil {
+		return nil, err
+	}
+	return statCacheFiles(files), nil
+}
+
+// CheckWritable returns an error if the cache directory cannot be written to
+func (fs *FileStore) CheckWritable() error {
+	file, err := os.CreateTemp(fs.Dir, ".writable-*")
+	if err != nil {
+		return err
+	}
+	file.Close()
+	return os.Remove(file.Name())
+}
+
+// statCacheFiles returns the cached apps for a list of cache files, skipping any that aren't regular files
+func statCacheFiles(files []string) []CachedApp {
+	apps := make([]CachedApp, 0, len(files))
+	for _, filename := range files {
+		fi, err := os.Stat(filename)
+		if err != nil {
+			log().Warn("failed to stat cache file", "file", filename, "error", err)
+			continue
+		}
+		if !fi.Mode().IsRegular() {
+			log().Warn("cache file is not a regular file", "file", filename)
+			continue
+		}
+		apps = append(apps, CachedApp{AppId: AppIdForFile(filename), Modified: fi.ModTime()})
+	}
+	sort.Slice(apps, func(i, j int) bool { return apps[i].AppId < apps[j].AppId })
+	return apps
+}
+
+// fileForAppId returns the filename to store or retrieve app reviews to for a given app id
+func fileForAppId(appId string) string {
+	return fmt.Sprintf("App-%s.json", appId)
+}
+
+// isNotCached reports whether a store error means the app simply has no cached reviews
+func isNotCached(err error) bool {
+	return errors.Is(err, os.ErrNotExist)
+}
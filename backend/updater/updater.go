@@ -0,0 +1,557 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/marcuswu/app-reviews/clock"
+	"github.com/marcuswu/app-reviews/config"
+	"github.com/marcuswu/app-reviews/events"
+	"github.com/marcuswu/app-reviews/models"
+	"github.com/marcuswu/app-reviews/spam"
+	"github.com/marcuswu/app-reviews/tagging"
+)
+
+// DefaultBaseURL is the Apple iTunes host the review RSS feed is served from
+const DefaultBaseURL = "https://itunes.apple.com"
+
+// Updater fetches app reviews from Apple and keeps them cached in a store.
+// Create one with New; the zero value is not usable.
+type Updater struct {
+	client   *http.Client
+	store    Store
+	clock    clock.Clock
+	logger   *slog.Logger
+	baseURL  string
+	window   time.Duration // how far back fetches page through reviews, zero for every page Apple serves
+	maxAge   time.Duration // how old a cache can be before it is refreshed
+	noFeed   time.Duration // how long to remember apps without a feed, zero to always ask Apple
+	status   *statusTracker
+	noFeeds  *noFeedCache
+	retries  *noFeedCache      // apps the refresher skips until it may retry their failed refresh
+	alerts   *alertCache       // anomalies recently sent to the notifier
+	notifier events.Notifier   // where events are sent, nil to only log them
+	queue    chan events.Event // events waiting for SendEvents
+	tags     atomic.Pointer[tagging.RuleSet]
+	saving   sync.Mutex // stops re-tagging from overwriting reviews saved while it runs
+}
+
+// Option configures an Updater
+type Option func(*Updater)
+
+// WithHTTPClient sets the client used to request reviews from Apple
+func WithHTTPClient(client *http.Client) Option {
+	return func(u *Updater) { u.client = client }
+}
+
+// WithStore sets where reviews are cached
+func WithStore(store Store) Option {
+	return func(u *Updater) { u.store = store }
+}
+
+// WithClock sets the clock used to judge cache freshness
+func WithClock(c clock.Clock) Option {
+	return func(u *Updater) { u.clock = c }
+}
+
+// WithLogger sets the logger. By default the updater package logger is used.
+func WithLogger(logger *slog.Logger) Option {
+	return func(u *Updater) { u.logger = logger }
+}
+
+// WithBaseURL sets the host reviews are requested from, mostly useful for pointing at a test server
+func WithBaseURL(baseURL string) Option {
+	return func(u *Updater) { u.baseURL = strings.TrimSuffix(baseURL, "/") }
+}
+
+// WithWindow stops fetches from requesting more pages once they reach reviews older than the window.
+// A zero window fetches every page Apple serves.
+func WithWindow(window time.Duration) Option {
+	return func(u *Updater) { u.window = window }
+}
+
+// WithMaxAge sets how old an app's cache can get before it is considered stale
+func WithMaxAge(maxAge time.Duration) Option {
+	return func(u *Updater) { u.maxAge = maxAge }
+}
+
+// WithNoFeedTTL sets how long fetches for an app without a review feed fail with ErrNoFeed before Apple is asked
+// again. Zero always asks Apple.
+func WithNoFeedTTL(ttl time.Duration) Option {
+	return func(u *Updater) { u.noFeed = ttl }
+}
+
+// WithTagRules sets the rules used to tag reviews as they are saved or enriched. By default tagging.Default is used.
+func WithTagRules(rules *tagging.RuleSet) Option {
+	return func(u *Updater) { u.tags.Store(rules) }
+}
+
+// New creates an Updater. Without options it behaves like the package level functions: it uses
+// http.DefaultClient, caches in the working directory and refreshes caches older than
+// config.MAX_REVIEW_FILE_AGE_MINUTES.
+func New(options ...Option) *Updater {
+	u := &Updater{
+		client:  http.DefaultClient,
+		store:   NewFileStore("."),
+		clock:   clock.System,
+		baseURL: DefaultBaseURL,
+		maxAge:  time.Duration(config.MAX_REVIEW_FILE_AGE_MINUTES) * time.Minute,
+		noFeed:  time.Duration(config.NO_FEED_CACHE_MINUTES) * time.Minute,
+		status:  newStatusTracker(),
+		noFeeds: newNoFeedCache(),
+		retries: newNoFeedCache(),
+		alerts:  newAlertCache(),
+		queue:   make(chan events.Event, config.NOTIFY_QUEUE_SIZE),
+	}
+	u.tags.Store(tagging.Default())
+	for _, option := range options {
+		option(u)
+	}
+	return u
+}
+
+// defaultUpdater backs the package level functions
+var defaultUpdater = New()
+
+// Default returns the Updater used by the package level functions
+func Default() *Updater {
+	return defaultUpdater
+}
+
+// SetDefault replaces the Updater used by the package level functions, such as with one using a fake clock in
+// tests. It is not safe to call while the package level functions are in use.
+func SetDefault(u *Updater) {
+	defaultUpdater = u
+}
+
+func (u *Updater) log() *slog.Logger {
+	if u.logger != nil {
+		return u.logger
+	}
+	return log()
+}
+
+// Store returns the store reviews are cached in
+func (u *Updater) Store() Store {
+	return u.store
+}
+
+// TagRules returns the rules reviews are tagged with
+func (u *Updater) TagRules() *tagging.RuleSet {
+	return u.tags.Load()
+}
+
+// SetTagRules replaces the rules reviews are tagged with. It is safe to call while fetches are running; call
+// RetagReviews to apply the new rules to reviews that are already cached.
+func (u *Updater) SetTagRules(rules *tagging.RuleSet) {
+	u.tags.Store(rules)
+}
+
+// FetchAppReviews retrieves the provided app's reviews from the default storefront
+func (u *Updater) FetchAppReviews(ctx context.Context, appId string) (models.AppReviews, error) {
+	return u.FetchStorefrontReviews(ctx, appId, config.DEFAULT_STOREFRONT)
+}
+
+// KnownNoFeed reports whether an app is remembered to have no review feed in the default storefront, so fetching it
+// would return ErrNoFeed without asking Apple. Callers can use it to skip charging for such fetches; a true answer
+// counts as a skipped fetch.
+func (u *Updater) KnownNoFeed(appId string) bool {
+	if !u.noFeeds.has(appId, config.DEFAULT_STOREFRONT, u.clock.Now()) {
+		return false
+	}
+	noFeedHits.Inc()
+	return true
+}
+
+// FetchStorefrontReviews retrieves reviews for the provided app id from a specific storefront (country code)
+// The context cancels any in flight request and is used for logging. Reviews are returned as Apple serves them and
+// enriched when saved; call Enrich for reviews that won't be. Returns ErrNoFeed if Apple has no reviews for the app,
+// without asking Apple if it had none the last time it was fetched. Any other failure, including one partway through
+// the pages, returns no reviews so callers keep the reviews they have; Apple answering with an error status is an
+// *UpstreamError.
+func (u *Updater) FetchStorefrontReviews(ctx context.Context, appId string, storefront string) (models.AppReviews, error) {
+	if u.noFeeds.has(appId, storefront, u.clock.Now()) {
+		noFeedHits.Inc()
+		u.log().DebugContext(ctx, "app is known to have no review feed", "app", appId, "storefront", storefront)
+		return nil, ErrNoFeed
+	}
+
+	page := 1
+	pages := 0
+	defer func() { pagesPerRefresh.Observe(float64(pages)) }()
+	oldest := time.Time{}
+	if u.window > 0 {
+		oldest = u.clock.Now().Add(-u.window)
+	}
+	reviews := make(models.AppReviews, 0, config.OLDEST_REVIEW_HOURS)
+	for needMore := true; needMore; page++ {
+		url := fmt.Sprintf("%s/%s/rss/customerreviews/id=%s/sortBy=mostRecent/page=%d/json",
+			u.baseURL, storefront, appId, page)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return reviews, err
+		}
+
+		// Latency is measured with the real clock since it is reported, not used for decisions
+		start := time.Now()
+		res, err := u.client.Do(req)
+		upstreamLatency.Observe(time.Since(start).Seconds(), appId)
+		if err != nil {
+			upstreamRequests.Inc(appId, "error")
+			u.status.recordUpstream(u.clock.Now(), true)
+			u.log().WarnContext(ctx, "app review request failed", "app", appId, "page", page, "error", err)
+			return nil, err
+		}
+		upstreamRequests.Inc(appId, strconv.Itoa(res.StatusCode))
+		// 404 is how Apple answers for apps without reviews, anything else outside 2xx (such as 429) is a failure
+		u.status.recordUpstream(u.clock.Now(), res.StatusCode/100 != 2 && res.StatusCode != http.StatusNotFound)
+		u.log().DebugContext(ctx, "fetched app review page", "app", appId, "storefront", storefront, "page", page,
+			"status", res.StatusCode, "duration", time.Since(start))
+		if res.StatusCode == http.StatusNotFound {
+			// The app has no feed, or there are no pages past this one
+			res.Body.Close()
+			break
+		}
+		if res.StatusCode/100 != 2 {
+			res.Body.Close()
+			u.log().WarnContext(ctx, "apple answered app review request with an error", "app", appId,
+				"storefront", storefront, "page", page, "status", res.StatusCode)
+			return nil, &UpstreamError{StatusCode: res.StatusCode, Page: page}
+		}
+
+		resBody, err := io.ReadAll(res.Body)
+		res.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		pages++
+
+		feed := models.AppReviewFeed{}
+		if err := json.Unmarshal(resBody, &feed); err != nil {
+			return nil, err
+		}
+
+		for _, review := range feed.Reviews {
+			reviews = append(reviews, review.AppReview())
+		}
+		if len(reviews) < 1 || len(feed.Reviews) < 1 {
+			needMore = false
+			continue
+		}
+		// Keep requesting more reviews until we find a page with a review older than we need
+		if (!oldest.IsZero() && reviews[len(reviews)-1].Updated.Before(oldest)) || page >= config.MAX_FEED_PAGES {
+			needMore = false
+		}
+
+		u.log().DebugContext(ctx, "fetched reviews", "app", appId, "reviews", len(reviews), "page", page)
+	}
+	u.log().InfoContext(ctx, "finished fetching reviews", "app", appId, "storefront", storefront,
+		"reviews", len(reviews), "pages", pages)
+
+	if len(reviews) < 1 {
+		if u.noFeed > 0 {
+			u.noFeeds.add(appId, storefront, u.clock.Now().Add(u.noFeed))
+		}
+		return reviews, ErrNoFeed
+	}
+	return reviews, nil
+}
+
+// SaveReviews scores the sentiment of a list of app reviews, detects their language, tags them, keeps the spam flags
+// cached reviews already had, keeps the earlier revisions of reviews that were edited, keeps cached reviews that
+// disappeared from the feed marked as removed and saves them to the store. New reviews are checked for spam by the
+// next DetectSpam. Once saved, edits, removals and rating anomalies are queued for the notifier. Nothing is written
+// if the context is already done.
+func (u *Updater) SaveReviews(ctx context.Context, appId string, reviews models.AppReviews) error {
+	u.Enrich(reviews)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	found, err := u.save(ctx, appId, reviews)
+	if err != nil {
+		return err
+	}
+	for _, review := range found.edited {
+		u.notify(ctx, editEvent(appId, review, u.clock.Now()))
+	}
+	if len(found.removed) > 0 {
+		u.notify(ctx, removedEvent(appId, found.removed, u.clock.Now()))
+	}
+	u.checkAnomalies(ctx, appId, reviews)
+	return nil
+}
+
+// Enrich scores the sentiment of reviews, detects their language and tags them with the current tag rules. Fetched
+// reviews are enriched when they are saved, so only reviews that are fetched without being saved need it.
+func (u *Updater) Enrich(reviews models.AppReviews) {
+	reviews.ScoreSentiment()
+	reviews.DetectLanguage()
+	u.TagRules().Apply(reviews)
+}
+
+// changes are what happened to an app's reviews since its cache was last saved
+type changes struct {
+	edited  models.AppReviews
+	removed models.AppReviews
+}
+
+// save carries over spam flags, revisions and removed reviews from the app's cache and saves reviews, holding
+// u.saving so re-tagging and spam checks don't overwrite them
+func (u *Updater) save(ctx context.Context, appId string, reviews models.AppReviews) (changes, error) {
+	u.saving.Lock()
+	defer u.saving.Unlock()
+	previous, _, err := u.store.Load(appId)
+	if err != nil && !isNotCached(err) {
+		u.log().WarnContext(ctx, "unable to load cached reviews to track changes", "app", appId, "error", err)
+	}
+	keepSpamFlags(reviews, previous)
+	found := changes{}
+	discovered, edited := reviews.TrackRevisions(previous)
+	found.edited = edited
+	reviews, found.removed = reviews.TrackRemoved(previous, u.clock.Now())
+	reviewsDiscovered.Add(float64(discovered), appId)
+	u.log().DebugContext(ctx, "saving reviews to cache", "app", appId, "reviews", len(reviews), "discovered", discovered,
+		"edited", len(found.edited), "removed", len(found.removed))
+
+	return found, u.store.Save(appId, reviews)
+}
+
+// LoadReviews loads an app's cached app reviews.
+// Returns an error if unable to read the reviews or ErrStaleCache if the cache is too stale to use
+func (u *Updater) LoadReviews(ctx context.Context, appId string) (models.AppReviews, error) {
+	reviews, modified, err := u.store.Load(appId)
+	if err != nil {
+		if isNotCached(err) {
+			u.log().DebugContext(ctx, "app is not cached", "app", appId)
+		} else {
+			u.log().WarnContext(ctx, "unable to load cached reviews", "app", appId, "error", err)
+		}
+		return nil, err
+	}
+
+	if u.clock.Now().Sub(modified) > u.maxAge {
+		u.log().DebugContext(ctx, "cache is stale", "app", appId, "modified", modified)
+		return nil, ErrStaleCache
+	}
+
+	return reviews, nil
+}
+
+// RemoveReviews removes an app's cached reviews
+func (u *Updater) RemoveReviews(ctx context.Context, appId string) error {
+	u.log().InfoContext(ctx, "removing cached reviews", "app", appId)
+	return u.store.Remove(appId)
+}
+
+// ListApps returns every app with cached reviews
+func (u *Updater) ListApps(ctx context.Context) ([]CachedApp, error) {
+	return u.store.List()
+}
+
+// RetagReviews applies the current tag rules to every cached app's reviews, regardless of how old the cache is, and
+// returns how many reviews' tags changed. Apps whose tags don't change aren't rewritten, and stores that can keep
+// the time an app was saved do so, so re-tagging doesn't make a cache look fresh.
+func (u *Updater) RetagReviews(ctx context.Context) (int, error) {
+	apps, err := u.store.List()
+	if err != nil {
+		return 0, err
+	}
+	rules := u.TagRules()
+	total := 0
+	for _, app := range apps {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		changed, err := u.retagApp(ctx, app.AppId, rules)
+		if err != nil {
+			return total, err
+		}
+		total += changed
+	}
+	u.log().InfoContext(ctx, "tagged cached reviews", "apps", len(apps), "changed", total, "rules", rules.Version)
+	return total, nil
+}
+
+// retagApp applies rules to one app's cached reviews and saves them if any tags changed
+func (u *Updater) retagApp(ctx context.Context, appId string, rules *tagging.RuleSet) (int, error) {
+	u.saving.Lock()
+	defer u.saving.Unlock()
+	reviews, modified, err := u.store.Load(appId)
+	if err != nil {
+		u.log().WarnContext(ctx, "unable to load cached reviews to tag", "app", appId, "error", err)
+		return 0, nil
+	}
+	changed := rules.Apply(reviews)
+	if changed < 1 {
+		return 0, nil
+	}
+	if err := u.rewrite(ctx, appId, reviews, modified); err != nil {
+		return 0, fmt.Errorf("saving tagged reviews for app %s: %w", appId, err)
+	}
+	u.log().DebugContext(ctx, "tagged cached reviews", "app", appId, "changed", changed)
+	return changed, nil
+}
+
+// rewrite saves reviews that changed without being fetched, keeping when the app was last saved if the store
+// can, so the cache doesn't look fresher than it is
+func (u *Updater) rewrite(ctx context.Context, appId string, reviews models.AppReviews, modified time.Time) error {
+	if err := u.store.Save(appId, reviews); err != nil {
+		return err
+	}
+	if setter, ok := u.store.(modifiedSetter); ok {
+		if err := setter.SetModified(appId, modified); err != nil {
+			u.log().WarnContext(ctx, "unable to keep cache modified time", "app", appId, "error", err)
+		}
+	}
+	return nil
+}
+
+// DetectSpam flags suspected spam in every cached app's reviews, comparing them within and across apps, and returns
+// how many reviews' flags changed. The apps are compared without holding up saves; each app whose flags changed is
+// then reloaded and rewritten with them, so reviews saved in the meantime aren't lost and keep their flags until the
+// next run.
+func (u *Updater) DetectSpam(ctx context.Context) (int, error) {
+	cached, err := u.store.List()
+	if err != nil {
+		return 0, err
+	}
+	apps := map[string]models.AppReviews{}
+	for _, app := range cached {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		loaded, _, err := u.store.Load(app.AppId)
+		if err != nil {
+			u.log().WarnContext(ctx, "unable to load cached reviews to check for spam", "app", app.AppId, "error", err)
+			continue
+		}
+		apps[app.AppId] = loaded
+	}
+
+	total := 0
+	for appId, changed := range spam.Detect(apps, spam.Options{}) {
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+		if err := u.flagSpam(ctx, appId, apps[appId]); err != nil {
+			return total, err
+		}
+		total += changed
+	}
+	u.log().InfoContext(ctx, "checked cached reviews for spam", "apps", len(apps), "changed", total)
+	return total, nil
+}
+
+// flagSpam copies the spam flags of checked onto an app's cached reviews and rewrites them if any changed
+func (u *Updater) flagSpam(ctx context.Context, appId string, checked models.AppReviews) error {
+	u.saving.Lock()
+	defer u.saving.Unlock()
+	reviews, modified, err := u.store.Load(appId)
+	if err != nil {
+		u.log().WarnContext(ctx, "unable to load cached reviews to flag spam", "app", appId, "error", err)
+		return nil
+	}
+	if keepSpamFlags(reviews, checked) < 1 {
+		return nil
+	}
+	if err := u.rewrite(ctx, appId, reviews, modified); err != nil {
+		return fmt.Errorf("saving spam flags for app %s: %w", appId, err)
+	}
+	return nil
+}
+
+// keepSpamFlags gives reviews the spam flags of the reviews in previous with the same id and returns how many changed
+func keepSpamFlags(reviews models.AppReviews, previous models.AppReviews) int {
+	flags := make(map[string][]string, len(previous))
+	for _, review := range previous {
+		flags[review.Id] = review.Spam
+	}
+	changed := 0
+	for i := range reviews {
+		flagged, ok := flags[reviews[i].Id]
+		if !ok || slices.Equal(reviews[i].Spam, flagged) {
+			continue
+		}
+		reviews[i].Spam = flagged
+		changed++
+	}
+	return changed
+}
+
+// nextApp returns the next app cache to refresh or an error if there is nothing to update
+func (u *Updater) nextApp(apps []CachedApp) (string, error) {
+	now := u.clock.Now()
+	oldest := now
+	oldestId := ""
+	stale := 0
+	for _, app := range apps {
+		if now.Sub(app.Modified) >= u.maxAge {
+			stale++
+		}
+		if u.retries.has(app.AppId, config.DEFAULT_STOREFRONT, now) {
+			continue
+		}
+		if app.Modified.Before(oldest) {
+			oldest = app.Modified
+			oldestId = app.AppId
+		}
+	}
+
+	refreshQueueDepth.Set(float64(stale))
+	refreshLag.Set(max(now.Sub(oldest)-u.maxAge, 0).Seconds())
+
+	if len(oldestId) < 1 {
+		return oldestId, errors.New("could not find an app to refresh")
+	}
+
+	if now.Sub(oldest) < u.maxAge {
+		// The oldest file has been refreshed too recently to refresh again
+		return oldestId, errors.New("could not find an app to refresh")
+	}
+
+	return oldestId, nil
+}
+
+// UpdateNext looks at the cached apps and refreshes the oldest one that is expired (if any)
+func (u *Updater) UpdateNext(ctx context.Context) error {
+	apps, err := u.store.List()
+	if err != nil {
+		return err
+	}
+	return u.updateNext(ctx, apps)
+}
+
+func (u *Updater) updateNext(ctx context.Context, apps []CachedApp) error {
+	u.status.recordRun(u.clock.Now())
+	app, err := u.nextApp(apps)
+	if err != nil {
+		u.log().DebugContext(ctx, "no app to update", "reason", err)
+		return err
+	}
+
+	u.log().InfoContext(ctx, "refreshing cache", "app", app)
+	reviews, err := u.FetchAppReviews(ctx, app)
+	if err != nil {
+		// Keep the cache and move on to other apps until the app may be retried
+		u.log().ErrorContext(ctx, "error fetching app reviews for update", "app", app, "error", err)
+		retry := u.clock.Now().Add(time.Duration(config.REFRESH_RETRY_MINUTES) * time.Minute)
+		u.retries.add(app, config.DEFAULT_STOREFRONT, retry)
+	} else {
+		err = u.SaveReviews(ctx, app, reviews)
+	}
+	u.status.recordRefresh(app, u.clock.Now(), err)
+	u.log().InfoContext(ctx, "finished updating app", "app", app)
+	return err
+}
@@ -0,0 +1,447 @@
+package updater
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/marcuswu/app-reviews/anomaly"
+	"github.com/marcuswu/app-reviews/clock/clocktest"
+	"github.com/marcuswu/app-reviews/events"
+	"github.com/marcuswu/app-reviews/models"
+)
+
+// memoryStore is a Store that keeps reviews in memory so tests don't touch disk
+type memoryStore struct {
+	mu       sync.Mutex
+	reviews  map[string]models.AppReviews
+	modified map[string]time.Time
+	now      func() time.Time
+}
+
+func newMemoryStore(now func() time.Time) *memoryStore {
+	return &memoryStore{reviews: map[string]models.AppReviews{}, modified: map[string]time.Time{}, now: now}
+}
+
+func (ms *memoryStore) Load(appId string) (models.AppReviews, time.Time, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	reviews, ok := ms.reviews[appId]
+	if !ok {
+		return nil, time.Time{}, os.ErrNotExist
+	}
+	return reviews, ms.modified[appId], nil
+}
+
+func (ms *memoryStore) Save(appId string, reviews models.AppReviews) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.reviews[appId] = reviews
+	ms.modified[appId] = ms.now()
+	return nil
+}
+
+func (ms *memoryStore) Remove(appId string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.reviews, appId)
+	delete(ms.modified, appId)
+	return nil
+}
+
+func (ms *memoryStore) List() ([]CachedApp, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	apps := []CachedApp{}
+	for appId, modified := range ms.modified {
+		apps = append(apps, CachedApp{AppId: appId, Modified: modified})
+	}
+	return apps, nil
+}
+
+// feedPage renders an Apple review feed page with one review per updated time
+func feedPage(firstId int, updated ...time.Time) string {
+	entries := []string{}
+	for i, u := range updated {
+		entries = append(entries, fmt.Sprintf(`{"author":{"name":{"label":"Author %[1]d"},"uri":{"label":"unused"}},`+
+			`"updated":{"label":"%[2]s"},"im:rating":{"label":"5"},"im:version":{"label":"1.0"},"id":{"label":"%[1]d"},`+
+			`"title":{"label":"Title %[1]d"},"content":{"label":"Content %[1]d"},"link":{"attributes":{"href":"unused"}}}`,
+			firstId+i, u.Format(time.RFC3339)))
+	}
+	return fmt.Sprintf(`{"feed":{"entry":[%s]}}`, strings.Join(entries, ","))
+}
+
+// newFeedServer serves pages of reviews for app 1234 in the us storefront and 404 past the last page
+func newFeedServer(t *testing.T, pages map[int]string, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		*requests++
+		var page int
+		if _, err := fmt.Sscanf(req.URL.Path, "/us/rss/customerreviews/id=1234/sortBy=mostRecent/page=%d/json", &page); err != nil {
+			t.Errorf("unexpected request path %s", req.URL.Path)
+		}
+		body, ok := pages[page]
+		if !ok {
+			http.NotFound(res, req)
+			return
+		}
+		fmt.Fprint(res, body)
+	}))
+}
+
+func TestUpdaterFetchAndCache(t *testing.T) {
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	clock := clocktest.NewFake(now)
+	requests := 0
+	server := newFeedServer(t, map[int]string{
+		1: feedPage(1, now.Add(-time.Hour), now.Add(-2*time.Hour)),
+		2: feedPage(3, now.Add(-3*time.Hour)),
+	}, &requests)
+	defer server.Close()
+
+	store := newMemoryStore(clock.Now)
+	u := New(WithBaseURL(server.URL), WithHTTPClient(server.Client()), WithStore(store), WithClock(clock),
+		WithMaxAge(10*time.Minute))
+	ctx := context.Background()
+
+	reviews, err := u.FetchAppReviews(ctx, "1234")
+	if err != nil {
+		t.Fatalf("expected no error fetching reviews, got %s", err)
+	}
+	if len(reviews) != 3 || requests != 3 {
+		t.Errorf("expected 3 reviews from 3 requests, got %d from %d", len(reviews), requests)
+	}
+	if reviews[0].Language != "" {
+		t.Errorf("expected fetched reviews to be enriched when saved, not when fetched")
+	}
+
+	if _, err := u.LoadReviews(ctx, "1234"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected an uncached app to not exist, got %v", err)
+	}
+	if err := u.SaveReviews(ctx, "1234", reviews); err != nil {
+		t.Fatalf("expected no error saving reviews, got %s", err)
+	}
+	if cached, err := u.LoadReviews(ctx, "1234"); err != nil || len(cached) != 3 || cached[0].Language == "" {
+		t.Errorf("expected 3 cached and enriched reviews, got %d (%v)", len(cached), err)
+	}
+
+	clock.Advance(11 * time.Minute)
+	if _, err := u.LoadReviews(ctx, "1234"); !errors.Is(err, ErrStaleCache) {
+		t.Errorf("expected the cache to be stale, got %v", err)
+	}
+
+	requests = 0
+	if err := u.UpdateNext(ctx); err != nil {
+		t.Errorf("expected the stale app to be refreshed, got %s", err)
+	}
+	if requests != 3 {
+		t.Errorf("expected the refresh to fetch every page, got %d requests", requests)
+	}
+	if _, err := u.LoadReviews(ctx, "1234"); err != nil {
+		t.Errorf("expected the refreshed cache to be fresh, got %s", err)
+	}
+	if err := u.UpdateNext(ctx); err == nil {
+		t.Errorf("expected nothing to refresh right after a refresh")
+	}
+	if status := u.Status(ctx); !status.RefresherLastRun.Equal(clock.Now()) || len(status.Apps) != 1 {
+		t.Errorf("unexpected status %+v", status)
+	}
+}
+
+func TestUpdaterWindow(t *testing.T) {
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	requests := 0
+	server := newFeedServer(t, map[int]string{
+		1: feedPage(1, now.Add(-time.Hour), now.Add(-50*time.Hour)),
+		2: feedPage(3, now.Add(-60*time.Hour)),
+	}, &requests)
+	defer server.Close()
+
+	u := New(WithBaseURL(server.URL+"/"), WithStore(newMemoryStore(time.Now)), WithClock(clocktest.NewFake(now)),
+		WithWindow(48*time.Hour))
+	reviews, err := u.FetchAppReviews(context.Background(), "1234")
+	if err != nil {
+		t.Fatalf("expected no error fetching reviews, got %s", err)
+	}
+	if requests != 1 || len(reviews) != 2 {
+		t.Errorf("expected to stop after the first page reaching past the window, got %d requests and %d reviews",
+			requests, len(reviews))
+	}
+}
+
+func TestUpdaterNextAppWithFakeClock(t *testing.T) {
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	fake := clocktest.NewFake(now)
+	u := New(WithStore(newMemoryStore(fake.Now)), WithClock(fake), WithMaxAge(10*time.Minute))
+
+	apps := []CachedApp{
+		{AppId: "123456789", Modified: now.Add(-5 * time.Minute)},
+		{AppId: "987654321", Modified: now.Add(-9 * time.Minute)},
+	}
+	if next, err := u.nextApp(apps); err == nil || next != "987654321" {
+		t.Errorf("expected nothing to refresh yet, got %s (%v)", next, err)
+	}
+
+	fake.Advance(time.Minute)
+	if next, err := u.nextApp(apps); err != nil || next != "987654321" {
+		t.Errorf("expected 987654321 to need a refresh exactly at its maximum age, got %s (%v)", next, err)
+	}
+	if refreshQueueDepth.Value() != 1 {
+		t.Errorf("expected 1 stale app in the queue, got %f", refreshQueueDepth.Value())
+	}
+
+	fake.Advance(10 * time.Minute)
+	u.nextApp(apps)
+	if lag := refreshLag.Value(); lag != 600 {
+		t.Errorf("expected exactly 600 seconds of lag, got %f", lag)
+	}
+}
+
+func TestUpdaterRemembersAppsWithoutFeed(t *testing.T) {
+	fake := clocktest.NewFake(time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC))
+	requests := 0
+	server := newFeedServer(t, map[int]string{}, &requests)
+	defer server.Close()
+	u := New(WithStore(newMemoryStore(fake.Now)), WithClock(fake), WithBaseURL(server.URL),
+		WithNoFeedTTL(time.Hour))
+
+	for i := 0; i < 2; i++ {
+		if _, err := u.FetchAppReviews(context.Background(), "1234"); !errors.Is(err, ErrNoFeed) {
+			t.Fatalf("expected ErrNoFeed, got %v", err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected the second fetch to be answered from the no feed cache, got %d requests", requests)
+	}
+
+	fake.Advance(time.Hour)
+	u.FetchAppReviews(context.Background(), "1234")
+	if requests != 2 {
+		t.Errorf("expected Apple to be asked again after the ttl, got %d requests", requests)
+	}
+}
+
+func TestUpdaterUpstreamErrors(t *testing.T) {
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	fake := clocktest.NewFake(now)
+	statuses := map[int]int{1: http.StatusTooManyRequests}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		requests++
+		var page int
+		fmt.Sscanf(req.URL.Path, "/us/rss/customerreviews/id=1234/sortBy=mostRecent/page=%d/json", &page)
+		if status, ok := statuses[page]; ok {
+			res.WriteHeader(status)
+			return
+		}
+		fmt.Fprint(res, feedPage(page*10, now.Add(-time.Duration(page)*time.Hour)))
+	}))
+	defer server.Close()
+	store := newMemoryStore(fake.Now)
+	u := New(WithStore(store), WithClock(fake), WithBaseURL(server.URL), WithNoFeedTTL(time.Hour),
+		WithMaxAge(10*time.Minute))
+	ctx := context.Background()
+
+	// Throttling says nothing about whether the app has a feed, so it isn't remembered as having none
+	for i := 0; i < 2; i++ {
+		var upstreamErr *UpstreamError
+		if _, err := u.FetchAppReviews(ctx, "1234"); !errors.As(err, &upstreamErr) || upstreamErr.StatusCode != 429 {
+			t.Fatalf("expected an upstream error for a 429, got %v", err)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("expected every fetch to ask Apple, got %d requests", requests)
+	}
+
+	// A failure partway through the pages fails the fetch rather than returning some of the reviews
+	statuses = map[int]int{3: http.StatusServiceUnavailable}
+	if reviews, err := u.FetchAppReviews(ctx, "1234"); err == nil || len(reviews) > 0 {
+		t.Errorf("expected a failure on page 3 to fail the fetch, got %d reviews (%v)", len(reviews), err)
+	}
+
+	// A failed refresh keeps the cache and the refresher moves on to other apps until the app may be retried
+	cached := models.AppReviews{{Id: "1", Rating: 5, Updated: now.Add(-time.Hour)}}
+	store.Save("1234", cached)
+	store.Save("5678", cached)
+	fake.Advance(11 * time.Minute)
+	store.modified["5678"] = fake.Now().Add(-10 * time.Minute)
+	if err := u.UpdateNext(ctx); err == nil {
+		t.Errorf("expected the refresh to fail")
+	}
+	if reviews, _, _ := store.Load("1234"); len(reviews) != 1 {
+		t.Errorf("expected the failed refresh to keep the cache, got %d reviews", len(reviews))
+	}
+	apps, _ := store.List()
+	if next, err := u.nextApp(apps); err != nil || next != "5678" {
+		t.Errorf("expected the refresher to move on to 5678, got %q (%v)", next, err)
+	}
+}
+
+// sendQueued delivers the events queued so far, as SendEvents does once it is stopped
+func sendQueued(u *Updater) {
+	stopped, stop := context.WithCancel(context.Background())
+	stop()
+	u.SendEvents(stopped, context.Background())
+}
+
+func TestNotifyQueuesEvents(t *testing.T) {
+	delivering := make(chan struct{})
+	release := make(chan struct{})
+	sent := []string{}
+	u := New(WithEventQueue(1), WithNotifier(events.NotifierFunc(func(ctx context.Context, event events.Event) error {
+		delivering <- struct{}{}
+		<-release
+		sent = append(sent, event.Message)
+		return nil
+	})))
+	stopCtx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		u.SendEvents(stopCtx, context.Background())
+		close(done)
+	}()
+
+	// While the channel is stuck on the first event, the second waits in the queue and the third is dropped
+	ctx := context.Background()
+	u.notify(ctx, events.Event{Message: "first"})
+	<-delivering
+	u.notify(ctx, events.Event{Message: "second"})
+	u.notify(ctx, events.Event{Message: "third"})
+
+	stop()
+	close(release)
+	<-delivering
+	<-done
+	if strings.Join(sent, ",") != "first,second" {
+		t.Errorf("expected the queued events to be sent before stopping, got %v", sent)
+	}
+}
+
+func TestSaveReviewsNotifiesAnomalies(t *testing.T) {
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	fake := clocktest.NewFake(now)
+	sent := []events.Event{}
+	u := New(WithStore(newMemoryStore(fake.Now)), WithClock(fake),
+		WithNotifier(events.NotifierFunc(func(ctx context.Context, event events.Event) error {
+			sent = append(sent, event)
+			return nil
+		})))
+
+	// A week of 5 star reviews every 6 hours, then a burst of 1 star reviews
+	reviews := models.AppReviews{}
+	for hour := 24; hour < 8*24; hour += 6 {
+		reviews = append(reviews, models.AppReview{Id: fmt.Sprintf("old-%d", hour), Rating: 5,
+			Updated: now.Add(-time.Duration(hour) * time.Hour)})
+	}
+	for i := 0; i < 6; i++ {
+		reviews = append(reviews, models.AppReview{Id: fmt.Sprintf("new-%d", i), Rating: 1,
+			Content: fmt.Sprintf("Broken after update %d", i), Updated: now.Add(-time.Duration(i+1) * time.Hour)})
+	}
+
+	ctx := context.Background()
+	if err := u.SaveReviews(ctx, "1234", reviews); err != nil {
+		t.Fatalf("expected no error saving reviews, got %s", err)
+	}
+	sendQueued(u)
+	if len(sent) != 2 || sent[0].Type != events.RatingAnomaly || sent[0].Kind != anomaly.LowRatingSpike ||
+		sent[0].AppId != "1234" || len(sent[0].Reviews) != 6 || sent[1].Kind != anomaly.RatingDrop {
+		t.Fatalf("expected a low rating spike and rating drop for the 6 new reviews, got %+v", sent)
+	}
+
+	// The same anomalies aren't sent again on the next refresh
+	fake.Advance(10 * time.Minute)
+	if err := u.SaveReviews(ctx, "1234", reviews); err != nil {
+		t.Fatalf("expected no error saving reviews, got %s", err)
+	}
+	sendQueued(u)
+	if len(sent) != 2 {
+		t.Errorf("expected repeated anomalies to be quiet, got %d events", len(sent))
+	}
+}
+
+func TestSaveReviewsTracksEdits(t *testing.T) {
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	fake := clocktest.NewFake(now)
+	sent := []events.Event{}
+	u := New(WithStore(newMemoryStore(fake.Now)), WithClock(fake),
+		WithNotifier(events.NotifierFunc(func(ctx context.Context, event events.Event) error {
+			sent = append(sent, event)
+			return nil
+		})))
+	ctx := context.Background()
+
+	original := models.AppReviews{
+		{Id: "1", Rating: 1, Title: "Broken", Content: "Crashes on launch", Updated: now.Add(-48 * time.Hour)},
+		{Id: "2", Rating: 4, Title: "Good", Content: "Works well", Updated: now.Add(-48 * time.Hour)},
+	}
+	if err := u.SaveReviews(ctx, "1234", original); err != nil {
+		t.Fatalf("expected no error saving reviews, got %s", err)
+	}
+	edited := models.AppReviews{
+		{Id: "1", Rating: 5, Title: "Fixed", Content: "Works after the update", Updated: now.Add(-time.Hour)},
+		{Id: "2", Rating: 4, Title: "Good", Content: "Works really well", Updated: now.Add(-time.Hour)},
+	}
+	if err := u.SaveReviews(ctx, "1234", edited); err != nil {
+		t.Fatalf("expected no error saving reviews, got %s", err)
+	}
+
+	sendQueued(u)
+	if len(sent) != 2 || sent[0].Type != events.ReviewEdited || sent[0].Kind != events.RatingChanged ||
+		sent[0].Message != "review 1 rating changed from 1 to 5" || sent[1].Kind != events.TextChanged {
+		t.Fatalf("expected a rating change and a text change, got %+v", sent)
+	}
+	cached, err := u.LoadReviews(ctx, "1234")
+	if err != nil {
+		t.Fatalf("expected no error loading reviews, got %s", err)
+	}
+	for _, review := range cached {
+		if len(review.Revisions) != 1 || review.Revisions[0].Updated != now.Add(-48*time.Hour) {
+			t.Errorf("expected review %s to keep its earlier revision, got %+v", review.Id, review.Revisions)
+		}
+	}
+}
+
+func TestSaveReviewsKeepsRemovedReviews(t *testing.T) {
+	now := time.Date(2024, 3, 13, 12, 0, 0, 0, time.UTC)
+	fake := clocktest.NewFake(now)
+	sent := []events.Event{}
+	u := New(WithStore(newMemoryStore(fake.Now)), WithClock(fake),
+		WithNotifier(events.NotifierFunc(func(ctx context.Context, event events.Event) error {
+			sent = append(sent, event)
+			return nil
+		})))
+	ctx := context.Background()
+
+	reviews := models.AppReviews{
+		{Id: "3", Rating: 1, Title: "Scam", Updated: now.Add(-time.Hour)},
+		{Id: "2", Rating: 4, Title: "Good", Updated: now.Add(-2 * time.Hour)},
+		{Id: "1", Rating: 5, Title: "Great", Updated: now.Add(-3 * time.Hour)},
+	}
+	if err := u.SaveReviews(ctx, "1234", reviews); err != nil {
+		t.Fatalf("expected no error saving reviews, got %s", err)
+	}
+	fake.Advance(10 * time.Minute)
+	if err := u.SaveReviews(ctx, "1234", models.AppReviews{reviews[1], reviews[2]}); err != nil {
+		t.Fatalf("expected no error saving reviews, got %s", err)
+	}
+
+	sendQueued(u)
+	if len(sent) != 1 || sent[0].Type != events.ReviewRemoved || len(sent[0].Reviews) != 1 ||
+		sent[0].Reviews[0].Id != "3" || sent[0].Message != "review 3 disappeared from the feed" {
+		t.Fatalf("expected a removal event for review 3, got %+v", sent)
+	}
+	cached, err := u.LoadReviews(ctx, "1234")
+	if err != nil {
+		t.Fatalf("expected no error loading reviews, got %s", err)
+	}
+	removed := cached.Filter(models.ReviewFilter{Removed: models.RemovedOnly})
+	if len(cached) != 3 || len(removed) != 1 || !removed[0].RemovedAt.Equal(fake.Now()) {
+		t.Errorf("expected review 3 to be kept and marked removed, got %+v", cached)
+	}
+}
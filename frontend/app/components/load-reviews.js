@@ -4,7 +4,11 @@ export default async function LoadReviews(appId, hours) {
     "use server";
         console.log("loading reviews for app id " + appId + " and hours " + hours);
         try {
-        let reviewsReq = await fetch('http://localhost:8000/' + appId + '?hours=' + hours);
+        let headers = {};
+        if (process.env.REVIEWS_API_KEY) {
+            headers['X-API-Key'] = process.env.REVIEWS_API_KEY;
+        }
+        let reviewsReq = await fetch('http://localhost:8000/' + appId + '?hours=' + hours, { headers: headers });
         console.log('created fetch');
         let reviews = await reviewsReq.json();
         console.log('got reviews ', reviews);